@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	swaggerDocs "ridash/docs"
 	customMiddleware "ridash/middleware"
@@ -16,7 +17,11 @@ import (
 	"ridash/router"
 	"ridash/utils/config"
 	"ridash/utils/id"
+	"ridash/utils/keyring"
 	"ridash/utils/logger"
+	"ridash/utils/loginguard"
+	"ridash/utils/mailer"
+	"ridash/utils/revocation"
 )
 
 // @title Ridash API
@@ -38,7 +43,7 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
+// @description Type "Bearer" followed by a space and a JWT access token or a personal access token.
 
 func main() {
 	// Load env
@@ -66,6 +71,33 @@ func main() {
 		zap.L().Fatal("Failed to initialize database:", zap.Error(err))
 	}
 
+	// Initialize the mailer and drain the outbox in the background
+	mail, err := mailer.Init(db)
+	if err != nil {
+		zap.L().Fatal("Failed to initialize mailer:", zap.Error(err))
+	}
+	go mail.Run(context.Background())
+
+	// Load the access token signing keys and rotate them in the background
+	keys, err := keyring.Init(db)
+	if err != nil {
+		zap.L().Fatal("Failed to initialize signing keys:", zap.Error(err))
+	}
+	go keys.Run(context.Background())
+
+	// Load the access token denylist and follow its changes
+	revocations, err := revocation.Init(db)
+	if err != nil {
+		zap.L().Fatal("Failed to initialize access token revocations:", zap.Error(err))
+	}
+	go revocations.Run(context.Background())
+
+	// Share the failed logins between instances and prune them
+	go loginguard.Init(db).Run(context.Background())
+
+	// Accept personal access tokens alongside access tokens
+	customMiddleware.UsePersonalAccessTokens(db)
+
 	// Setup routes
 	routes(e, db)
 	zap.L().Fatal("Api server crash", zap.Error(e.Start(":"+env.AppPort)))
@@ -78,12 +110,17 @@ func routes(e *echo.Echo, db *pgxpool.Pool) {
 		e.GET("/reference", scalarDocsHandler())
 	}
 
+	router.WellKnownRouter(e.Group("/.well-known"))
+
 	// User routes
 	api := e.Group("/api")
 	router.AuthRouter(api, db)
 	router.TeamRouter(api, db)
 	router.FolderRouter(api, db)
 	router.DocumentRouter(api, db)
+	router.InvitationRouter(api, db)
+	router.UserRouter(api, db)
+	router.AdminRouter(api, db)
 }
 
 func scalarDocsHandler() echo.HandlerFunc {
@@ -0,0 +1,14 @@
+package admin
+
+import (
+	"ridash/utils/loginguard"
+	"ridash/utils/revocation"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AdminHandler struct {
+	DB          *pgxpool.Pool
+	Revocations *revocation.Store
+	LoginGuard  *loginguard.Guard
+}
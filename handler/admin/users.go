@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"net/http"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | DisableUser                                  |
+// +----------------------------------------------+
+
+// DisableUser godoc
+// @Summary Disable a user
+// @Description Signs the user out everywhere and refuses their logins, access tokens and personal access tokens until they are enabled again. Admins only
+// @Tags admin
+// @Produce json
+// @Param userID path string true "User ID"
+// @Success 200 {object} response.SuccessResponse "User disabled successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID or the admin's own user"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/users/{userID}/disable [post]
+// @Security BearerAuth
+func (h *AdminHandler) DisableUser(c echo.Context) error {
+	adminID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || adminID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	// Locking everyone out starts with the admin doing it
+	if userID == *adminID {
+		return echo.NewHTTPError(http.StatusBadRequest, "Admins can't disable themselves")
+	}
+
+	return h.setUserDisabled(c, *adminID, userID, true)
+}
+
+// +----------------------------------------------+
+// | EnableUser                                   |
+// +----------------------------------------------+
+
+// EnableUser godoc
+// @Summary Enable a user
+// @Description Lets a disabled user sign in again, their personal access tokens work again too. Admins only
+// @Tags admin
+// @Produce json
+// @Param userID path string true "User ID"
+// @Success 200 {object} response.SuccessResponse "User enabled successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/users/{userID}/enable [post]
+// @Security BearerAuth
+func (h *AdminHandler) EnableUser(c echo.Context) error {
+	adminID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || adminID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	return h.setUserDisabled(c, *adminID, userID, false)
+}
+
+// setUserDisabled disables or enables the user and has every instance reload the denylist
+func (h *AdminHandler) setUserDisabled(c echo.Context, adminID int64, userID int64, disabled bool) error {
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	user, err := repository.GetUserByIDForUpdate(c.Request().Context(), tx, userID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+
+	// Deleted users have nothing left to sign in with
+	if user == nil || user.DeletedAt != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	now := time.Now()
+	var disabledAt *time.Time
+	if disabled {
+		disabledAt = &now
+
+		if err := repository.RevokeRefreshTokensByUserID(c.Request().Context(), tx, userID, now); err != nil {
+			zap.L().Error("Failed to revoke refresh tokens", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke refresh tokens")
+		}
+	}
+
+	if err := repository.SetUserDisabledAt(c.Request().Context(), tx, userID, disabledAt, now); err != nil {
+		zap.L().Error("Failed to update user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update user")
+	}
+
+	if err := repository.NotifyAccessTokenRevocations(c.Request().Context(), tx); err != nil {
+		zap.L().Error("Failed to notify access token revocations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to notify access token revocations")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	// The other instances reload when Postgres notifies them
+	if err := h.Revocations.Reload(c.Request().Context()); err != nil {
+		zap.L().Error("Failed to reload access token revocations", zap.Error(err))
+	}
+
+	if disabled {
+		zap.L().Info("User disabled", zap.Int64("user_id", userID), zap.Int64("admin_id", adminID))
+		return c.JSON(http.StatusOK, response.SuccessMessage("User disabled successfully"))
+	}
+
+	zap.L().Info("User enabled", zap.Int64("user_id", userID), zap.Int64("admin_id", adminID))
+	return c.JSON(http.StatusOK, response.SuccessMessage("User enabled successfully"))
+}
+
+// +----------------------------------------------+
+// | UnlockUser                                   |
+// +----------------------------------------------+
+
+// UnlockUser godoc
+// @Summary Unlock a user
+// @Description Lifts the lockouts and forgets the failed logins of every email address of the user. Admins only
+// @Tags admin
+// @Produce json
+// @Param userID path string true "User ID"
+// @Success 200 {object} response.SuccessResponse "User unlocked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Admin access required"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/users/{userID}/unlock [post]
+// @Security BearerAuth
+func (h *AdminHandler) UnlockUser(c echo.Context) error {
+	adminID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || adminID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	userID, err := strconv.ParseInt(c.Param("userID"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	user, err := repository.GetUserByID(c.Request().Context(), tx, userID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+
+	// Deleted users have nothing left to sign in with
+	if user == nil || user.DeletedAt != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	accounts, err := repository.ListAccountsByUserID(c.Request().Context(), tx, userID)
+	if err != nil {
+		zap.L().Error("Failed to list accounts", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list accounts")
+	}
+
+	emails := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if account.Email != "" {
+			emails = append(emails, account.Email)
+		}
+	}
+
+	if err := h.LoginGuard.UnlockEmails(c.Request().Context(), tx, emails); err != nil {
+		zap.L().Error("Failed to unlock emails", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to unlock emails")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("User unlocked", zap.Int64("user_id", userID), zap.Int64("admin_id", *adminID))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("User unlocked successfully"))
+}
@@ -2,11 +2,21 @@ package auth
 
 import (
 	"ridash/utils/config"
+	"ridash/utils/keyring"
+	"ridash/utils/loginguard"
+	"ridash/utils/mailer"
+	"ridash/utils/revocation"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type AuthHandler struct {
-	DB *pgxpool.Pool
+	DB          *pgxpool.Pool
 	OAuthConfig *config.OAuthConfig
-}
\ No newline at end of file
+	Mailer      *mailer.Mailer
+	WebAuthn    *webauthn.WebAuthn
+	Keyring     *keyring.Keyring
+	Revocations *revocation.Store
+	LoginGuard  *loginguard.Guard
+}
@@ -3,9 +3,12 @@ package auth
 import (
 	"encoding/json"
 	"net/http"
+	"ridash/models"
 	"ridash/repository"
+	"ridash/utils/config"
 	"ridash/utils/encrypt"
 	"ridash/utils/response"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
@@ -24,13 +27,16 @@ type LoginRequest struct {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticates a user with email and password, returns a refresh token cookie
+// @Description Authenticates a user with email and password, returns a refresh token cookie. Users with TOTP enabled get an MFA token to exchange at /auth/login/mfa instead. Repeated failures make each attempt wait longer, then lock the email and send an unlock link to it
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login request with email and password"
-// @Success 200 {object} response.SuccessResponse "Login successful, refresh token set in cookie"
+// @Success 200 {object} response.SuccessResponse{data=mfaChallengeResponse} "Login successful, refresh token set in cookie, or second factor required"
 // @Failure 400 {object} response.ErrorResponse "Invalid request body or invalid credentials"
+// @Failure 403 {object} response.ErrorResponse "Email address is not verified or the account is disabled"
+// @Failure 423 {object} response.ErrorResponse "Too many failed logins locked the email, see the Retry-After header"
+// @Failure 429 {object} response.ErrorResponse "Too many failed logins, see the Retry-After header"
 // @Failure 500 {object} response.ErrorResponse "Internal server error (transaction, database, or password verification failure)"
 // @Failure 502 {object} response.ErrorResponse "Invalid request body format"
 // @Router /auth/login [post]
@@ -54,6 +60,11 @@ func (h *AuthHandler) Login(c echo.Context) error {
 	}
 	defer repository.DeferRollback(tx, c.Request().Context())
 
+	// Refuse the attempt while the email or the IP address has to wait
+	if err := h.LoginGuard.Check(c.Request().Context(), tx, loginRequest.Email, c.RealIP(), time.Now()); err != nil {
+		return loginGuardHTTPError(c, err)
+	}
+
 	// Get the user by email
 	user, err := repository.GetUserByEmail(c.Request().Context(), tx, loginRequest.Email)
 	if err != nil {
@@ -61,37 +72,121 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user by email")
 	}
 
-	// TODO: Need to change this
-	// If the user is not found, return an error
-	if user == nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid credentials")
+	// Unknown emails and users without a password are compared against a dummy hash, so they fail like a wrong password in the same time
+	passwordHash, err := getDummyPasswordHash()
+	if err != nil {
+		zap.L().Error("Failed to hash password", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to hash password")
+	}
+	hasPassword := user != nil && user.PasswordHash != nil
+	if hasPassword {
+		passwordHash = *user.PasswordHash
 	}
 
 	// Compare the password and hash
-	match, err := encrypt.ComparePasswordAndHash(loginRequest.Password, *user.PasswordHash)
+	match, err := encrypt.ComparePasswordAndHash(loginRequest.Password, passwordHash)
 	if err != nil {
 		zap.L().Error("Failed to compare password and hash", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to compare password and hash")
 	}
+	match = match && hasPassword
 
-	// If the password is not correct, return an error
+	// If the password is not correct, count the failure and return an error
 	if !match {
+		if err := h.recordLoginFailure(c, tx, loginRequest.Email, user); err != nil {
+			zap.L().Error("Failed to record login failure", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to record login failure")
+		}
+
+		// The failure must stick even though the request fails
+		if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+			zap.L().Error("Failed to commit transaction", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+		}
+
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid credentials")
 	}
 
+	// The right password forgets the previous failures of the email
+	if err := h.LoginGuard.RecordSuccess(c.Request().Context(), tx, loginRequest.Email, time.Now()); err != nil {
+		zap.L().Error("Failed to record login success", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to record login success")
+	}
+
+	// Hashes made before the cost was raised are upgraded while the password is known
+	needsUpgrade, upgradeParams, err := encrypt.Argon2idHashNeedsUpgrade(*user.PasswordHash, config.GetArgon2idParams())
+	if err != nil {
+		zap.L().Error("Failed to check password hash parameters", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check password hash parameters")
+	}
+
+	if needsUpgrade {
+		passwordHash, err := encrypt.CreateArgon2idHash(loginRequest.Password, upgradeParams)
+		if err != nil {
+			zap.L().Error("Failed to hash password", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to hash password")
+		}
+
+		if err := repository.UpdateUserPasswordHash(c.Request().Context(), tx, user.ID, passwordHash, time.Now()); err != nil {
+			zap.L().Error("Failed to update password", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update password")
+		}
+
+		zap.L().Info("Password hash upgraded", zap.Int64("user_id", user.ID))
+	}
+
+	// Block unverified email accounts when verification is required to log in
+	if config.Env().EmailVerificationRequired == config.EmailVerificationLogin {
+		account, err := repository.GetAccountByProviderAndEmail(c.Request().Context(), tx, models.ProviderEmail, loginRequest.Email)
+		if err != nil {
+			zap.L().Error("Failed to get account by email", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account by email")
+		}
+
+		if account == nil || account.VerifiedAt == nil {
+			return echo.NewHTTPError(http.StatusForbidden, "Email address is not verified")
+		}
+	}
+
+	// Users with an authenticator finish the login at /auth/login/mfa
+	userTOTP, err := repository.GetUserTOTP(c.Request().Context(), tx, user.ID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	if userTOTP != nil && userTOTP.ConfirmedAt != nil {
+		mfaToken, err := generateMFAChallengeToken(c.Request().Context(), tx, user.ID)
+		if err != nil {
+			zap.L().Error("Failed to generate mfa token", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate mfa token")
+		}
+
+		if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+			zap.L().Error("Failed to commit transaction", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+		}
+
+		return c.JSON(http.StatusOK, response.Success("Second factor required", mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}))
+	}
+
 	// Generate the refresh token
 	refreshToken, err := generateTokenAndSaveRefreshToken(c, tx, user.ID)
 	if err != nil {
-		zap.L().Error("Failed to generate refresh token", zap.Error(err))
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate refresh token")
+		return refreshTokenHTTPError(err)
 	}
 
 	// Commit the transaction
-	repository.CommitTransaction(tx, c.Request().Context())
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
 
 	// Generate the refresh token cookie
-	refreshTokenCookie := generateRefreshTokenCookie(refreshToken)
-	c.SetCookie(&refreshTokenCookie)
+	setRefreshTokenCookie(c, refreshToken)
 
 	return c.JSON(http.StatusOK, response.SuccessMessage("Login successful"))
 }
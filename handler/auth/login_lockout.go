@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/id"
+	"ridash/utils/loginguard"
+	"ridash/utils/mailer"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// loginGuardHTTPError turns a refused login attempt into the response, with the Retry-After header
+func loginGuardHTTPError(c echo.Context, err error) error {
+	var blocked *loginguard.Blocked
+	if !errors.As(err, &blocked) {
+		zap.L().Error("Failed to check login attempts", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check login attempts")
+	}
+
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+
+	if blocked.Locked {
+		return echo.NewHTTPError(http.StatusLocked, "Too many failed logins, the account is temporarily locked")
+	}
+
+	return echo.NewHTTPError(http.StatusTooManyRequests, "Too many failed logins, try again later")
+}
+
+// recordLoginFailure counts a failed password login. When it locks the email of an existing user,
+// a security event is recorded and the unlock link is emailed.
+func (h *AuthHandler) recordLoginFailure(c echo.Context, tx pgx.Tx, email string, user *models.User) error {
+	ctx := c.Request().Context()
+	now := time.Now()
+	ip := c.RealIP()
+
+	lockout, err := h.LoginGuard.RecordFailure(ctx, tx, email, ip, now)
+	if err != nil {
+		return err
+	}
+
+	// Unknown emails are locked all the same, so the response doesn't reveal them
+	if lockout == nil || user == nil {
+		return nil
+	}
+
+	zap.L().Warn("Account locked after failed logins", zap.Int64("user_id", user.ID), zap.String("ip", ip))
+
+	eventID, err := id.GetID()
+	if err != nil {
+		return fmt.Errorf("failed to generate security event ID: %w", err)
+	}
+
+	userAgent := c.Request().UserAgent()
+	err = repository.CreateSecurityEvent(ctx, tx, models.SecurityEvent{
+		ID:        eventID,
+		UserID:    user.ID,
+		Type:      models.SecurityEventAccountLocked,
+		IP:        &ip,
+		UserAgent: &userAgent,
+		Details: map[string]any{
+			"locked_until": lockout.LockedUntil,
+		},
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+
+	// The email is how the user unlocks the account, it is sent even without security alerts
+	return h.Mailer.Enqueue(ctx, tx, email, mailer.TemplateAccountLocked, mailer.AccountLockedData{
+		DisplayName: user.DisplayName,
+		IP:          ip,
+		UnlockURL:   config.Env().FrontendURL + "/unlock-account?token=" + lockout.UnlockToken,
+		LockedUntil: lockout.LockedUntil,
+	})
+}
+
+// +----------------------------------------------+
+// | Unlock Login                                 |
+// +----------------------------------------------+
+
+type unlockLoginRequest struct {
+	Token string `json:"token" validate:"required,max=255" example:"q8Xn2..."`
+}
+
+// UnlockLogin godoc
+// @Summary Unlock an account
+// @Description Lifts the lockout of an email after too many failed logins, with the token from the email sent when it was locked
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body unlockLoginRequest true "Unlock token"
+// @Success 200 {object} response.SuccessResponse "Account unlocked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body or invalid or expired token"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/login/unlock [post]
+func (h *AuthHandler) UnlockLogin(c echo.Context) error {
+	var req unlockLoginRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	unlocked, err := h.LoginGuard.Unlock(c.Request().Context(), tx, req.Token, time.Now())
+	if err != nil {
+		zap.L().Error("Failed to unlock login", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to unlock login")
+	}
+
+	// The lockout may also have ended or been lifted by an admin
+	if !unlocked {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid or expired token")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Account unlocked by email", zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Account unlocked successfully"))
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"net/http"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/response"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | Logout                                       |
+// +----------------------------------------------+
+
+// Logout godoc
+// @Summary Logout
+// @Description Revokes the refresh token from the cookie and clears the cookie. The access token sent in the Authorization header, if any, is revoked right away
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse "Logout successful"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+	// Always clear the cookie, even if the token is already gone
+	clearRefreshTokenCookies(c)
+
+	// The route isn't authenticated, an invalid access token is simply not revoked
+	validAccessToken, accessTokenClaims, _ := h.Keyring.ValidateAccessTokenAndGetClaims(strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer "))
+
+	userRefreshToken, err := authutil.GetRefreshTokenCookie(c)
+	hasRefreshToken := err == nil && userRefreshToken.Value != ""
+	if !hasRefreshToken && !validAccessToken {
+		return c.JSON(http.StatusOK, response.SuccessMessage("Logout successful"))
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	if hasRefreshToken {
+		refreshToken, err := repository.GetRefreshTokenByToken(c.Request().Context(), tx, userRefreshToken.Value)
+		if err != nil {
+			zap.L().Error("Failed to get refresh token by token", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get refresh token by token")
+		}
+
+		if refreshToken != nil && refreshToken.RevokedAt == nil {
+			if err := repository.RevokeRefreshToken(c.Request().Context(), tx, refreshToken.ID, time.Now()); err != nil {
+				zap.L().Error("Failed to revoke refresh token", zap.Error(err))
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke refresh token")
+			}
+		}
+	}
+
+	if validAccessToken {
+		if err := h.Revocations.RevokeToken(c.Request().Context(), tx, accessTokenClaims); err != nil {
+			zap.L().Error("Failed to revoke access token", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke access token")
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	h.reloadRevocations(c)
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Logout successful"))
+}
+
+// +----------------------------------------------+
+// | Logout All                                   |
+// +----------------------------------------------+
+
+// LogoutAll godoc
+// @Summary Logout everywhere
+// @Description Revokes every refresh token and access token of the authenticated user and clears the cookie
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse "Logged out of all sessions"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/logout-all [post]
+// @Security BearerAuth
+func (h *AuthHandler) LogoutAll(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	now := time.Now()
+	if err := repository.RevokeRefreshTokensByUserID(c.Request().Context(), tx, *userID, now); err != nil {
+		zap.L().Error("Failed to revoke refresh tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke refresh tokens")
+	}
+
+	if err := h.Revocations.RevokeUser(c.Request().Context(), tx, *userID, now); err != nil {
+		zap.L().Error("Failed to revoke access tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke access tokens")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	h.reloadRevocations(c)
+
+	clearRefreshTokenCookies(c)
+
+	zap.L().Info("User logged out of all sessions", zap.Int64("user_id", *userID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Logged out of all sessions"))
+}
@@ -0,0 +1,359 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"ridash/utils/invitation"
+	"ridash/utils/mailer"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | Request Magic Link                           |
+// +----------------------------------------------+
+
+type magicLinkRequest struct {
+	Email           string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+	InvitationToken string `json:"invitation_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // Lets invited people sign up when self-signup is disabled
+}
+
+// RequestMagicLink godoc
+// @Summary Request a login link
+// @Description Emails a single-use signed login link. Unknown addresses get one too when they can sign up, the response is the same either way. Links are throttled per address and per IP address
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body magicLinkRequest true "Email address to log in with"
+// @Success 200 {object} response.SuccessResponse "Login link sent if the address can log in"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body"
+// @Failure 429 {object} response.ErrorResponse "Too many login links requested from this IP address, see the Retry-After header"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/magic-link [post]
+func (h *AuthHandler) RequestMagicLink(c echo.Context) error {
+	var req magicLinkRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	now := time.Now()
+	window := time.Duration(config.Env().MagicLinkLimitWindow) * time.Second
+
+	ipLinks, oldestIPLink, err := repository.CountMagicLinksByIPSince(c.Request().Context(), tx, c.RealIP(), now.Add(-window))
+	if err != nil {
+		zap.L().Error("Failed to count magic links", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to count magic links")
+	}
+
+	if ipLinks >= config.Env().MagicLinkIPLimit && oldestIPLink != nil {
+		zap.L().Warn("Magic links throttled", zap.String("ip", c.RealIP()))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(oldestIPLink.Add(window).Sub(now).Seconds()))))
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Too many login links requested, try again later")
+	}
+
+	account, err := repository.GetAccountByEmail(c.Request().Context(), tx, req.Email)
+	if err != nil {
+		zap.L().Error("Failed to get account by email", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account by email")
+	}
+
+	// Don't reveal whether the address is registered
+	if account != nil || selfSignupAllowed(req.InvitationToken) {
+		emailLinks, err := repository.CountMagicLinksByEmailSince(c.Request().Context(), tx, req.Email, now.Add(-window))
+		if err != nil {
+			zap.L().Error("Failed to count magic links", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to count magic links")
+		}
+
+		// A refusal would tell the address apart from unknown ones, the inbox just stops filling up
+		if emailLinks >= config.Env().MagicLinkEmailLimit {
+			zap.L().Warn("Magic links throttled", zap.String("ip", c.RealIP()))
+		} else if err := sendMagicLink(c.Request().Context(), tx, h.Mailer, req.Email, req.InvitationToken, c.RealIP()); err != nil {
+			zap.L().Error("Failed to send magic link", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to send magic link")
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Login link sent if the address can log in"))
+}
+
+// +----------------------------------------------+
+// | Consume Magic Link                           |
+// +----------------------------------------------+
+
+type consumeMagicLinkRequest struct {
+	Token string `json:"token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// ConsumeMagicLink godoc
+// @Summary Log in with a login link
+// @Description Exchanges the token of a login link for a refresh token cookie and an access token, creating the user on first use. Users with TOTP enabled get an MFA token to exchange at /auth/login/mfa instead
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body consumeMagicLinkRequest true "Token from the login link"
+// @Success 200 {object} response.SuccessResponse{data=map[string]string} "Login successful, refresh token set in cookie, or second factor required"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body, invitation, or invalid, used or expired link"
+// @Failure 403 {object} response.ErrorResponse "Self-signup is disabled and there is no invitation, the invitation was sent to another email address, or the account is disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/magic-link/consume [post]
+func (h *AuthHandler) ConsumeMagicLink(c echo.Context) error {
+	var req consumeMagicLinkRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	valid, claims, err := secret.ValidateEmailTokenAndGetClaims(req.Token, encrypt.TokenPurposeMagicLink)
+	if err != nil || !valid {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid login link")
+	}
+
+	linkID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid login link")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Lock the link so two requests can't both use it
+	link, err := repository.GetMagicLinkByIDForUpdate(c.Request().Context(), tx, linkID)
+	if err != nil {
+		zap.L().Error("Failed to get magic link", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get magic link")
+	}
+
+	now := time.Now()
+	if link == nil || link.Email != claims.Email || link.UsedAt != nil || now.After(link.ExpiresAt) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid login link")
+	}
+
+	if err := repository.MarkMagicLinkUsed(c.Request().Context(), tx, link.ID, now); err != nil {
+		zap.L().Error("Failed to mark magic link used", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to mark magic link used")
+	}
+
+	// Prefer the email account, any account with the address proves the same ownership
+	account, err := repository.GetAccountByProviderAndEmail(c.Request().Context(), tx, models.ProviderEmail, link.Email)
+	if err == nil && account == nil {
+		account, err = repository.GetAccountByEmail(c.Request().Context(), tx, link.Email)
+	}
+	if err != nil {
+		zap.L().Error("Failed to get account by email", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account by email")
+	}
+
+	invitationToken := ""
+	if link.InvitationToken != nil {
+		invitationToken = *link.InvitationToken
+	}
+
+	var userID int64
+	if account == nil {
+		if !selfSignupAllowed(invitationToken) {
+			return echo.NewHTTPError(http.StatusForbidden, "Self-signup is disabled")
+		}
+
+		user, newAccount, err := generateMagicLinkUser(link.Email, now)
+		if err != nil {
+			zap.L().Error("Failed to generate user", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate user")
+		}
+
+		if err := repository.CreateUserAndAccount(c.Request().Context(), tx, user, newAccount); err != nil {
+			zap.L().Error("Failed to create user", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create user")
+		}
+
+		userID = user.ID
+		zap.L().Info("Magic link new user registered", zap.Int64("user_id", userID), zap.String("ip", c.RealIP()))
+	} else {
+		userID = account.UserID
+
+		// Opening the link proves the address
+		if account.Provider == models.ProviderEmail && account.VerifiedAt == nil {
+			if err := repository.UpdateAccountVerifiedAt(c.Request().Context(), tx, account.ID, now); err != nil {
+				zap.L().Error("Failed to verify account", zap.Error(err))
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify account")
+			}
+		}
+	}
+
+	// Join the team the user was invited to
+	if invitationToken != "" {
+		// Opening the link proves its address, the invitation must have been sent there
+		if _, err := invitation.Accept(c.Request().Context(), tx, invitationToken, userID, []string{link.Email}); err != nil {
+			if errors.Is(err, invitation.ErrEmailMismatch) {
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+			if invitation.IsClientError(err) {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			zap.L().Error("Failed to accept invitation", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to accept invitation")
+		}
+	}
+
+	// The link is a single factor, users with an authenticator finish at /auth/login/mfa
+	userTOTP, err := repository.GetUserTOTP(c.Request().Context(), tx, userID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	if userTOTP != nil && userTOTP.ConfirmedAt != nil {
+		mfaToken, err := generateMFAChallengeToken(c.Request().Context(), tx, userID)
+		if err != nil {
+			zap.L().Error("Failed to generate mfa token", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate mfa token")
+		}
+
+		if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+			zap.L().Error("Failed to commit transaction", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+		}
+
+		return c.JSON(http.StatusOK, response.Success("Second factor required", mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}))
+	}
+
+	// Generate the refresh token
+	refreshToken, err := generateTokenAndSaveRefreshToken(c, tx, userID)
+	if err != nil {
+		return refreshTokenHTTPError(err)
+	}
+
+	accessToken, err := h.Keyring.GenerateAccessToken(config.Env().AppName, strconv.FormatInt(userID, 10), now.Add(time.Duration(config.Env().AccessTokenExpiresAt)*time.Second))
+	if err != nil {
+		zap.L().Error("Failed to generate access token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate access token")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	// Generate the refresh token cookie
+	setRefreshTokenCookie(c, refreshToken)
+
+	zap.L().Info("Magic link login successful", zap.Int64("user_id", userID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.Success("Login successful", map[string]string{
+		"access_token": accessToken,
+	}))
+}
+
+// sendMagicLink stores a new magic link and queues the email carrying its signed token
+func sendMagicLink(ctx context.Context, tx pgx.Tx, m *mailer.Mailer, email string, invitationToken string, ip string) error {
+	linkID, err := id.GetID()
+	if err != nil {
+		return fmt.Errorf("failed to generate magic link ID: %w", err)
+	}
+
+	now := time.Now()
+	link := models.MagicLink{
+		ID:        linkID,
+		Email:     email,
+		IP:        &ip,
+		ExpiresAt: now.Add(time.Duration(config.Env().MagicLinkExpiresAt) * time.Second),
+		CreatedAt: now,
+	}
+	if invitationToken != "" {
+		link.InvitationToken = &invitationToken
+	}
+
+	if err := repository.CreateMagicLink(ctx, tx, link); err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	token, err := secret.GenerateEmailToken(encrypt.TokenPurposeMagicLink, strconv.FormatInt(link.ID, 10), link.Email, link.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to generate magic link token: %w", err)
+	}
+
+	return m.Enqueue(ctx, tx, link.Email, mailer.TemplateMagicLink, mailer.MagicLinkData{
+		LoginURL:  config.Env().FrontendURL + "/magic-link?token=" + token,
+		ExpiresAt: link.ExpiresAt,
+	})
+}
+
+// generateMagicLinkUser generates a passwordless user with a verified email account
+func generateMagicLinkUser(email string, now time.Time) (models.User, models.Account, error) {
+	userID, err := id.GetID()
+	if err != nil {
+		return models.User{}, models.Account{}, fmt.Errorf("failed to generate user ID: %w", err)
+	}
+
+	accountID, err := id.GetID()
+	if err != nil {
+		return models.User{}, models.Account{}, fmt.Errorf("failed to generate account ID: %w", err)
+	}
+
+	user := models.User{
+		ID:          userID,
+		DisplayName: encrypt.GenerateRandomUserDisplayName(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	account := models.Account{
+		ID:             accountID,
+		Provider:       models.ProviderEmail,
+		ProviderUserID: strconv.FormatInt(userID, 10),
+		UserID:         userID,
+		Email:          email,
+		VerifiedAt:     &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	return user, account, nil
+}
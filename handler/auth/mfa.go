@@ -0,0 +1,613 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"ridash/utils/mfa"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// mfaChallengeResponse is returned by Login instead of a session when a second factor is needed
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // Exchanged at /auth/login/mfa with a code
+}
+
+type mfaStatusResponse struct {
+	TOTPEnabled            bool `json:"totp_enabled" example:"true"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining" example:"10"`
+}
+
+type totpEnrollmentResponse struct {
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXP"`                                                         // For authenticator apps that can't scan QR codes
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/ridash:user@example.com?secret=JBSWY3DPEHPK3PXP"` // Encode as a QR code
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"abcde-12345"` // Shown once, only hashes are stored
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6" example:"123456"`
+}
+
+type secondFactorRequest struct {
+	Code         string `json:"code,omitempty" validate:"required_without=RecoveryCode,omitempty,numeric,len=6" example:"123456"`
+	RecoveryCode string `json:"recovery_code,omitempty" validate:"required_without=Code,omitempty,max=32" example:"abcde-12345"`
+}
+
+type loginMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	secondFactorRequest
+}
+
+// +----------------------------------------------+
+// | MFA Status                                   |
+// +----------------------------------------------+
+
+// MFAStatus godoc
+// @Summary Get MFA status
+// @Description Tells whether TOTP is enabled and how many recovery codes are left
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=mfaStatusResponse} "MFA status retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/mfa [get]
+// @Security BearerAuth
+func (h *AuthHandler) MFAStatus(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	userTOTP, err := repository.GetUserTOTP(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	remaining, err := repository.CountUnusedMFARecoveryCodes(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to count recovery codes", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to count recovery codes")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("MFA status retrieved successfully", mfaStatusResponse{
+		TOTPEnabled:            userTOTP != nil && userTOTP.ConfirmedAt != nil,
+		RecoveryCodesRemaining: remaining,
+	}))
+}
+
+// +----------------------------------------------+
+// | Enroll TOTP                                  |
+// +----------------------------------------------+
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Creates a new TOTP secret, it protects logins once confirmed with a code. Restarting an unconfirmed enrollment replaces the secret
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=totpEnrollmentResponse} "TOTP enrollment started"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "TOTP is already enabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/mfa/totp [post]
+// @Security BearerAuth
+func (h *AuthHandler) EnrollTOTP(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	existing, err := repository.GetUserTOTP(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	if existing != nil && existing.ConfirmedAt != nil {
+		return echo.NewHTTPError(http.StatusConflict, "TOTP is already enabled")
+	}
+
+	// Authenticator apps show the account name next to the codes
+	accounts, err := repository.ListAccountsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list accounts", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list accounts")
+	}
+
+	accountName := primaryEmail(accounts)
+	if accountName == "" {
+		accountName = strconv.FormatInt(*userID, 10)
+	}
+
+	key, err := mfa.GenerateTOTPKey(accountName)
+	if err != nil {
+		zap.L().Error("Failed to generate totp key", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate totp key")
+	}
+
+	now := time.Now()
+	err = repository.UpsertUserTOTP(c.Request().Context(), tx, models.UserTOTP{
+		UserID:    *userID,
+		Secret:    key.Secret(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		zap.L().Error("Failed to save totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save totp")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("TOTP enrollment started", totpEnrollmentResponse{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+	}))
+}
+
+// +----------------------------------------------+
+// | Confirm TOTP                                 |
+// +----------------------------------------------+
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Checks a code from the authenticator app, enables TOTP and returns the recovery codes
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body totpCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} response.SuccessResponse{data=recoveryCodesResponse} "TOTP enabled successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body, code, or no enrollment started"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "TOTP is already enabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/mfa/totp/confirm [post]
+// @Security BearerAuth
+func (h *AuthHandler) ConfirmTOTP(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req totpCodeRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	userTOTP, err := repository.GetUserTOTP(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	if userTOTP == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "No TOTP enrollment started")
+	}
+
+	if userTOTP.ConfirmedAt != nil {
+		return echo.NewHTTPError(http.StatusConflict, "TOTP is already enabled")
+	}
+
+	now := time.Now()
+	step, ok := mfa.ValidateTOTP(userTOTP.Secret, req.Code, userTOTP.LastUsedStep, now)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid code")
+	}
+
+	if err := repository.ConfirmUserTOTP(c.Request().Context(), tx, *userID, step, now); err != nil {
+		zap.L().Error("Failed to confirm totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to confirm totp")
+	}
+
+	codes, err := replaceRecoveryCodes(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to generate recovery codes", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate recovery codes")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("TOTP enabled", zap.Int64("user_id", *userID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.Success("TOTP enabled successfully", recoveryCodesResponse{RecoveryCodes: codes}))
+}
+
+// +----------------------------------------------+
+// | Disable TOTP                                 |
+// +----------------------------------------------+
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Removes the authenticator and the recovery codes, needs a current code or a recovery code
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body secondFactorRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} response.SuccessResponse "TOTP disabled successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body, code, or TOTP not enabled"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/mfa/totp [delete]
+// @Security BearerAuth
+func (h *AuthHandler) DisableTOTP(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req secondFactorRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	userTOTP, err := repository.GetUserTOTP(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	if userTOTP == nil || userTOTP.ConfirmedAt == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "TOTP is not enabled")
+	}
+
+	ok, err := verifySecondFactor(c.Request().Context(), tx, *userTOTP, req)
+	if err != nil {
+		zap.L().Error("Failed to verify second factor", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify second factor")
+	}
+
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid code")
+	}
+
+	if err := repository.DeleteMFARecoveryCodesByUserID(c.Request().Context(), tx, *userID); err != nil {
+		zap.L().Error("Failed to delete recovery codes", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete recovery codes")
+	}
+
+	if err := repository.DeleteUserTOTP(c.Request().Context(), tx, *userID); err != nil {
+		zap.L().Error("Failed to delete totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete totp")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("TOTP disabled", zap.Int64("user_id", *userID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("TOTP disabled successfully"))
+}
+
+// +----------------------------------------------+
+// | Regenerate Recovery Codes                    |
+// +----------------------------------------------+
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces every recovery code with a new set, needs a current code from the authenticator app
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body totpCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} response.SuccessResponse{data=recoveryCodesResponse} "Recovery codes regenerated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body, code, or TOTP not enabled"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/mfa/recovery-codes [post]
+// @Security BearerAuth
+func (h *AuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req totpCodeRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	userTOTP, err := repository.GetUserTOTP(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	if userTOTP == nil || userTOTP.ConfirmedAt == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "TOTP is not enabled")
+	}
+
+	ok, err := verifySecondFactor(c.Request().Context(), tx, *userTOTP, secondFactorRequest{Code: req.Code})
+	if err != nil {
+		zap.L().Error("Failed to verify second factor", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify second factor")
+	}
+
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid code")
+	}
+
+	codes, err := replaceRecoveryCodes(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to generate recovery codes", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate recovery codes")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Recovery codes regenerated successfully", recoveryCodesResponse{RecoveryCodes: codes}))
+}
+
+// +----------------------------------------------+
+// | Login MFA                                    |
+// +----------------------------------------------+
+
+// LoginMFA godoc
+// @Summary Complete a login with a second factor
+// @Description Exchanges the MFA token returned by the login plus a TOTP code or a recovery code for a refresh token cookie. The token completes a single login and is invalidated after MFA_CHALLENGE_MAX_ATTEMPTS wrong codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body loginMFARequest true "MFA token and code"
+// @Success 200 {object} response.SuccessResponse "Login successful, refresh token set in cookie"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body or code"
+// @Failure 401 {object} response.ErrorResponse "Invalid, expired, used or exhausted MFA token"
+// @Failure 403 {object} response.ErrorResponse "Account is disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/login/mfa [post]
+func (h *AuthHandler) LoginMFA(c echo.Context) error {
+	var req loginMFARequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	valid, claims, err := secret.ValidateMFAChallengeTokenAndGetClaims(req.MFAToken)
+	if err != nil || !valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+
+	challengeID, err := strconv.ParseInt(claims.ID, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Locking the challenge makes concurrent attempts with the same token wait their turn
+	challenge, err := repository.GetMFAChallengeByID(c.Request().Context(), tx, challengeID)
+	if err != nil {
+		zap.L().Error("Failed to get mfa challenge", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get mfa challenge")
+	}
+
+	// Tokens complete a single login and only take a few wrong codes
+	if challenge == nil || challenge.UserID != userID || challenge.UsedAt != nil || challenge.Attempts >= config.Env().MFAChallengeMaxAttempts {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+
+	userTOTP, err := repository.GetUserTOTP(c.Request().Context(), tx, userID)
+	if err != nil {
+		zap.L().Error("Failed to get totp", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get totp")
+	}
+
+	// TOTP was disabled since the password step
+	if userTOTP == nil || userTOTP.ConfirmedAt == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired MFA token")
+	}
+
+	ok, err := verifySecondFactor(c.Request().Context(), tx, *userTOTP, req.secondFactorRequest)
+	if err != nil {
+		zap.L().Error("Failed to verify second factor", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify second factor")
+	}
+
+	if !ok {
+		zap.L().Warn("Invalid MFA code", zap.Int64("user_id", userID), zap.Int("attempts", challenge.Attempts+1), zap.String("ip", c.RealIP()))
+
+		if err := repository.IncrementMFAChallengeAttempts(c.Request().Context(), tx, challengeID); err != nil {
+			zap.L().Error("Failed to count mfa attempt", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to count mfa attempt")
+		}
+
+		// The attempt must count even though the request fails
+		if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+			zap.L().Error("Failed to commit transaction", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+		}
+
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid code")
+	}
+
+	if err := repository.MarkMFAChallengeUsed(c.Request().Context(), tx, challengeID, time.Now()); err != nil {
+		zap.L().Error("Failed to mark mfa challenge as used", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to mark mfa challenge as used")
+	}
+
+	refreshToken, err := generateTokenAndSaveRefreshToken(c, tx, userID)
+	if err != nil {
+		return refreshTokenHTTPError(err)
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	setRefreshTokenCookie(c, refreshToken)
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Login successful"))
+}
+
+// generateMFAChallengeToken saves the challenge and signs the token handed out by Login while the second factor is pending
+func generateMFAChallengeToken(ctx context.Context, tx pgx.Tx, userID int64) (string, error) {
+	now := time.Now()
+	if err := repository.DeleteExpiredMFAChallenges(ctx, tx, now); err != nil {
+		return "", fmt.Errorf("failed to delete expired mfa challenges: %w", err)
+	}
+
+	challengeID, err := id.GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mfa challenge ID: %w", err)
+	}
+
+	challenge := models.MFAChallenge{
+		ID:        challengeID,
+		UserID:    userID,
+		ExpiresAt: now.Add(time.Duration(config.Env().MFAChallengeExpiresAt) * time.Second),
+		CreatedAt: now,
+	}
+	if err := repository.CreateMFAChallenge(ctx, tx, challenge); err != nil {
+		return "", fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	return secret.GenerateMFAChallengeToken(strconv.FormatInt(challengeID, 10), strconv.FormatInt(userID, 10), challenge.ExpiresAt)
+}
+
+// verifySecondFactor checks a TOTP code, remembering its time step, or consumes a recovery code
+func verifySecondFactor(ctx context.Context, tx pgx.Tx, userTOTP models.UserTOTP, req secondFactorRequest) (bool, error) {
+	now := time.Now()
+
+	if req.RecoveryCode != "" {
+		return repository.UseMFARecoveryCode(ctx, tx, userTOTP.UserID, mfa.HashRecoveryCode(req.RecoveryCode), now)
+	}
+
+	step, ok := mfa.ValidateTOTP(userTOTP.Secret, req.Code, userTOTP.LastUsedStep, now)
+	if !ok {
+		return false, nil
+	}
+
+	if err := repository.UpdateUserTOTPLastUsedStep(ctx, tx, userTOTP.UserID, step, now); err != nil {
+		return false, fmt.Errorf("failed to update totp last used step: %w", err)
+	}
+
+	return true, nil
+}
+
+// replaceRecoveryCodes generates a new set of recovery codes, stores their hashes and returns them
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64) ([]string, error) {
+	codes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	records := make([]models.MFARecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		codeID, err := id.GetID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code ID: %w", err)
+		}
+
+		records = append(records, models.MFARecoveryCode{
+			ID:        codeID,
+			UserID:    userID,
+			CodeHash:  mfa.HashRecoveryCode(code),
+			CreatedAt: now,
+		})
+	}
+
+	if err := repository.ReplaceMFARecoveryCodes(ctx, tx, userID, records); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
@@ -2,9 +2,11 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"ridash/models"
 	"ridash/repository"
+	"ridash/utils/invitation"
 	"strconv"
 	"time"
 
@@ -26,8 +28,10 @@ import (
 // @Param code query string true "Authorization code from OAuth provider"
 // @Param state query string true "OAuth state parameter for CSRF protection"
 // @Success 307 {string} string "Redirect to success URL with authentication cookies set"
-// @Failure 400 {object} response.ErrorResponse "Invalid provider, oauth state, or verification failed"
+// @Failure 400 {object} response.ErrorResponse "Invalid provider, oauth state, invitation, or verification failed"
+// @Failure 403 {object} response.ErrorResponse "Self-signup is disabled and there is no invitation, the invitation was sent to another email address, or the account is disabled"
 // @Failure 500 {object} response.ErrorResponse "Internal server error during user creation or token generation"
+// @Failure 503 {object} response.ErrorResponse "OAuth provider can't be reached"
 // @Router /auth/oauth/{provider}/callback [get]
 func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 	// Get the oauth state from the query params
@@ -42,16 +46,11 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 	}
 
 	// Parse the provider
-	provider, err := parseProvider(c.Param("provider"))
+	provider, err := parseProvider(h.OAuthConfig, c.Param("provider"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid provider")
 	}
 
-	// No need to check if the provider is valid because it's checked in the parseProvider function
-	oauthConfig := h.OAuthConfig.Providers[provider]
-	// Get the oidc provider
-	oidcProvider := h.OAuthConfig.OIDCProviders[provider]
-
 	// Validate the oauth state
 	valid, payload, err := oauthValidateStateWithPayload(oauthSessionCookie.Value)
 	if err != nil || !valid || oauthState != payload.State {
@@ -78,22 +77,31 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
 	defer cancel()
 
+	// No need to check if the provider is valid because it's checked in the parseProvider function
+	oauthConfig, oidcProvider, err := h.OAuthConfig.Providers[provider].Resolve(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "OAuth provider unavailable")
+	}
+
+	fetcher, err := profileFetcher(provider, oauthConfig, oidcProvider)
+	if err != nil {
+		zap.L().Error("Failed to get profile fetcher", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get profile fetcher")
+	}
+
 	// Exchange the code for a token
 	token, err := oauthConfig.Exchange(ctx, code)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Failed to exchange code")
 	}
 
-	// Get the raw ID token
-	rawIDToken, ok := token.Extra("id_token").(string)
-	if !ok {
-		zap.L().Error("Failed to get id token")
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get id token")
+	// Read the profile from the ID token or the provider API
+	profile, err := fetcher.FetchProfile(ctx, token)
+	if errors.Is(err, errNoVerifiedEmail) {
+		return echo.NewHTTPError(http.StatusBadRequest, "The provider account has no verified email address")
 	}
-
-	// Verify the token
-	userInfo, err := oauthVerifyTokenAndGetUserInfo(c.Request().Context(), rawIDToken, token, oidcProvider, oauthConfig)
 	if err != nil {
+		zap.L().Warn("Failed to fetch oauth profile", zap.String("provider", string(provider)), zap.Error(err))
 		return echo.NewHTTPError(http.StatusBadRequest, "Failed to verify token")
 	}
 
@@ -107,7 +115,7 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 	defer repository.DeferRollback(tx, c.Request().Context())
 
 	// Get the account and user by the provider and user ID for checking if the user is already linked/registered
-	account, user, err := repository.GetAccountWithUserByProviderUserID(c.Request().Context(), tx, provider, userInfo.Subject)
+	account, user, err := repository.GetAccountWithUserByProviderUserID(c.Request().Context(), tx, provider, profile.Subject)
 	if err != nil {
 		zap.L().Error("Failed to get account", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account")
@@ -117,7 +125,7 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 	// so we need to link the account to the user
 	if user == nil && userID != 0 {
 		// Link the account to the user
-		newAccount, err := generateUserAccountFromOAuthUserInfo(userInfo, provider, userID)
+		newAccount, err := generateUserAccountFromOAuthProfile(profile, provider, userID)
 		if err != nil {
 			zap.L().Error("Failed to link account", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate account")
@@ -133,8 +141,12 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 
 		zap.L().Info("OAuth link account successful", zap.String("provider", string(provider)), zap.Int64("user_id", userID), zap.String("ip", c.RealIP()))
 	} else if account == nil && userID == 0 {
+		if !selfSignupAllowed(payload.InvitationToken) {
+			return echo.NewHTTPError(http.StatusForbidden, "Self-signup is disabled")
+		}
+
 		// Generate the full user object
-		newUser, newAccount, err := generateUserFromOAuthUserInfo(userInfo, provider)
+		newUser, newAccount, err := generateUserFromOAuthProfile(profile, provider)
 		if err != nil {
 			zap.L().Error("Failed to generate user", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate user and account")
@@ -163,6 +175,26 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create user and account")
 	}
 
+	// Join the team the user was invited to
+	if payload.InvitationToken != "" {
+		verifiedEmails, err := invitation.VerifiedEmails(c.Request().Context(), tx, userID)
+		if err != nil {
+			zap.L().Error("Failed to get verified emails", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get verified emails")
+		}
+
+		if _, err := invitation.Accept(c.Request().Context(), tx, payload.InvitationToken, userID, verifiedEmails); err != nil {
+			if errors.Is(err, invitation.ErrEmailMismatch) {
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+			if invitation.IsClientError(err) {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			zap.L().Error("Failed to accept invitation", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to accept invitation")
+		}
+	}
+
 	// Create the oauth token
 	err = repository.CreateOAuthToken(c.Request().Context(), tx, models.OAuthToken{
 		AccountID:    accountID,
@@ -182,8 +214,7 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 	// Generate the refresh token
 	refreshToken, err := generateTokenAndSaveRefreshToken(c, tx, userID)
 	if err != nil {
-		zap.L().Error("Failed to create refresh token", zap.Error(err))
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create refresh token")
+		return refreshTokenHTTPError(err)
 	}
 
 	// Commit the transaction
@@ -193,8 +224,7 @@ func (h *AuthHandler) OAuthCallback(c echo.Context) error {
 	}
 
 	// Generate the refresh token cookie
-	refreshTokenCookie := generateRefreshTokenCookie(refreshToken)
-	c.SetCookie(&refreshTokenCookie)
+	setRefreshTokenCookie(c, refreshToken)
 
 	// Redirect to the redirect URI
 	return c.Redirect(http.StatusTemporaryRedirect, payload.RedirectURI)
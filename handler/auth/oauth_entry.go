@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	authutil "ridash/utils/auth"
 	"ridash/utils/config"
@@ -22,13 +23,15 @@ import (
 // @Tags oauth
 // @Param provider path string true "OAuth provider (e.g., google, github)"
 // @Param next query string false "Redirect URL after successful OAuth linking"
+// @Param invitation_token query string false "Team invitation token to accept once signed in"
 // @Success 307 {string} string "Redirect to OAuth provider"
 // @Failure 400 {object} response.ErrorResponse "Invalid provider or bad request"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Failure 503 {object} response.ErrorResponse "OAuth provider can't be reached"
 // @Router /auth/oauth/{provider} [get]
 func (h *AuthHandler) OAuthEntry(c echo.Context) error {
 	// Parse provider
-	provider, err := parseProvider(c.Param("provider"))
+	provider, err := parseProvider(h.OAuthConfig, c.Param("provider"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid provider")
 	}
@@ -45,13 +48,19 @@ func (h *AuthHandler) OAuthEntry(c echo.Context) error {
 		next = "/"
 	}
 
-	oauthStateJwt, oauthState, err := oauthGenerateStateWithPayload(next, expiresAt, userID)
+	oauthStateJwt, oauthState, err := oauthGenerateStateWithPayload(next, expiresAt, userID, c.QueryParam("invitation_token"))
 	if err != nil {
 		zap.L().Error("Failed to generate oauth state", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate oauth state")
 	}
 
-	oauthConfig := h.OAuthConfig.Providers[provider]
+	// OIDC providers are discovered on first use
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	oauthConfig, _, err := h.OAuthConfig.Providers[provider].Resolve(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "OAuth provider unavailable")
+	}
 
 	authURL := oauthConfig.AuthCodeURL(
 		oauthState,
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"ridash/models"
+	"ridash/utils/config"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// +----------------------------------------------+
+// | OAuth providers                              |
+// +----------------------------------------------+
+
+// oauthProfile is what the callback needs from a provider to find, link or create the account
+type oauthProfile struct {
+	Subject       string // Stable ID of the user at the provider
+	Email         string
+	EmailVerified bool
+	DisplayName   string
+	Picture       string
+}
+
+// oauthProfileFetcher loads the profile of the signed in user once the code is exchanged
+type oauthProfileFetcher interface {
+	FetchProfile(ctx context.Context, token *oauth2.Token) (*oauthProfile, error)
+}
+
+// errNoVerifiedEmail is returned when the provider has no verified address for the user
+var errNoVerifiedEmail = errors.New("no verified primary email")
+
+// profileFetcher picks how the profile is read, OIDC providers through the ID token and plain OAuth2 ones through their API
+func profileFetcher(provider models.Provider, oauthConfig *oauth2.Config, oidcProvider *oidc.Provider) (oauthProfileFetcher, error) {
+	if oidcProvider != nil {
+		return &oidcProfileFetcher{provider: oidcProvider, config: oauthConfig}, nil
+	}
+
+	switch provider {
+	case models.ProviderGitHub:
+		return &githubProfileFetcher{apiURL: config.Env().GitHubAPIURL, config: oauthConfig}, nil
+	default:
+		return nil, fmt.Errorf("no profile fetcher for provider: %s", provider)
+	}
+}
+
+// oidcProfileFetcher verifies the ID token and reads the userinfo endpoint
+type oidcProfileFetcher struct {
+	provider *oidc.Provider
+	config   *oauth2.Config
+}
+
+func (f *oidcProfileFetcher) FetchProfile(ctx context.Context, token *oauth2.Token) (*oauthProfile, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("id token missing from the token response")
+	}
+
+	userInfo, err := oauthVerifyTokenAndGetUserInfo(ctx, rawIDToken, token, f.provider, f.config)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Picture           string `json:"picture"`
+		Name              string `json:"name"`
+		FamilyName        string `json:"family_name"`
+		GivenName         string `json:"given_name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	_ = userInfo.Claims(&claims)
+
+	// Not every OIDC provider fills the name claims
+	displayName := strings.TrimSpace(fmt.Sprintf("%s %s", claims.GivenName, claims.FamilyName))
+	if displayName == "" {
+		displayName = strings.TrimSpace(claims.Name)
+	}
+	if displayName == "" {
+		displayName = strings.TrimSpace(claims.PreferredUsername)
+	}
+
+	return &oauthProfile{
+		Subject:       userInfo.Subject,
+		Email:         userInfo.Email,
+		EmailVerified: userInfo.EmailVerified,
+		DisplayName:   displayName,
+		Picture:       claims.Picture,
+	}, nil
+}
+
+// githubProfileFetcher reads the user and their primary email from the GitHub REST API
+type githubProfileFetcher struct {
+	apiURL string
+	config *oauth2.Config
+}
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (f *githubProfileFetcher) FetchProfile(ctx context.Context, token *oauth2.Token) (*oauthProfile, error) {
+	client := f.config.Client(ctx, token)
+
+	var user githubUser
+	if err := f.get(ctx, client, "/user", &user); err != nil {
+		return nil, fmt.Errorf("failed to get github user: %w", err)
+	}
+
+	var emails []githubEmail
+	if err := f.get(ctx, client, "/user/emails", &emails); err != nil {
+		return nil, fmt.Errorf("failed to get github emails: %w", err)
+	}
+
+	// Only the verified primary address can identify the user
+	var email string
+	for _, candidate := range emails {
+		if candidate.Primary && candidate.Verified {
+			email = candidate.Email
+			break
+		}
+	}
+	if email == "" {
+		return nil, errNoVerifiedEmail
+	}
+
+	displayName := strings.TrimSpace(user.Name)
+	if displayName == "" {
+		displayName = user.Login
+	}
+
+	return &oauthProfile{
+		Subject:       strconv.FormatInt(user.ID, 10),
+		Email:         email,
+		EmailVerified: true,
+		DisplayName:   displayName,
+		Picture:       user.AvatarURL,
+	}, nil
+}
+
+// get decodes a GitHub API response into target
+func (f *githubProfileFetcher) get(ctx context.Context, client *http.Client, path string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(f.apiURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, path)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
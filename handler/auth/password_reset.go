@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"ridash/utils/mailer"
+	"ridash/utils/response"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | Forgot Password                              |
+// +----------------------------------------------+
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Emails a single-use password reset link if the address belongs to an email account, the response is the same either way
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body forgotPasswordRequest true "Email address of the account"
+// @Success 200 {object} response.SuccessResponse "Password reset email sent if the account exists"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Only email/password accounts have a password to reset
+	user, err := repository.GetUserByEmail(c.Request().Context(), tx, req.Email)
+	if err != nil {
+		zap.L().Error("Failed to get user by email", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user by email")
+	}
+
+	// Don't reveal whether the address is registered
+	if user != nil {
+		token, err := encrypt.GenerateRandomString(48)
+		if err != nil {
+			zap.L().Error("Failed to generate password reset token", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate password reset token")
+		}
+
+		resetTokenID, err := id.GetID()
+		if err != nil {
+			zap.L().Error("Failed to generate password reset token ID", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate password reset token ID")
+		}
+
+		now := time.Now()
+		resetToken := models.PasswordResetToken{
+			ID:        resetTokenID,
+			UserID:    user.ID,
+			TokenHash: encrypt.HashToken(token),
+			ExpiresAt: now.Add(time.Duration(config.Env().PasswordResetExpiresAt) * time.Second),
+			CreatedAt: now,
+		}
+
+		if err := repository.CreatePasswordResetToken(c.Request().Context(), tx, resetToken); err != nil {
+			zap.L().Error("Failed to create password reset token", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create password reset token")
+		}
+
+		err = h.Mailer.Enqueue(c.Request().Context(), tx, req.Email, mailer.TemplatePasswordReset, mailer.PasswordResetData{
+			DisplayName: user.DisplayName,
+			ResetURL:    config.Env().FrontendURL + "/reset-password?token=" + token,
+			ExpiresAt:   resetToken.ExpiresAt,
+		})
+		if err != nil {
+			zap.L().Error("Failed to queue password reset email", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue password reset email")
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Password reset email sent if the account exists"))
+}
+
+// +----------------------------------------------+
+// | Reset Password                               |
+// +----------------------------------------------+
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" validate:"required,max=255" example:"q8Xn2..."`
+	Password string `json:"password" validate:"required,min=8,max=255" example:"newpassword123"`
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password with a reset token from the email and signs out every session of the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body resetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} response.SuccessResponse "Password reset successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body or invalid, used or expired token"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	resetToken, err := repository.GetPasswordResetTokenByHash(c.Request().Context(), tx, encrypt.HashToken(req.Token))
+	if err != nil {
+		zap.L().Error("Failed to get password reset token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get password reset token")
+	}
+
+	now := time.Now()
+	if resetToken == nil || resetToken.UsedAt != nil || resetToken.ExpiresAt.Before(now) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid or expired reset token")
+	}
+
+	passwordHash, err := encrypt.CreateArgon2idHash(req.Password, config.GetArgon2idParams())
+	if err != nil {
+		zap.L().Error("Failed to hash password", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to hash password")
+	}
+
+	if err := repository.UpdateUserPasswordHash(c.Request().Context(), tx, resetToken.UserID, passwordHash, now); err != nil {
+		zap.L().Error("Failed to update password", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update password")
+	}
+
+	// Burn this token and any other outstanding one
+	if err := repository.MarkPasswordResetTokensUsedByUserID(c.Request().Context(), tx, resetToken.UserID, now); err != nil {
+		zap.L().Error("Failed to mark password reset tokens as used", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to mark password reset tokens as used")
+	}
+
+	// Whoever knew the old password must not stay signed in
+	if err := repository.RevokeRefreshTokensByUserID(c.Request().Context(), tx, resetToken.UserID, now); err != nil {
+		zap.L().Error("Failed to revoke refresh tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke refresh tokens")
+	}
+
+	if err := h.Revocations.RevokeUser(c.Request().Context(), tx, resetToken.UserID, now); err != nil {
+		zap.L().Error("Failed to revoke access tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke access tokens")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	h.reloadRevocations(c)
+
+	zap.L().Info("Password reset", zap.Int64("user_id", resetToken.UserID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Password reset successfully"))
+}
@@ -1,14 +1,19 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
+	"ridash/models"
 	"ridash/repository"
+	authutil "ridash/utils/auth"
 	"ridash/utils/config"
-	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"ridash/utils/mailer"
 	"ridash/utils/response"
 	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
@@ -19,16 +24,17 @@ import (
 
 // RefreshToken godoc
 // @Summary Refresh token
-// @Description Refreshes the access token using the refresh token cookie, returns a new access token and refresh token cookie
+// @Description Refreshes the access token using the refresh token cookie, returns a new access token and refresh token cookie. Replaying a rotated token revokes its whole session
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Success 201 {object} response.SuccessResponse "Access token generated successfully, new refresh token set in cookie"
-// @Failure 401 {object} response.ErrorResponse "Refresh token not found, invalid, or already used"
+// @Failure 401 {object} response.ErrorResponse "Refresh token not found, invalid, revoked, or already used"
+// @Failure 403 {object} response.ErrorResponse "Account is disabled"
 // @Failure 500 {object} response.ErrorResponse "Internal server error (transaction, database, or token generation failure)"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c echo.Context) error {
-	userRefreshToken, err := c.Cookie("refresh_token")
+	userRefreshToken, err := authutil.GetRefreshTokenCookie(c)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Refresh token not found")
 	}
@@ -41,8 +47,8 @@ func (h *AuthHandler) RefreshToken(c echo.Context) error {
 	}
 	defer repository.DeferRollback(tx, c.Request().Context())
 
-	// Get the refresh token by token
-	checkedRefreshToken, err := repository.GetRefreshTokenByToken(c.Request().Context(), tx, userRefreshToken.Value)
+	// Get the refresh token by token, concurrent refreshes wait here and then see it used
+	checkedRefreshToken, err := repository.GetRefreshTokenByTokenForUpdate(c.Request().Context(), tx, userRefreshToken.Value)
 	if err != nil {
 		zap.L().Error("Failed to get refresh token by token", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get refresh token by token")
@@ -53,16 +59,34 @@ func (h *AuthHandler) RefreshToken(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Refresh token not found")
 	}
 
-	// TODO: Need to tell the user might just been hacked
+	// A rotated token coming back means two parties hold the session, revoke it and warn the user
 	if checkedRefreshToken.UsedAt != nil {
 		zap.L().Warn("Refresh token already used",
 			zap.Int64("user_id", checkedRefreshToken.UserID),
+			zap.Int64("family_id", checkedRefreshToken.FamilyID),
 			zap.String("ip", c.RealIP()),
 			zap.String("user_agent", c.Request().UserAgent()),
 		)
+
+		if err := handleRefreshTokenReuse(c, tx, h.Mailer, *checkedRefreshToken); err != nil {
+			zap.L().Error("Failed to handle refresh token reuse", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke session")
+		}
+
+		// The revocation must stick even though the request fails
+		if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+			zap.L().Error("Failed to commit transaction", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+		}
+
 		return echo.NewHTTPError(http.StatusUnauthorized, "Refresh token already used")
 	}
 
+	// Revoked tokens belong to a session that was signed out
+	if checkedRefreshToken.RevokedAt != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Refresh token revoked")
+	}
+
 	// Update the refresh token used_at
 	now := time.Now()
 	checkedRefreshToken.UsedAt = &now
@@ -72,26 +96,22 @@ func (h *AuthHandler) RefreshToken(c echo.Context) error {
 	}
 
 	// Generate new refresh token
-	newRefreshToken, err := generateTokenAndSaveRefreshToken(c, tx, checkedRefreshToken.UserID)
+	newRefreshToken, err := rotateRefreshToken(c, tx, *checkedRefreshToken)
 	if err != nil {
-		zap.L().Error("Failed to generate refresh token", zap.Error(err))
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate refresh token")
+		return refreshTokenHTTPError(err)
 	}
 
 	// Commit the transaction
-	repository.CommitTransaction(tx, c.Request().Context())
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
 
 	// Generate the refresh token cookie
-	refreshTokenCookie := generateRefreshTokenCookie(newRefreshToken)
-	c.SetCookie(&refreshTokenCookie)
-
-	// Generate AccessTokenClaims
-	accessTokenClaims := encrypt.JWTSecret{
-		Secret: config.Env().JWTSecretKey,
-	}
+	setRefreshTokenCookie(c, newRefreshToken)
 
 	// Generate the access token
-	accessToken, err := accessTokenClaims.GenerateAccessToken(config.Env().AppName, strconv.FormatInt(checkedRefreshToken.UserID, 10), time.Now().Add(time.Duration(config.Env().AccessTokenExpiresAt)*time.Second))
+	accessToken, err := h.Keyring.GenerateAccessToken(config.Env().AppName, strconv.FormatInt(checkedRefreshToken.UserID, 10), time.Now().Add(time.Duration(config.Env().AccessTokenExpiresAt)*time.Second))
 	if err != nil {
 		zap.L().Error("Failed to generate access token", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate access token")
@@ -101,3 +121,68 @@ func (h *AuthHandler) RefreshToken(c echo.Context) error {
 		"access_token": accessToken,
 	}))
 }
+
+// handleRefreshTokenReuse revokes the family of the replayed token, records a security event and emails the user.
+// Replays against a family that is already revoked are ignored so the user is only warned once.
+func handleRefreshTokenReuse(c echo.Context, tx pgx.Tx, m *mailer.Mailer, token models.RefreshToken) error {
+	ctx := c.Request().Context()
+	now := time.Now()
+
+	revoked, err := repository.RevokeRefreshTokenFamily(ctx, tx, token.UserID, token.FamilyID, now)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	if revoked == 0 {
+		return nil
+	}
+
+	eventID, err := id.GetID()
+	if err != nil {
+		return fmt.Errorf("failed to generate security event ID: %w", err)
+	}
+
+	ip := c.RealIP()
+	userAgent := c.Request().UserAgent()
+	err = repository.CreateSecurityEvent(ctx, tx, models.SecurityEvent{
+		ID:        eventID,
+		UserID:    token.UserID,
+		Type:      models.SecurityEventRefreshTokenReuse,
+		IP:        &ip,
+		UserAgent: &userAgent,
+		Details: map[string]any{
+			"family_id":      strconv.FormatInt(token.FamilyID, 10),
+			"token_id":       strconv.FormatInt(token.ID, 10),
+			"revoked_tokens": revoked,
+		},
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+
+	if !config.Env().SecurityAlertEmails {
+		return nil
+	}
+
+	user, err := repository.GetUserByID(ctx, tx, token.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	accounts, err := repository.ListAccountsByUserID(ctx, tx, token.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	address := primaryEmail(accounts)
+	if user == nil || address == "" {
+		return nil
+	}
+
+	return m.Enqueue(ctx, tx, address, mailer.TemplateRefreshTokenReuse, mailer.RefreshTokenReuseData{
+		DisplayName: user.DisplayName,
+		IP:          ip,
+		UserAgent:   userAgent,
+		DetectedAt:  now,
+	})
+}
@@ -2,8 +2,11 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/invitation"
 	"ridash/utils/response"
 
 	"github.com/go-playground/validator/v10"
@@ -16,20 +19,22 @@ import (
 // +----------------------------------------------+
 
 type registerRequest struct {
-	Email       string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
-	Password    string `json:"password" validate:"required,min=8,max=255" example:"password123"`
-	DisplayName string `json:"display_name" validate:"required,min=3,max=255" example:"John Doe"`
+	Email           string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+	Password        string `json:"password" validate:"required,min=8,max=255" example:"password123"`
+	DisplayName     string `json:"display_name" validate:"required,min=3,max=255" example:"John Doe"`
+	InvitationToken string `json:"invitation_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
 
 // Register godoc
 // @Summary Register a new user
-// @Description Creates a new user account with email and password
+// @Description Creates a new user account with email and password, optionally accepting a team invitation, and emails a verification link
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body registerRequest true "Registration request"
 // @Success 200 {object} response.SuccessResponse "User registered successfully"
-// @Failure 400 {object} response.ErrorResponse "Invalid request body or email already in use"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body, invitation, or email already in use"
+// @Failure 403 {object} response.ErrorResponse "Self-signup is disabled and there is no invitation, or the invitation was sent to another email address"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c echo.Context) error {
@@ -65,6 +70,10 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "This email is already in use")
 	}
 
+	if !selfSignupAllowed(registerRequest.InvitationToken) {
+		return echo.NewHTTPError(http.StatusForbidden, "Self-signup is disabled")
+	}
+
 	// Generate the user and account
 	user, account, err := GenerateUser(registerRequest)
 	if err != nil {
@@ -78,6 +87,37 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create user")
 	}
 
+	// Join the team the user was invited to
+	if registerRequest.InvitationToken != "" {
+		// The invitation doesn't open the signup to other addresses
+		if _, err := invitation.Accept(c.Request().Context(), tx, registerRequest.InvitationToken, user.ID, []string{registerRequest.Email}); err != nil {
+			if errors.Is(err, invitation.ErrEmailMismatch) {
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+			if invitation.IsClientError(err) {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			zap.L().Error("Failed to accept invitation", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to accept invitation")
+		}
+	}
+
+	// Email the link verifying the address
+	if err := sendVerificationEmail(c.Request().Context(), tx, h.Mailer, account, user.DisplayName); err != nil {
+		zap.L().Error("Failed to queue verification email", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue verification email")
+	}
+
+	// Unverified accounts can't log in, so don't start a session yet
+	if config.Env().EmailVerificationRequired == config.EmailVerificationLogin {
+		if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+			zap.L().Error("Failed to commit transaction", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+		}
+
+		return c.JSON(http.StatusOK, response.SuccessMessage("User registered successfully, please verify your email address"))
+	}
+
 	// Generate the refresh token
 	refreshToken, err := generateTokenAndSaveRefreshToken(c, tx, user.ID)
 	if err != nil {
@@ -92,8 +132,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 	}
 
 	// Generate the refresh token cookie
-	refreshTokenCookie := generateRefreshTokenCookie(refreshToken)
-	c.SetCookie(&refreshTokenCookie)
+	setRefreshTokenCookie(c, refreshToken)
 
 	// Respond with the success message
 	return c.JSON(http.StatusOK, response.SuccessMessage("User registered successfully"))
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"net/http"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/config"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | List Sessions                                |
+// +----------------------------------------------+
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description Lists the devices the user is logged in on, rotated refresh tokens of one login are grouped into one session
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=[]models.Session} "Sessions retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/sessions [get]
+// @Security BearerAuth
+func (h *AuthHandler) ListSessions(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Tokens issued before this are expired
+	issuedAfter := time.Now().Add(-time.Duration(config.Env().RefreshTokenExpiresAt) * time.Second)
+	sessions, err := repository.ListActiveSessionsByUserID(c.Request().Context(), tx, *userID, issuedAfter)
+	if err != nil {
+		zap.L().Error("Failed to list sessions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list sessions")
+	}
+
+	// Flag the session the refresh token cookie belongs to
+	if cookie, err := authutil.GetRefreshTokenCookie(c); err == nil && cookie.Value != "" {
+		currentToken, err := repository.GetRefreshTokenByToken(c.Request().Context(), tx, cookie.Value)
+		if err != nil {
+			zap.L().Error("Failed to get refresh token by token", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get refresh token by token")
+		}
+
+		if currentToken != nil && currentToken.UserID == *userID {
+			for i := range sessions {
+				sessions[i].Current = sessions[i].ID == currentToken.FamilyID
+			}
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Sessions retrieved successfully", sessions))
+}
+
+// +----------------------------------------------+
+// | Revoke Session                               |
+// +----------------------------------------------+
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Signs out one device by revoking every refresh token of the session
+// @Tags auth
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} response.SuccessResponse "Session revoked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid session ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Session not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/sessions/{id} [delete]
+// @Security BearerAuth
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid session ID")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Scoped to the user so nobody can revoke someone else's session
+	revoked, err := repository.RevokeRefreshTokenFamily(c.Request().Context(), tx, *userID, sessionID, time.Now())
+	if err != nil {
+		zap.L().Error("Failed to revoke session", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke session")
+	}
+
+	if revoked == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Session not found")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Session revoked", zap.Int64("user_id", *userID), zap.Int64("session_id", sessionID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Session revoked successfully"))
+}
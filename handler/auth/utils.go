@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,12 +11,15 @@ import (
 	"ridash/utils/config"
 	"ridash/utils/encrypt"
 	"ridash/utils/id"
+	"ridash/utils/mailer"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 )
 
@@ -36,7 +40,7 @@ func GenerateUser(registerRequest registerRequest) (models.User, models.Account,
 	}
 
 	// hash the password
-	passwordHash, err := encrypt.CreateArgon2idHash(registerRequest.Password)
+	passwordHash, err := encrypt.CreateArgon2idHash(registerRequest.Password, config.GetArgon2idParams())
 	if err != nil {
 		return models.User{}, models.Account{}, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -64,13 +68,29 @@ func GenerateUser(registerRequest registerRequest) (models.User, models.Account,
 	return user, account, nil
 }
 
-// generateRefreshToken generates a refresh token for the user
-func generateRefreshToken(userID int64, userAgent string, ip string) (models.RefreshToken, error) {
+// selfSignupAllowed reports whether a new user can be created, invited people can always sign up
+func selfSignupAllowed(invitationToken string) bool {
+	return config.Env().SelfSignupEnabled || invitationToken != ""
+}
+
+// refreshTokenCookiePath scopes the refresh token cookie to the API, /api/me needs it to tell the current session apart
+const refreshTokenCookiePath = "/api"
+
+// legacyRefreshTokenCookiePaths are where the cookie was set before, browsers would keep sending those already used tokens
+var legacyRefreshTokenCookiePaths = []string{"/api/auth/refresh", "/api/auth"}
+
+// generateRefreshToken generates a refresh token for the user, a zero familyID starts a new family
+func generateRefreshToken(userID int64, familyID int64, userAgent string, ip string) (models.RefreshToken, error) {
 	refreshTokenID, err := id.GetID()
 	if err != nil {
 		return models.RefreshToken{}, fmt.Errorf("failed to generate refresh token ID: %w", err)
 	}
 
+	// The first token of a login names the family
+	if familyID == 0 {
+		familyID = refreshTokenID
+	}
+
 	// Extract IP address, handling cases where port may or may not be present
 	ipStr := ip
 	if host, _, err := net.SplitHostPort(ip); err == nil {
@@ -87,6 +107,7 @@ func generateRefreshToken(userID int64, userAgent string, ip string) (models.Ref
 	return models.RefreshToken{
 		ID:        refreshTokenID,
 		UserID:    userID,
+		FamilyID:  familyID,
 		Token:     refreshToken,
 		UserAgent: &userAgent,
 		IP:        &ipStr,
@@ -99,7 +120,7 @@ func generateRefreshToken(userID int64, userAgent string, ip string) (models.Ref
 func generateRefreshTokenCookie(refreshToken models.RefreshToken) http.Cookie {
 	return http.Cookie{
 		Name:     models.CookieNameRefreshToken,
-		Path:     "/api/auth/refresh",
+		Path:     refreshTokenCookiePath,
 		Domain:   config.Env().FrontendDomain,
 		Value:    refreshToken.Token,
 		HttpOnly: true,
@@ -109,12 +130,68 @@ func generateRefreshTokenCookie(refreshToken models.RefreshToken) http.Cookie {
 	}
 }
 
-// generateTokenAndSaveRefreshToken generates a refresh token and saves it to the database
+// setRefreshTokenCookie sets the refresh token cookie and expires the ones left at legacy paths
+func setRefreshTokenCookie(c echo.Context, refreshToken models.RefreshToken) {
+	refreshTokenCookie := generateRefreshTokenCookie(refreshToken)
+	c.SetCookie(&refreshTokenCookie)
+
+	for _, path := range legacyRefreshTokenCookiePaths {
+		clearedCookie := clearRefreshTokenCookie(path)
+		c.SetCookie(&clearedCookie)
+	}
+}
+
+// clearRefreshTokenCookies expires the refresh token cookie in the browser, at the current and legacy paths
+func clearRefreshTokenCookies(c echo.Context) {
+	for _, path := range append([]string{refreshTokenCookiePath}, legacyRefreshTokenCookiePaths...) {
+		clearedCookie := clearRefreshTokenCookie(path)
+		c.SetCookie(&clearedCookie)
+	}
+}
+
+// clearRefreshTokenCookie expires the refresh token cookie at path
+func clearRefreshTokenCookie(path string) http.Cookie {
+	return http.Cookie{
+		Name:     models.CookieNameRefreshToken,
+		Path:     path,
+		Domain:   config.Env().FrontendDomain,
+		Value:    "",
+		HttpOnly: true,
+		Secure:   config.Env().AppEnv == config.AppEnvProd,
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// generateTokenAndSaveRefreshToken generates a refresh token for a new login and saves it to the database
 func generateTokenAndSaveRefreshToken(e echo.Context, tx pgx.Tx, userID int64) (models.RefreshToken, error) {
+	return saveRefreshTokenInFamily(e, tx, userID, 0)
+}
+
+// rotateRefreshToken generates the refresh token replacing previous, in the same family
+func rotateRefreshToken(e echo.Context, tx pgx.Tx, previous models.RefreshToken) (models.RefreshToken, error) {
+	return saveRefreshTokenInFamily(e, tx, previous.UserID, previous.FamilyID)
+}
+
+// errAccountDisabled is returned instead of a session for users an admin disabled
+var errAccountDisabled = errors.New("account is disabled")
+
+// saveRefreshTokenInFamily generates a refresh token in the family and saves it to the database, disabled users get errAccountDisabled
+func saveRefreshTokenInFamily(e echo.Context, tx pgx.Tx, userID int64, familyID int64) (models.RefreshToken, error) {
+	user, err := repository.GetUserByID(e.Request().Context(), tx, userID)
+	if err != nil {
+		return models.RefreshToken{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user != nil && user.DisabledAt != nil {
+		return models.RefreshToken{}, errAccountDisabled
+	}
+
 	userAgent := e.Request().UserAgent()
 	ip := e.RealIP()
 
-	refreshToken, err := generateRefreshToken(userID, userAgent, ip)
+	refreshToken, err := generateRefreshToken(userID, familyID, userAgent, ip)
 	if err != nil {
 		return models.RefreshToken{}, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
@@ -126,22 +203,101 @@ func generateTokenAndSaveRefreshToken(e echo.Context, tx pgx.Tx, userID int64) (
 	return refreshToken, nil
 }
 
+// refreshTokenHTTPError turns a failure to save a refresh token into the response
+func refreshTokenHTTPError(err error) error {
+	if errors.Is(err, errAccountDisabled) {
+		return echo.NewHTTPError(http.StatusForbidden, "Account is disabled")
+	}
+
+	zap.L().Error("Failed to generate refresh token", zap.Error(err))
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate refresh token")
+}
+
+// reloadRevocations applies a committed revocation on this instance right away, the others reload when Postgres notifies them
+func (h *AuthHandler) reloadRevocations(c echo.Context) {
+	if err := h.Revocations.Reload(c.Request().Context()); err != nil {
+		zap.L().Error("Failed to reload access token revocations", zap.Error(err))
+	}
+}
+
+// dummyPasswordHash is compared against when there is no hash to check, so unknown emails take as long as wrong passwords
+var dummyPasswordHash struct {
+	sync.Mutex
+	params encrypt.Argon2idParams
+	hash   string
+}
+
+// getDummyPasswordHash returns a hash made with the current parameters, it is only regenerated when they change
+func getDummyPasswordHash() (string, error) {
+	dummyPasswordHash.Lock()
+	defer dummyPasswordHash.Unlock()
+
+	params := config.GetArgon2idParams()
+	if dummyPasswordHash.hash != "" && dummyPasswordHash.params == params {
+		return dummyPasswordHash.hash, nil
+	}
+
+	hash, err := encrypt.CreateArgon2idHash("dummy-password", params)
+	if err != nil {
+		return "", err
+	}
+
+	dummyPasswordHash.params = params
+	dummyPasswordHash.hash = hash
+	return hash, nil
+}
+
+// primaryEmail picks the address used to contact the user, the oldest verified one if any
+func primaryEmail(accounts []models.Account) string {
+	for _, account := range accounts {
+		if account.VerifiedAt != nil && account.Email != "" {
+			return account.Email
+		}
+	}
+
+	for _, account := range accounts {
+		if account.Email != "" {
+			return account.Email
+		}
+	}
+
+	return ""
+}
+
+// sendVerificationEmail queues an email with a signed link verifying the account email address
+func sendVerificationEmail(ctx context.Context, tx pgx.Tx, m *mailer.Mailer, account models.Account, displayName string) error {
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	expiresAt := time.Now().Add(time.Duration(config.Env().EmailVerificationExpiresAt) * time.Second)
+	token, err := secret.GenerateEmailToken(encrypt.TokenPurposeEmailVerification, strconv.FormatInt(account.ID, 10), account.Email, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to generate email verification token: %w", err)
+	}
+
+	return m.Enqueue(ctx, tx, account.Email, mailer.TemplateEmailVerification, mailer.EmailVerificationData{
+		DisplayName: displayName,
+		VerifyURL:   config.Env().FrontendURL + "/verify-email?token=" + token,
+		ExpiresAt:   expiresAt,
+	})
+}
+
 // +----------------------------------------------+
 // | OAuth part                                   |
 // +----------------------------------------------+
 
-// parseProvider parse the provider from the request
-func parseProvider(provider string) (models.Provider, error) {
-	switch provider {
-	case string(models.ProviderGoogle):
-		return models.ProviderGoogle, nil
-	default:
+// parseProvider parse the provider from the request, only configured OAuth providers are valid
+func parseProvider(oauthConfig *config.OAuthConfig, provider string) (models.Provider, error) {
+	if !oauthConfig.HasProvider(models.Provider(provider)) {
 		return "", fmt.Errorf("invalid provider: %s", provider)
 	}
+
+	return models.Provider(provider), nil
 }
 
 // oauthGenerateStateWithPayload generate the oauth state with the payload
-func oauthGenerateStateWithPayload(redirectURI string, expiresAt time.Time, userID string) (string, string, error) {
+func oauthGenerateStateWithPayload(redirectURI string, expiresAt time.Time, userID string, invitationToken string) (string, string, error) {
 	OAuthState, err := encrypt.GenerateRandomString(32)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate random string: %w", err)
@@ -151,7 +307,7 @@ func oauthGenerateStateWithPayload(redirectURI string, expiresAt time.Time, user
 		Secret: config.Env().JWTSecretKey,
 	}
 
-	tokenString, err := secret.GenerateOAuthState(OAuthState, redirectURI, expiresAt, userID)
+	tokenString, err := secret.GenerateOAuthState(OAuthState, redirectURI, expiresAt, userID, invitationToken)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate oauth state: %w", err)
 	}
@@ -203,27 +359,20 @@ func oauthVerifyTokenAndGetUserInfo(ctx context.Context, rawIDToken string, toke
 	return userInfo, nil
 }
 
-// generateUserFromOAuthUserInfo generate the user and account from the oauth user info
-func generateUserFromOAuthUserInfo(userInfo *oidc.UserInfo, provider models.Provider) (models.User, models.Account, error) {
+// generateUserFromOAuthProfile generate the user and account from the oauth profile
+func generateUserFromOAuthProfile(profile *oauthProfile, provider models.Provider) (models.User, models.Account, error) {
 	userID, err := id.GetID()
 	if err != nil {
 		return models.User{}, models.Account{}, fmt.Errorf("failed to generate user ID: %w", err)
 	}
 
-	// Get the picture from the user info
+	// Get the picture from the profile
 	var picture *string
-	var displayName string
-	var claims struct {
-		Picture    string `json:"picture"`
-		FamilyName string `json:"family_name"`
-		GivenName  string `json:"given_name"`
+	if profile.Picture != "" {
+		picture = &profile.Picture
 	}
-	if err := userInfo.Claims(&claims); err == nil && claims.Picture != "" {
-		picture = &claims.Picture
-	}
-
-	displayName = fmt.Sprintf("%s %s", claims.GivenName, claims.FamilyName)
 
+	displayName := profile.DisplayName
 	if displayName == "" {
 		displayName = encrypt.GenerateRandomUserDisplayName()
 	}
@@ -238,27 +387,16 @@ func generateUserFromOAuthUserInfo(userInfo *oidc.UserInfo, provider models.Prov
 		UpdatedAt:    time.Now(),
 	}
 
-	accountID, err := id.GetID()
+	account, err := generateUserAccountFromOAuthProfile(profile, provider, userID)
 	if err != nil {
-		return models.User{}, models.Account{}, fmt.Errorf("failed to generate account ID: %w", err)
-	}
-
-	// create the account
-	account := models.Account{
-		ID:             accountID,
-		UserID:         userID,
-		Provider:       provider,
-		ProviderUserID: userInfo.Subject,
-		Email:          userInfo.Email,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		return models.User{}, models.Account{}, err
 	}
 
 	return user, account, nil
 }
 
-// generateUserAccountFromOAuthUserInfo generate the user and account from the oauth user info
-func generateUserAccountFromOAuthUserInfo(userInfo *oidc.UserInfo, provider models.Provider, userID int64) (models.Account, error) {
+// generateUserAccountFromOAuthProfile generate the account of an existing user from the oauth profile
+func generateUserAccountFromOAuthProfile(profile *oauthProfile, provider models.Provider, userID int64) (models.Account, error) {
 	accountID, err := id.GetID()
 	if err != nil {
 		return models.Account{}, fmt.Errorf("failed to generate account ID: %w", err)
@@ -269,8 +407,9 @@ func generateUserAccountFromOAuthUserInfo(userInfo *oidc.UserInfo, provider mode
 		ID:             accountID,
 		UserID:         userID,
 		Provider:       provider,
-		ProviderUserID: userInfo.Subject,
-		Email:          userInfo.Email,
+		ProviderUserID: profile.Subject,
+		Email:          profile.Email,
+		VerifiedAt:     oauthVerifiedAt(profile),
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
@@ -278,6 +417,16 @@ func generateUserAccountFromOAuthUserInfo(userInfo *oidc.UserInfo, provider mode
 	return account, nil
 }
 
+// oauthVerifiedAt trusts the email address when the provider says it verified it
+func oauthVerifiedAt(profile *oauthProfile) *time.Time {
+	if !profile.EmailVerified {
+		return nil
+	}
+
+	now := time.Now()
+	return &now
+}
+
 // generateSessionCookie generates a session cookie
 func generateOAuthSessionCookie(session string) http.Cookie {
 	oauthSessionCookie := http.Cookie{
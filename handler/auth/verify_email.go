@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | Verify Email                                 |
+// +----------------------------------------------+
+
+type verifyEmailRequest struct {
+	Token string `json:"token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Marks the email account the verification token was issued for as verified
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body verifyEmailRequest true "Verification token from the email"
+// @Success 200 {object} response.SuccessResponse "Email address verified successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body or invalid or expired token"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	valid, claims, err := secret.ValidateEmailTokenAndGetClaims(req.Token, encrypt.TokenPurposeEmailVerification)
+	if err != nil || !valid {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid verification token")
+	}
+
+	accountID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid verification token")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	account, err := repository.GetAccountByID(c.Request().Context(), tx, accountID)
+	if err != nil {
+		zap.L().Error("Failed to get account", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account")
+	}
+
+	// The token is bound to the address it was sent to
+	if account == nil || account.Email != claims.Email {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid verification token")
+	}
+
+	// Verifying twice is harmless, keep the original timestamp
+	if account.VerifiedAt == nil {
+		if err := repository.UpdateAccountVerifiedAt(c.Request().Context(), tx, account.ID, time.Now()); err != nil {
+			zap.L().Error("Failed to verify account", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify account")
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Email address verified", zap.Int64("user_id", account.UserID), zap.Int64("account_id", account.ID))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Email address verified successfully"))
+}
+
+// +----------------------------------------------+
+// | Resend Verification Email                    |
+// +----------------------------------------------+
+
+type resendVerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend verification email
+// @Description Emails a new verification link if the address belongs to an unverified email account, the response is the same either way
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body resendVerificationEmailRequest true "Email address to verify"
+// @Success 200 {object} response.SuccessResponse "Verification email sent if the account exists"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/verify-email/resend [post]
+func (h *AuthHandler) ResendVerificationEmail(c echo.Context) error {
+	var req resendVerificationEmailRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	account, err := repository.GetAccountByProviderAndEmail(c.Request().Context(), tx, models.ProviderEmail, req.Email)
+	if err != nil {
+		zap.L().Error("Failed to get account by email", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account by email")
+	}
+
+	// Don't reveal whether the address is registered
+	if account != nil && account.VerifiedAt == nil {
+		user, err := repository.GetUserByID(c.Request().Context(), tx, account.UserID)
+		if err != nil || user == nil {
+			zap.L().Error("Failed to get user", zap.Error(err), zap.Int64("user_id", account.UserID))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+		}
+
+		if err := sendVerificationEmail(c.Request().Context(), tx, h.Mailer, *account, user.DisplayName); err != nil {
+			zap.L().Error("Failed to queue verification email", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue verification email")
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Verification email sent if the account exists"))
+}
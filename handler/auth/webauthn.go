@@ -0,0 +1,558 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// webauthnSessionCookiePath scopes the ceremony cookie to the passkey endpoints
+const webauthnSessionCookiePath = "/api/auth/webauthn"
+
+// defaultPasskeyName labels the passkeys registered without a name
+const defaultPasskeyName = "Passkey"
+
+// webauthnUser adapts a user and its passkeys to the webauthn library, the user handle is the decimal user ID
+type webauthnUser struct {
+	id          int64
+	name        string
+	displayName string
+	credentials []webauthn.Credential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return []byte(strconv.FormatInt(u.id, 10))
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return u.name
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	return u.displayName
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// +----------------------------------------------+
+// | Passkey registration                         |
+// +----------------------------------------------+
+
+// BeginWebAuthnRegistration godoc
+// @Summary Start passkey registration
+// @Description Returns the options for navigator.credentials.create, the challenge is bound to a short lived session cookie
+// @Tags webauthn
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=protocol.CredentialCreation} "Passkey registration started"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/webauthn/register/begin [post]
+// @Security BearerAuth
+func (h *AuthHandler) BeginWebAuthnRegistration(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	user, err := loadWebAuthnUser(c, tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to load user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load user")
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	// Passkeys must be discoverable so the login doesn't need an email first
+	creation, sessionData, err := h.WebAuthn.BeginRegistration(user,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(user.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		zap.L().Error("Failed to begin passkey registration", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin passkey registration")
+	}
+
+	sessionCookie, err := saveWebAuthnSession(c, tx, userID, models.WebAuthnSessionRegistration, sessionData)
+	if err != nil {
+		zap.L().Error("Failed to save webauthn session", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save webauthn session")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	c.SetCookie(&sessionCookie)
+
+	return c.JSON(http.StatusOK, response.Success("Passkey registration started", creation))
+}
+
+// FinishWebAuthnRegistration godoc
+// @Summary Finish passkey registration
+// @Description Verifies the attestation returned by navigator.credentials.create and stores the passkey as a login method of the user
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param name query string false "Label of the passkey"
+// @Param request body object true "PublicKeyCredential returned by the browser"
+// @Success 200 {object} response.SuccessResponse{data=models.WebAuthnCredential} "Passkey registered successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid name, session or passkey response"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Passkey already registered"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/webauthn/register/finish [post]
+// @Security BearerAuth
+func (h *AuthHandler) FinishWebAuthnRegistration(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	name := c.QueryParam("name")
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	if len(name) > 255 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey name")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	sessionData, err := takeWebAuthnSession(c, tx, models.WebAuthnSessionRegistration)
+	if err != nil {
+		return err
+	}
+
+	user, err := loadWebAuthnUser(c, tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to load user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load user")
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	// The session user is checked against the current one by CreateCredential
+	parsed, err := protocol.ParseCredentialCreationResponseBody(c.Request().Body)
+	if err != nil {
+		logWebAuthnError("Failed to parse passkey attestation", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey response")
+	}
+
+	credential, err := h.WebAuthn.CreateCredential(user, *sessionData, parsed)
+	if err != nil {
+		logWebAuthnError("Failed to verify passkey attestation", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey response")
+	}
+
+	for _, existing := range user.credentials {
+		if bytes.Equal(existing.ID, credential.ID) {
+			return echo.NewHTTPError(http.StatusConflict, "Passkey already registered")
+		}
+	}
+
+	newCredential, err := generateWebAuthnCredential(*userID, name, credential)
+	if err != nil {
+		zap.L().Error("Failed to generate passkey", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate passkey")
+	}
+
+	if err := repository.CreateWebAuthnCredential(c.Request().Context(), tx, newCredential); err != nil {
+		zap.L().Error("Failed to create passkey", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create passkey")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	clearCookie := clearWebAuthnSessionCookie()
+	c.SetCookie(&clearCookie)
+
+	zap.L().Info("Passkey registered", zap.Int64("user_id", *userID), zap.Int64("credential_id", newCredential.ID))
+
+	return c.JSON(http.StatusOK, response.Success("Passkey registered successfully", newCredential))
+}
+
+// +----------------------------------------------+
+// | Passkey login                                |
+// +----------------------------------------------+
+
+// BeginWebAuthnLogin godoc
+// @Summary Start passkey login
+// @Description Returns the options for navigator.credentials.get, any passkey registered on this site can answer
+// @Tags webauthn
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=protocol.CredentialAssertion} "Passkey login started"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/webauthn/login/begin [post]
+func (h *AuthHandler) BeginWebAuthnLogin(c echo.Context) error {
+	assertion, sessionData, err := h.WebAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		zap.L().Error("Failed to begin passkey login", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin passkey login")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Anyone can start a login, so abandoned ceremonies are cleaned up here
+	if err := repository.DeleteExpiredWebAuthnSessions(c.Request().Context(), tx, time.Now()); err != nil {
+		zap.L().Error("Failed to delete expired webauthn sessions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete expired webauthn sessions")
+	}
+
+	sessionCookie, err := saveWebAuthnSession(c, tx, nil, models.WebAuthnSessionLogin, sessionData)
+	if err != nil {
+		zap.L().Error("Failed to save webauthn session", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save webauthn session")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	c.SetCookie(&sessionCookie)
+
+	return c.JSON(http.StatusOK, response.Success("Passkey login started", assertion))
+}
+
+// FinishWebAuthnLogin godoc
+// @Summary Finish passkey login
+// @Description Verifies the assertion returned by navigator.credentials.get and issues a refresh token cookie like the password login. A passkey already proves possession and user verification, so TOTP isn't asked
+// @Tags webauthn
+// @Accept json
+// @Produce json
+// @Param request body object true "PublicKeyCredential returned by the browser"
+// @Success 200 {object} response.SuccessResponse "Login successful, refresh token set in cookie"
+// @Failure 400 {object} response.ErrorResponse "Invalid session or passkey response"
+// @Failure 403 {object} response.ErrorResponse "Account is disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/webauthn/login/finish [post]
+func (h *AuthHandler) FinishWebAuthnLogin(c echo.Context) error {
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	sessionData, err := takeWebAuthnSession(c, tx, models.WebAuthnSessionLogin)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(c.Request().Body)
+	if err != nil {
+		logWebAuthnError("Failed to parse passkey assertion", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey response")
+	}
+
+	// The user handle stored in the passkey names the user
+	var user *webauthnUser
+	var storedCredentials []models.WebAuthnCredential
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := strconv.ParseInt(string(userHandle), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user handle: %w", err)
+		}
+
+		user, err = loadWebAuthnUser(c, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("user not found")
+		}
+
+		storedCredentials, err = repository.ListWebAuthnCredentialsByUserID(c.Request().Context(), tx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+
+	_, credential, err := h.WebAuthn.ValidatePasskeyLogin(findUser, *sessionData, parsed)
+	if err != nil {
+		logWebAuthnError("Failed to verify passkey assertion", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey response")
+	}
+
+	// A counter going backwards means the private key was copied
+	if credential.Authenticator.CloneWarning {
+		zap.L().Warn("Passkey signature counter went backwards", zap.Int64("user_id", user.id))
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey response")
+	}
+
+	var stored *models.WebAuthnCredential
+	for i := range storedCredentials {
+		if bytes.Equal(storedCredentials[i].CredentialID, credential.ID) {
+			stored = &storedCredentials[i]
+			break
+		}
+	}
+
+	if stored == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey response")
+	}
+
+	err = repository.UpdateWebAuthnCredentialUsage(c.Request().Context(), tx, stored.ID, int64(credential.Authenticator.SignCount), credential.Flags.BackupState, time.Now())
+	if err != nil {
+		zap.L().Error("Failed to update passkey", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update passkey")
+	}
+
+	// Generate the refresh token
+	refreshToken, err := generateTokenAndSaveRefreshToken(c, tx, user.id)
+	if err != nil {
+		return refreshTokenHTTPError(err)
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	clearCookie := clearWebAuthnSessionCookie()
+	c.SetCookie(&clearCookie)
+
+	// Generate the refresh token cookie
+	setRefreshTokenCookie(c, refreshToken)
+
+	zap.L().Info("Passkey login successful", zap.Int64("user_id", user.id), zap.Int64("credential_id", stored.ID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Login successful"))
+}
+
+// +----------------------------------------------+
+// | Passkey helpers                              |
+// +----------------------------------------------+
+
+// loadWebAuthnUser loads the user with its passkeys, nil when the user doesn't exist
+func loadWebAuthnUser(c echo.Context, tx pgx.Tx, userID int64) (*webauthnUser, error) {
+	user, err := repository.GetUserByID(c.Request().Context(), tx, userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	accounts, err := repository.ListAccountsByUserID(c.Request().Context(), tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := repository.ListWebAuthnCredentialsByUserID(c.Request().Context(), tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authenticators show the name when picking a passkey, the email tells accounts apart best
+	name := user.DisplayName
+	for _, account := range accounts {
+		if account.Email != "" {
+			name = account.Email
+			break
+		}
+	}
+
+	webauthnCredentials := make([]webauthn.Credential, 0, len(credentials))
+	for _, credential := range credentials {
+		webauthnCredentials = append(webauthnCredentials, toWebAuthnCredential(credential))
+	}
+
+	return &webauthnUser{
+		id:          userID,
+		name:        name,
+		displayName: user.DisplayName,
+		credentials: webauthnCredentials,
+	}, nil
+}
+
+// toWebAuthnCredential converts a stored passkey to the library credential
+func toWebAuthnCredential(credential models.WebAuthnCredential) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+	for _, transport := range credential.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(transport))
+	}
+
+	return webauthn.Credential{
+		ID:              credential.CredentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: uint32(credential.SignCount),
+		},
+	}
+}
+
+// generateWebAuthnCredential builds the passkey to store from a verified registration
+func generateWebAuthnCredential(userID int64, name string, credential *webauthn.Credential) (models.WebAuthnCredential, error) {
+	credentialID, err := id.GetID()
+	if err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("failed to generate credential ID: %w", err)
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return models.WebAuthnCredential{
+		ID:              credentialID,
+		UserID:          userID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+		CreatedAt:       time.Now(),
+	}, nil
+}
+
+// saveWebAuthnSession stores the ceremony challenge and returns the cookie naming it
+func saveWebAuthnSession(c echo.Context, tx pgx.Tx, userID *int64, purpose models.WebAuthnSessionPurpose, sessionData *webauthn.SessionData) (http.Cookie, error) {
+	sessionID, err := id.GetID()
+	if err != nil {
+		return http.Cookie{}, fmt.Errorf("failed to generate webauthn session ID: %w", err)
+	}
+
+	token, err := encrypt.GenerateRandomString(32)
+	if err != nil {
+		return http.Cookie{}, fmt.Errorf("failed to generate webauthn session token: %w", err)
+	}
+
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return http.Cookie{}, fmt.Errorf("failed to encode webauthn session: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(config.Env().WebAuthnChallengeExpiresAt) * time.Second)
+
+	err = repository.CreateWebAuthnSession(c.Request().Context(), tx, models.WebAuthnSession{
+		ID:        sessionID,
+		TokenHash: encrypt.HashToken(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		Data:      data,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return http.Cookie{}, err
+	}
+
+	return http.Cookie{
+		Name:     models.CookieNameWebAuthnSession,
+		Path:     webauthnSessionCookiePath,
+		Domain:   config.Env().FrontendDomain,
+		Value:    token,
+		HttpOnly: true,
+		Secure:   config.Env().AppEnv == config.AppEnvProd,
+		Expires:  expiresAt,
+		SameSite: http.SameSiteStrictMode,
+	}, nil
+}
+
+// takeWebAuthnSession consumes the ceremony session named by the cookie, the returned error is an HTTP error
+func takeWebAuthnSession(c echo.Context, tx pgx.Tx, purpose models.WebAuthnSessionPurpose) (*webauthn.SessionData, error) {
+	cookie, err := c.Cookie(models.CookieNameWebAuthnSession)
+	if err != nil || cookie.Value == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "WebAuthn session not found")
+	}
+
+	session, err := repository.TakeWebAuthnSession(c.Request().Context(), tx, encrypt.HashToken(cookie.Value))
+	if err != nil {
+		zap.L().Error("Failed to get webauthn session", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to get webauthn session")
+	}
+
+	if session == nil || session.Purpose != purpose || time.Now().After(session.ExpiresAt) {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "WebAuthn session not found")
+	}
+
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal(session.Data, &sessionData); err != nil {
+		zap.L().Error("Failed to decode webauthn session", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to decode webauthn session")
+	}
+
+	return &sessionData, nil
+}
+
+// clearWebAuthnSessionCookie expires the ceremony cookie once the ceremony is finished
+func clearWebAuthnSessionCookie() http.Cookie {
+	return http.Cookie{
+		Name:     models.CookieNameWebAuthnSession,
+		Path:     webauthnSessionCookiePath,
+		Domain:   config.Env().FrontendDomain,
+		Value:    "",
+		HttpOnly: true,
+		Secure:   config.Env().AppEnv == config.AppEnvProd,
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+// logWebAuthnError logs why a ceremony was rejected, the client only gets a generic message
+func logWebAuthnError(message string, err error) {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) {
+		zap.L().Debug(message, zap.String("details", protocolErr.Details), zap.String("info", protocolErr.DevInfo))
+		return
+	}
+
+	zap.L().Debug(message, zap.Error(err))
+}
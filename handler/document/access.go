@@ -8,6 +8,7 @@ import (
 
 	"ridash/models"
 	"ridash/repository"
+	"ridash/utils/authz"
 )
 
 type documentContext struct {
@@ -49,6 +50,7 @@ func loadDocumentContext(ctx context.Context, tx pgx.Tx, docID int64) (documentC
 	}, nil
 }
 
-func isTeamOwner(userID int64, team *models.Team) bool {
-	return team != nil && team.OwnerID == userID
+// authorizeDocument checks the user's team role against the authorization policy for the document.
+func authorizeDocument(ctx context.Context, tx pgx.Tx, docCtx documentContext, userID int64, action authz.Action) (bool, error) {
+	return authz.Authorize(ctx, tx, docCtx.Team, userID, action)
 }
@@ -6,6 +6,7 @@ import (
 	"ridash/models"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/id"
 	"ridash/utils/response"
 	"time"
@@ -27,7 +28,7 @@ type createDocumentRequest struct {
 
 // CreateDocument godoc
 // @Summary Create a document
-// @Description Creates a new document owned by the authenticated user
+// @Description Creates a new document in a team folder (team owners and admins only)
 // @Tags documents
 // @Accept json
 // @Produce json
@@ -35,6 +36,8 @@ type createDocumentRequest struct {
 // @Success 200 {object} response.SuccessResponse{data=models.Document} "Document created successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid request body"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Only team owner or admins can create documents"
+// @Failure 404 {object} response.ErrorResponse "Folder not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /documents [post]
 // @Security BearerAuth
@@ -77,8 +80,14 @@ func (h *DocumentHandler) CreateDocument(c echo.Context) error {
 	if team == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found for folder")
 	}
-	if team.OwnerID != *userID {
-		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can create documents")
+
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionDocumentCreate)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can create documents")
 	}
 
 	docID, err := id.GetID()
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/docmanager"
 	"ridash/utils/response"
 	"strconv"
@@ -19,7 +20,7 @@ import (
 
 // DeleteDocument godoc
 // @Summary Delete a document
-// @Description Deletes a document owned by the authenticated user
+// @Description Deletes a document (team owners and admins only)
 // @Tags documents
 // @Produce json
 // @Param id path int true "Document ID"
@@ -58,7 +59,12 @@ func (h *DocumentHandler) DeleteDocument(c echo.Context) error {
 	if docCtx.Document == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Document not found")
 	}
-	if !isTeamOwner(*userID, docCtx.Team) {
+	allowed, err := authorizeDocument(c.Request().Context(), tx, docCtx, *userID, authz.ActionDocumentDelete)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
 	}
 
@@ -6,6 +6,7 @@ import (
 	"ridash/models"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/docmanager"
 	"ridash/utils/response"
 	"strconv"
@@ -66,7 +67,13 @@ func (h *DocumentHandler) GetDocument(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusForbidden, "Access denied")
 		}
 
-		if !isTeamOwner(*userID, docCtx.Team) {
+		allowed, err := authorizeDocument(c.Request().Context(), tx, docCtx, *userID, authz.ActionDocumentRead)
+		if err != nil {
+			zap.L().Error("Failed to check permissions", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+		}
+
+		if !allowed {
 			share, err := repository.GetShareByDocumentAndUser(c.Request().Context(), tx, doc.ID, *userID)
 			if err != nil {
 				zap.L().Error("Failed to check share permissions", zap.Error(err))
@@ -16,7 +16,7 @@ import (
 
 // ListDocuments godoc
 // @Summary List documents
-// @Description Lists documents visible to the current user (public, owned, or shared)
+// @Description Lists documents visible to the current user (public, in one of their teams, or shared)
 // @Tags documents
 // @Produce json
 // @Success 200 {object} response.SuccessResponse{data=[]models.Document} "Documents retrieved successfully"
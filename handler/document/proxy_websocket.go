@@ -14,6 +14,7 @@ import (
 	"ridash/models"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/docmanager"
 )
 
@@ -107,7 +108,11 @@ func (h *DocumentHandler) ProxyDocumentWebsocket(c echo.Context) error {
 }
 
 func canWriteDocument(ctx context.Context, tx pgx.Tx, docCtx documentContext, userID int64) (bool, error) {
-	if isTeamOwner(userID, docCtx.Team) {
+	allowed, err := authorizeDocument(ctx, tx, docCtx, userID, authz.ActionDocumentUpdate)
+	if err != nil {
+		return false, err
+	}
+	if allowed {
 		return true, nil
 	}
 
@@ -6,6 +6,7 @@ import (
 	"ridash/models"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/id"
 	"ridash/utils/response"
 	"strconv"
@@ -21,7 +22,7 @@ import (
 
 // ListShares godoc
 // @Summary List document shares
-// @Description Lists all shares for a document (owner only)
+// @Description Lists all shares for a document (team owners and admins only)
 // @Tags documents
 // @Produce json
 // @Param id path int true "Document ID"
@@ -60,7 +61,12 @@ func (h *DocumentHandler) ListShares(c echo.Context) error {
 	if docCtx.Document == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Document not found")
 	}
-	if !isTeamOwner(*userID, docCtx.Team) {
+	allowed, err := authorizeDocument(c.Request().Context(), tx, docCtx, *userID, authz.ActionDocumentShare)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
 	}
 
@@ -89,7 +95,7 @@ type createShareRequest struct {
 
 // CreateShare godoc
 // @Summary Create a share
-// @Description Shares a document with a user (owner only)
+// @Description Shares a document with a user (team owners and admins only)
 // @Tags documents
 // @Accept json
 // @Produce json
@@ -98,7 +104,7 @@ type createShareRequest struct {
 // @Success 200 {object} response.SuccessResponse{data=models.DocsShare} "Share created successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid request body or document ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
-// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 403 {object} response.ErrorResponse "Forbidden or email address not verified"
 // @Failure 404 {object} response.ErrorResponse "Document not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /documents/{id}/shares [post]
@@ -138,10 +144,24 @@ func (h *DocumentHandler) CreateShare(c echo.Context) error {
 	if docCtx.Document == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Document not found")
 	}
-	if !isTeamOwner(*userID, docCtx.Team) {
+	allowed, err := authorizeDocument(c.Request().Context(), tx, docCtx, *userID, authz.ActionDocumentShare)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
 	}
 
+	canShare, err := authz.CanShare(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to check email verification", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check email verification")
+	}
+	if !canShare {
+		return echo.NewHTTPError(http.StatusForbidden, "Verify your email address before sharing")
+	}
+
 	existingShare, err := repository.GetShareByDocumentAndUser(c.Request().Context(), tx, docID, req.UserID)
 	if err != nil {
 		zap.L().Error("Failed to check existing share", zap.Error(err))
@@ -187,7 +207,7 @@ type updateShareRequest struct {
 
 // UpdateShare godoc
 // @Summary Update a share
-// @Description Updates a document share (owner only)
+// @Description Updates a document share (team owners and admins only)
 // @Tags documents
 // @Accept json
 // @Produce json
@@ -236,7 +256,12 @@ func (h *DocumentHandler) UpdateShare(c echo.Context) error {
 	if docCtx.Document == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Document not found")
 	}
-	if !isTeamOwner(*userID, docCtx.Team) {
+	allowed, err := authorizeDocument(c.Request().Context(), tx, docCtx, *userID, authz.ActionDocumentShare)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
 	}
 
@@ -270,7 +295,7 @@ func (h *DocumentHandler) UpdateShare(c echo.Context) error {
 
 // DeleteShare godoc
 // @Summary Delete a share
-// @Description Deletes a document share (owner only)
+// @Description Deletes a document share (team owners and admins only)
 // @Tags documents
 // @Produce json
 // @Param id path int true "Document ID"
@@ -309,7 +334,12 @@ func (h *DocumentHandler) DeleteShare(c echo.Context) error {
 	if docCtx.Document == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Document not found")
 	}
-	if !isTeamOwner(*userID, docCtx.Team) {
+	allowed, err := authorizeDocument(c.Request().Context(), tx, docCtx, *userID, authz.ActionDocumentShare)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
 	}
 
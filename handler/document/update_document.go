@@ -6,6 +6,7 @@ import (
 	"ridash/models"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 	"time"
@@ -26,7 +27,7 @@ type updateDocumentRequest struct {
 
 // UpdateDocument godoc
 // @Summary Update a document
-// @Description Updates a document owned by the authenticated user
+// @Description Updates a document (team members with edit access)
 // @Tags documents
 // @Accept json
 // @Produce json
@@ -76,7 +77,12 @@ func (h *DocumentHandler) UpdateDocument(c echo.Context) error {
 	if docCtx.Document == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Document not found")
 	}
-	if !isTeamOwner(*userID, docCtx.Team) {
+	allowed, err := authorizeDocument(c.Request().Context(), tx, docCtx, *userID, authz.ActionDocumentUpdate)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
 	}
 
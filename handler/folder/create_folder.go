@@ -6,6 +6,7 @@ import (
 	"ridash/models"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/id"
 	"ridash/utils/response"
 	"strconv"
@@ -27,7 +28,7 @@ type createFolderRequest struct {
 
 // CreateFolder godoc
 // @Summary Create a new folder for a team
-// @Description Creates a folder within a team; only team owner can create folders
+// @Description Creates a folder within a team; team owners and admins can create folders
 // @Tags folder
 // @Accept json
 // @Produce json
@@ -36,7 +37,7 @@ type createFolderRequest struct {
 // @Success 200 {object} response.SuccessResponse{data=models.Folder} "Folder created successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid request body or team ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
-// @Failure 403 {object} response.ErrorResponse "Only team owner can create folders"
+// @Failure 403 {object} response.ErrorResponse "Only team owner or admins can create folders"
 // @Failure 404 {object} response.ErrorResponse "Team or parent folder not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /teams/{teamID}/folders [post]
@@ -79,8 +80,14 @@ func (h *FolderHandler) CreateFolder(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
 	}
 
-	if team.OwnerID != *userID {
-		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can create folders")
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionFolderCreate)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can create folders")
 	}
 
 	if req.ParentFolder != nil {
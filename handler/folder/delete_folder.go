@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 
@@ -17,7 +18,7 @@ import (
 
 // DeleteFolder godoc
 // @Summary Delete a folder
-// @Description Deletes a folder within a team (owner only)
+// @Description Deletes a folder within a team (owner or admin only)
 // @Tags folder
 // @Accept json
 // @Produce json
@@ -26,7 +27,7 @@ import (
 // @Success 200 {object} response.SuccessResponse "Folder deleted successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid team ID or folder ID"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
-// @Failure 403 {object} response.ErrorResponse "Only team owner can delete the folder"
+// @Failure 403 {object} response.ErrorResponse "Only team owner or admins can delete the folder"
 // @Failure 404 {object} response.ErrorResponse "Team or folder not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /teams/{teamID}/folders/{id} [delete]
@@ -66,8 +67,14 @@ func (h *FolderHandler) DeleteFolder(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
 	}
 
-	if team.OwnerID != *userID {
-		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can delete the folder")
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionFolderDelete)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can delete the folder")
 	}
 
 	folder, err := repository.GetFolderByIDAndTeamID(c.Request().Context(), tx, folderID, teamID)
@@ -3,6 +3,8 @@ package folder
 import (
 	"net/http"
 	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 
@@ -24,11 +26,18 @@ import (
 // @Param id path int true "Folder ID"
 // @Success 200 {object} response.SuccessResponse{data=models.Folder} "Folder retrieved successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid team ID or folder ID"
-// @Failure 404 {object} response.ErrorResponse "Folder not found"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Only team members can view folders"
+// @Failure 404 {object} response.ErrorResponse "Team or folder not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /teams/{teamID}/folders/{id} [get]
 // @Security BearerAuth
 func (h *FolderHandler) GetFolder(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
 	teamIDStr := c.Param("teamID")
 	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
 	if err != nil {
@@ -48,6 +57,26 @@ func (h *FolderHandler) GetFolder(c echo.Context) error {
 	}
 	defer repository.DeferRollback(tx, c.Request().Context())
 
+	team, err := repository.GetTeamByID(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to get team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team")
+	}
+
+	if team == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	}
+
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionFolderRead)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team members can view folders")
+	}
+
 	folder, err := repository.GetFolderByIDAndTeamID(c.Request().Context(), tx, folderID, teamID)
 	if err != nil {
 		zap.L().Error("Failed to get folder", zap.Error(err))
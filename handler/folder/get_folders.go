@@ -3,6 +3,8 @@ package folder
 import (
 	"net/http"
 	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 
@@ -23,11 +25,18 @@ import (
 // @Param teamID path int true "Team ID"
 // @Success 200 {object} response.SuccessResponse{data=[]models.Folder} "Folders retrieved successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid team ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Only team members can view folders"
 // @Failure 404 {object} response.ErrorResponse "Team not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /teams/{teamID}/folders [get]
 // @Security BearerAuth
 func (h *FolderHandler) GetFolders(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
 	teamIDStr := c.Param("teamID")
 	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
 	if err != nil {
@@ -51,6 +60,16 @@ func (h *FolderHandler) GetFolders(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
 	}
 
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionFolderRead)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team members can view folders")
+	}
+
 	folders, err := repository.GetFoldersByTeamID(c.Request().Context(), tx, teamID)
 	if err != nil {
 		zap.L().Error("Failed to get folders", zap.Error(err))
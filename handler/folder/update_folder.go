@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 	"time"
@@ -25,7 +26,7 @@ type updateFolderRequest struct {
 
 // UpdateFolder godoc
 // @Summary Update a folder
-// @Description Updates folder information (only accessible by team owner)
+// @Description Updates folder information (team owners and admins only)
 // @Tags folder
 // @Accept json
 // @Produce json
@@ -35,7 +36,7 @@ type updateFolderRequest struct {
 // @Success 200 {object} response.SuccessResponse{data=models.Folder} "Folder updated successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid request body or IDs"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
-// @Failure 403 {object} response.ErrorResponse "Only team owner can update the folder"
+// @Failure 403 {object} response.ErrorResponse "Only team owner or admins can update the folder"
 // @Failure 404 {object} response.ErrorResponse "Team or folder not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /teams/{teamID}/folders/{id} [put]
@@ -84,8 +85,14 @@ func (h *FolderHandler) UpdateFolder(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
 	}
 
-	if team.OwnerID != *userID {
-		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can update the folder")
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionFolderUpdate)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can update the folder")
 	}
 
 	folder, err := repository.GetFolderByIDAndTeamID(c.Request().Context(), tx, folderID, teamID)
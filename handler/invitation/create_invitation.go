@@ -0,0 +1,196 @@
+package invitation
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
+	"ridash/utils/config"
+	"ridash/utils/email"
+	"ridash/utils/id"
+	invitationutil "ridash/utils/invitation"
+	"ridash/utils/mailer"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | CreateInvitation                             |
+// +----------------------------------------------+
+
+type createInvitationRequest struct {
+	Email string      `json:"email" validate:"required,email,max=255" example:"user@example.com"`
+	Role  models.Role `json:"role" validate:"required,oneof=admin member" example:"member"`
+}
+
+// CreateInvitation godoc
+// @Summary Invite someone to a team
+// @Description Emails a signed, expiring invitation to join the team (owner or admin only, only the owner can invite admins)
+// @Tags invitation
+// @Accept json
+// @Produce json
+// @Param teamID path int true "Team ID"
+// @Param request body createInvitationRequest true "Create invitation request"
+// @Success 200 {object} response.SuccessResponse{data=models.Invitation} "Invitation sent successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body, team ID, or the invitee is already invited or a member"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden or email address not verified"
+// @Failure 404 {object} response.ErrorResponse "Team not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /teams/{teamID}/invitations [post]
+// @Security BearerAuth
+func (h *InvitationHandler) CreateInvitation(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	teamIDStr := c.Param("teamID")
+	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid team ID")
+	}
+
+	var req createInvitationRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	inviteeEmail, err := email.ValidateAddress(req.Email)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid email address")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	team, err := repository.GetTeamByID(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to get team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team")
+	}
+	if team == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	}
+
+	role, err := authz.GetTeamRole(c.Request().Context(), tx, team, *userID)
+	if err != nil {
+		zap.L().Error("Failed to resolve team role", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve team role")
+	}
+	if !authz.Can(role, authz.ActionMembersManage) {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can invite members")
+	}
+	if req.Role == models.RoleAdmin && role != models.RoleOwner {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can invite admins")
+	}
+
+	canShare, err := authz.CanShare(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to check email verification", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check email verification")
+	}
+	if !canShare {
+		return echo.NewHTTPError(http.StatusForbidden, "Verify your email address before sharing")
+	}
+
+	// Don't invite people who already belong to the team
+	account, err := repository.GetAccountByEmail(c.Request().Context(), tx, inviteeEmail)
+	if err != nil {
+		zap.L().Error("Failed to get account by email", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account by email")
+	}
+	if account != nil {
+		existingMember, err := repository.GetTeamMemberByTeamAndUser(c.Request().Context(), tx, teamID, account.UserID)
+		if err != nil {
+			zap.L().Error("Failed to check existing team member", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check existing team member")
+		}
+		if existingMember != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "User is already a member of this team")
+		}
+	}
+
+	now := time.Now()
+	pendingInvitation, err := repository.GetPendingInvitationByTeamAndEmail(c.Request().Context(), tx, teamID, inviteeEmail, now)
+	if err != nil {
+		zap.L().Error("Failed to check pending invitation", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check pending invitation")
+	}
+	if pendingInvitation != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "This email already has a pending invitation")
+	}
+
+	invitationID, err := id.GetID()
+	if err != nil {
+		zap.L().Error("Failed to generate invitation ID", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate invitation ID")
+	}
+
+	invitation := models.Invitation{
+		ID:        invitationID,
+		TeamID:    teamID,
+		Email:     inviteeEmail,
+		Role:      req.Role,
+		InvitedBy: *userID,
+		Status:    models.InvitationStatusPending,
+		ExpiresAt: now.Add(time.Duration(config.Env().InvitationExpiresAt) * time.Second),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := repository.CreateInvitation(c.Request().Context(), tx, invitation); err != nil {
+		zap.L().Error("Failed to create invitation", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create invitation")
+	}
+
+	token, err := invitationutil.GenerateToken(invitation)
+	if err != nil {
+		zap.L().Error("Failed to generate invitation token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate invitation token")
+	}
+
+	inviter, err := repository.GetUserByID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get inviter", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get inviter")
+	}
+
+	inviterName := ""
+	if inviter != nil {
+		inviterName = inviter.DisplayName
+	}
+
+	// Queued in the same transaction so the email only goes out if the invitation is stored
+	err = h.Mailer.Enqueue(c.Request().Context(), tx, inviteeEmail, mailer.TemplateInvitation, mailer.InvitationData{
+		TeamName:    team.Name,
+		InviterName: inviterName,
+		AcceptURL:   invitationutil.AcceptURL(token),
+		ExpiresAt:   invitation.ExpiresAt,
+	})
+	if err != nil {
+		zap.L().Error("Failed to queue invitation email", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue invitation email")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Invitation sent successfully", invitation))
+}
@@ -0,0 +1,12 @@
+package invitation
+
+import (
+	"ridash/utils/mailer"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InvitationHandler struct {
+	DB     *pgxpool.Pool
+	Mailer *mailer.Mailer
+}
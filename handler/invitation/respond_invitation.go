@@ -0,0 +1,132 @@
+package invitation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	invitationutil "ridash/utils/invitation"
+	"ridash/utils/response"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type respondInvitationRequest struct {
+	Token string `json:"token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// +----------------------------------------------+
+// | AcceptInvitation                             |
+// +----------------------------------------------+
+
+// AcceptInvitation godoc
+// @Summary Accept a team invitation
+// @Description Adds the authenticated user to the team the invitation token was issued for, the invitation must have been sent to one of their verified email addresses
+// @Tags invitation
+// @Accept json
+// @Produce json
+// @Param request body respondInvitationRequest true "Invitation token from the email"
+// @Success 200 {object} response.SuccessResponse{data=models.TeamMember} "Invitation accepted successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid, expired or already answered invitation"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "The invitation was sent to another email address"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /invitations/accept [post]
+// @Security BearerAuth
+func (h *InvitationHandler) AcceptInvitation(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req respondInvitationRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Only the addresses the user verified count, anyone can sign up with an address they don't own
+	verifiedEmails, err := invitationutil.VerifiedEmails(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get verified emails", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get verified emails")
+	}
+
+	teamMember, err := invitationutil.Accept(c.Request().Context(), tx, req.Token, *userID, verifiedEmails)
+	if err != nil {
+		if errors.Is(err, invitationutil.ErrEmailMismatch) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		if invitationutil.IsClientError(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		zap.L().Error("Failed to accept invitation", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to accept invitation")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Invitation accepted successfully", teamMember))
+}
+
+// +----------------------------------------------+
+// | DeclineInvitation                            |
+// +----------------------------------------------+
+
+// DeclineInvitation godoc
+// @Summary Decline a team invitation
+// @Description Declines the invitation the token was issued for, no account is needed
+// @Tags invitation
+// @Accept json
+// @Produce json
+// @Param request body respondInvitationRequest true "Invitation token from the email"
+// @Success 200 {object} response.SuccessResponse "Invitation declined successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid, expired or already answered invitation"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /invitations/decline [post]
+func (h *InvitationHandler) DeclineInvitation(c echo.Context) error {
+	var req respondInvitationRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	if _, err := invitationutil.Decline(c.Request().Context(), tx, req.Token); err != nil {
+		if invitationutil.IsClientError(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		zap.L().Error("Failed to decline invitation", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to decline invitation")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Invitation declined successfully"))
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 
@@ -59,7 +60,13 @@ func (h *TeamHandler) DeleteTeam(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
 	}
 
-	if team.OwnerID != *userID {
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionTeamDelete)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can delete the team")
 	}
 
@@ -68,6 +75,11 @@ func (h *TeamHandler) DeleteTeam(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete team members")
 	}
 
+	if err := repository.DeleteInvitationsByTeamID(c.Request().Context(), tx, teamID); err != nil {
+		zap.L().Error("Failed to delete team invitations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete team invitations")
+	}
+
 	if err := repository.DeleteTeam(c.Request().Context(), tx, teamID); err != nil {
 		zap.L().Error("Failed to delete team", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete team")
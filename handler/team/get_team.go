@@ -3,6 +3,8 @@ package team
 import (
 	"net/http"
 	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 
@@ -16,17 +18,26 @@ import (
 
 // GetTeam godoc
 // @Summary Get team by ID
-// @Description Retrieves a team by its ID
+// @Description Retrieves a team by its ID (team members only)
 // @Tags team
 // @Accept json
 // @Produce json
 // @Param id path int true "Team ID"
 // @Success 200 {object} response.SuccessResponse{data=models.Team} "Team retrieved successfully"
 // @Failure 400 {object} response.ErrorResponse "Invalid team ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Only team members can view the team"
 // @Failure 404 {object} response.ErrorResponse "Team not found"
 // @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /teams/{id} [get]
+// @Security BearerAuth
 func (h *TeamHandler) GetTeam(c echo.Context) error {
+	// Get the user ID from the context (set by auth middleware)
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
 	// Get the team ID from the URL parameter
 	teamIDStr := c.Param("id")
 	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
@@ -55,6 +66,17 @@ func (h *TeamHandler) GetTeam(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
 	}
 
+	// Check if the user is allowed to view the team
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionTeamRead)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team members can view the team")
+	}
+
 	// Commit the transaction
 	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
 		zap.L().Error("Failed to commit transaction", zap.Error(err))
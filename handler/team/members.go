@@ -0,0 +1,413 @@
+package team
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
+	"ridash/utils/id"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | ListMembers                                  |
+// +----------------------------------------------+
+
+// ListMembers godoc
+// @Summary List team members
+// @Description Lists all members of a team (team members only)
+// @Tags team
+// @Produce json
+// @Param teamID path int true "Team ID"
+// @Success 200 {object} response.SuccessResponse{data=[]models.TeamMember} "Team members retrieved successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid team ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Team not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /teams/{teamID}/members [get]
+// @Security BearerAuth
+func (h *TeamHandler) ListMembers(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	teamIDStr := c.Param("teamID")
+	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid team ID")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	team, err := repository.GetTeamByID(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to get team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team")
+	}
+	if team == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	}
+
+	role, err := authz.GetTeamRole(c.Request().Context(), tx, team, *userID)
+	if err != nil {
+		zap.L().Error("Failed to resolve team role", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve team role")
+	}
+	if !authz.Can(role, authz.ActionTeamRead) {
+		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+	}
+
+	members, err := repository.ListTeamMembersByTeamID(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to list team members", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list team members")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Team members retrieved successfully", members))
+}
+
+// +----------------------------------------------+
+// | AddMember                                    |
+// +----------------------------------------------+
+
+type addMemberRequest struct {
+	UserID int64       `json:"user_id,string" validate:"required" example:"175928847299117063"`
+	Role   models.Role `json:"role" validate:"required,oneof=admin member" example:"member"`
+}
+
+// AddMember godoc
+// @Summary Add a team member
+// @Description Adds a user to a team (owner or admin only, only the owner can add admins)
+// @Tags team
+// @Accept json
+// @Produce json
+// @Param teamID path int true "Team ID"
+// @Param request body addMemberRequest true "Add member request"
+// @Success 200 {object} response.SuccessResponse{data=models.TeamMember} "Team member added successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body, team ID or user is already a member"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Team or user not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /teams/{teamID}/members [post]
+// @Security BearerAuth
+func (h *TeamHandler) AddMember(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	teamIDStr := c.Param("teamID")
+	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid team ID")
+	}
+
+	var req addMemberRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	team, err := repository.GetTeamByID(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to get team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team")
+	}
+	if team == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	}
+
+	role, err := authz.GetTeamRole(c.Request().Context(), tx, team, *userID)
+	if err != nil {
+		zap.L().Error("Failed to resolve team role", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve team role")
+	}
+	if !authz.Can(role, authz.ActionMembersManage) {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can add members")
+	}
+	if req.Role == models.RoleAdmin && role != models.RoleOwner {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can add admins")
+	}
+
+	user, err := repository.GetUserByID(c.Request().Context(), tx, req.UserID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	existingMember, err := repository.GetTeamMemberByTeamAndUser(c.Request().Context(), tx, teamID, req.UserID)
+	if err != nil {
+		zap.L().Error("Failed to check existing team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check existing team member")
+	}
+	if existingMember != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "User is already a member of this team")
+	}
+
+	teamMemberID, err := id.GetID()
+	if err != nil {
+		zap.L().Error("Failed to generate team member ID", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate team member ID")
+	}
+
+	now := time.Now()
+	teamMember := models.TeamMember{
+		ID:        teamMemberID,
+		TeamID:    teamID,
+		UserID:    req.UserID,
+		Role:      req.Role,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := repository.CreateTeamMember(c.Request().Context(), tx, teamMember); err != nil {
+		zap.L().Error("Failed to add team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add team member")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Team member added successfully", teamMember))
+}
+
+// +----------------------------------------------+
+// | UpdateMember                                 |
+// +----------------------------------------------+
+
+type updateMemberRequest struct {
+	Role models.Role `json:"role" validate:"required,oneof=admin member" example:"admin"`
+}
+
+// UpdateMember godoc
+// @Summary Update a team member's role
+// @Description Changes the role of a team member (owner or admin only, only the owner can grant or revoke admin)
+// @Tags team
+// @Accept json
+// @Produce json
+// @Param teamID path int true "Team ID"
+// @Param userID path int true "User ID of the member"
+// @Param request body updateMemberRequest true "Update member request"
+// @Success 200 {object} response.SuccessResponse{data=models.TeamMember} "Team member updated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body or path parameters"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Team or member not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /teams/{teamID}/members/{userID} [put]
+// @Security BearerAuth
+func (h *TeamHandler) UpdateMember(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	teamID, memberUserID, err := parseTeamAndMemberIDs(c)
+	if err != nil {
+		return err
+	}
+
+	var req updateMemberRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	team, err := repository.GetTeamByID(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to get team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team")
+	}
+	if team == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	}
+
+	role, err := authz.GetTeamRole(c.Request().Context(), tx, team, *userID)
+	if err != nil {
+		zap.L().Error("Failed to resolve team role", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve team role")
+	}
+	if !authz.Can(role, authz.ActionMembersManage) {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can update members")
+	}
+
+	member, err := repository.GetTeamMemberByTeamAndUser(c.Request().Context(), tx, teamID, memberUserID)
+	if err != nil {
+		zap.L().Error("Failed to get team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team member")
+	}
+	if member == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team member not found")
+	}
+	if member.Role == models.RoleOwner || member.UserID == team.OwnerID {
+		return echo.NewHTTPError(http.StatusForbidden, "Team owner role cannot be changed")
+	}
+	if role != models.RoleOwner && (member.Role == models.RoleAdmin || req.Role == models.RoleAdmin) {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can grant or revoke admin")
+	}
+
+	now := time.Now()
+	if err := repository.UpdateTeamMemberRole(c.Request().Context(), tx, teamID, memberUserID, req.Role, now); err != nil {
+		zap.L().Error("Failed to update team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update team member")
+	}
+
+	member.Role = req.Role
+	member.UpdatedAt = now
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Team member updated successfully", member))
+}
+
+// +----------------------------------------------+
+// | RemoveMember                                 |
+// +----------------------------------------------+
+
+// RemoveMember godoc
+// @Summary Remove a team member
+// @Description Removes a member from a team (owner or admin only), members can also remove themselves to leave the team
+// @Tags team
+// @Produce json
+// @Param teamID path int true "Team ID"
+// @Param userID path int true "User ID of the member"
+// @Success 200 {object} response.SuccessResponse "Team member removed successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid path parameters"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Team or member not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /teams/{teamID}/members/{userID} [delete]
+// @Security BearerAuth
+func (h *TeamHandler) RemoveMember(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	teamID, memberUserID, err := parseTeamAndMemberIDs(c)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	team, err := repository.GetTeamByID(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to get team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team")
+	}
+	if team == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	}
+
+	role, err := authz.GetTeamRole(c.Request().Context(), tx, team, *userID)
+	if err != nil {
+		zap.L().Error("Failed to resolve team role", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve team role")
+	}
+
+	member, err := repository.GetTeamMemberByTeamAndUser(c.Request().Context(), tx, teamID, memberUserID)
+	if err != nil {
+		zap.L().Error("Failed to get team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team member")
+	}
+	if member == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team member not found")
+	}
+	if member.Role == models.RoleOwner || member.UserID == team.OwnerID {
+		return echo.NewHTTPError(http.StatusForbidden, "Team owner cannot be removed")
+	}
+
+	// Members can always leave a team, otherwise the caller needs to manage the team
+	if memberUserID != *userID {
+		if !authz.Can(role, authz.ActionMembersManage) {
+			return echo.NewHTTPError(http.StatusForbidden, "Only team owner or admins can remove members")
+		}
+		if role != models.RoleOwner && member.Role == models.RoleAdmin {
+			return echo.NewHTTPError(http.StatusForbidden, "Only team owner can remove admins")
+		}
+	}
+
+	if err := repository.DeleteTeamMember(c.Request().Context(), tx, teamID, memberUserID); err != nil {
+		zap.L().Error("Failed to remove team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove team member")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Team member removed successfully"))
+}
+
+// parseTeamAndMemberIDs parses team and member user IDs from the path params.
+func parseTeamAndMemberIDs(c echo.Context) (int64, int64, error) {
+	teamIDStr := c.Param("teamID")
+	userIDStr := c.Param("userID")
+
+	teamID, err := strconv.ParseInt(teamIDStr, 10, 64)
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid team ID")
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	return teamID, userID, nil
+}
@@ -0,0 +1,139 @@
+package team
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | TransferTeam                                 |
+// +----------------------------------------------+
+
+type transferTeamRequest struct {
+	UserID int64 `json:"user_id,string" validate:"required" example:"175928847299117063"`
+}
+
+// TransferTeam godoc
+// @Summary Transfer a team
+// @Description Makes another member the owner of the team, the previous owner stays on as an admin (only accessible by team owner)
+// @Tags team
+// @Accept json
+// @Produce json
+// @Param id path int true "Team ID"
+// @Param request body transferTeamRequest true "Transfer team request"
+// @Success 200 {object} response.SuccessResponse{data=models.Team} "Team transferred successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body or team ID, or the user isn't a member of the team"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Only team owner can transfer the team"
+// @Failure 404 {object} response.ErrorResponse "Team not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /teams/{id}/transfer [post]
+// @Security BearerAuth
+func (h *TeamHandler) TransferTeam(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	teamID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid team ID")
+	}
+
+	var req transferTeamRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Concurrent transfers of the team run one after the other, the second one sees the new owner
+	team, err := repository.GetTeamByIDForUpdate(c.Request().Context(), tx, teamID)
+	if err != nil {
+		zap.L().Error("Failed to get team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team")
+	}
+	if team == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
+	}
+
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionTeamTransfer)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+	if !allowed {
+		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can transfer the team")
+	}
+
+	if req.UserID == team.OwnerID {
+		return echo.NewHTTPError(http.StatusBadRequest, "The user already owns the team")
+	}
+
+	// Locking the new owner holds back their account deletion until the team is theirs
+	newOwner, err := repository.GetUserByIDForUpdate(c.Request().Context(), tx, req.UserID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+	if newOwner == nil || newOwner.DeletedAt != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "The user is not a member of this team")
+	}
+
+	// Only someone already in the team can take it over
+	member, err := repository.GetTeamMemberByTeamAndUser(c.Request().Context(), tx, teamID, req.UserID)
+	if err != nil {
+		zap.L().Error("Failed to get team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get team member")
+	}
+	if member == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "The user is not a member of this team")
+	}
+
+	now := time.Now()
+	if err := repository.UpdateTeamOwner(c.Request().Context(), tx, teamID, req.UserID, now); err != nil {
+		zap.L().Error("Failed to transfer team", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to transfer team")
+	}
+
+	if err := repository.UpdateTeamMemberRole(c.Request().Context(), tx, teamID, req.UserID, models.RoleOwner, now); err != nil {
+		zap.L().Error("Failed to update team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update team member")
+	}
+
+	if err := repository.UpdateTeamMemberRole(c.Request().Context(), tx, teamID, team.OwnerID, models.RoleAdmin, now); err != nil {
+		zap.L().Error("Failed to update team member", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update team member")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Team transferred", zap.Int64("team_id", teamID), zap.Int64("from_user_id", team.OwnerID), zap.Int64("to_user_id", req.UserID))
+
+	team.OwnerID = req.UserID
+	team.UpdatedAt = now
+
+	return c.JSON(http.StatusOK, response.Success("Team transferred successfully", team))
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"ridash/repository"
 	authutil "ridash/utils/auth"
+	"ridash/utils/authz"
 	"ridash/utils/response"
 	"strconv"
 	"time"
@@ -84,8 +85,14 @@ func (h *TeamHandler) UpdateTeam(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Team not found")
 	}
 
-	// Check if the user is the owner of the team
-	if team.OwnerID != *userID {
+	// Check if the user is allowed to update the team
+	allowed, err := authz.Authorize(c.Request().Context(), tx, team, *userID, authz.ActionTeamUpdate)
+	if err != nil {
+		zap.L().Error("Failed to check permissions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check permissions")
+	}
+
+	if !allowed {
 		return echo.NewHTTPError(http.StatusForbidden, "Only team owner can update the team")
 	}
 
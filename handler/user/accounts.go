@@ -0,0 +1,163 @@
+package user
+
+import (
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | ListAccounts                                 |
+// +----------------------------------------------+
+
+// ListAccounts godoc
+// @Summary List linked accounts
+// @Description Lists the login methods (email/password and OAuth providers) linked to the authenticated user
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=[]models.Account} "Accounts retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/accounts [get]
+// @Security BearerAuth
+func (h *UserHandler) ListAccounts(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	accounts, err := repository.ListAccountsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list accounts", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list accounts")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Accounts retrieved successfully", accounts))
+}
+
+// +----------------------------------------------+
+// | UnlinkAccount                                |
+// +----------------------------------------------+
+
+// UnlinkAccount godoc
+// @Summary Unlink an account
+// @Description Removes a login method from the authenticated user, the last usable one (passkeys included) can't be removed. Removing the email account also removes the password
+// @Tags user
+// @Produce json
+// @Param accountID path string true "Account ID"
+// @Success 200 {object} response.SuccessResponse "Account unlinked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid account ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Account not found"
+// @Failure 409 {object} response.ErrorResponse "The account is the last usable login method"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/accounts/{accountID} [delete]
+// @Security BearerAuth
+func (h *UserHandler) UnlinkAccount(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	accountID, err := strconv.ParseInt(c.Param("accountID"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid account ID")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Lock the user so two unlinks can't each leave the other as the last login method
+	user, err := repository.GetUserByIDForUpdate(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	accounts, err := repository.ListAccountsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list accounts", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list accounts")
+	}
+
+	// Every account is a way in, email accounts work through magic links even without a password
+	var target *models.Account
+	remaining := 0
+	for i := range accounts {
+		if accounts[i].ID == accountID {
+			target = &accounts[i]
+			continue
+		}
+		remaining++
+	}
+
+	if target == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Account not found")
+	}
+
+	// Passkeys are login methods too
+	passkeys, err := repository.CountWebAuthnCredentialsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to count passkeys", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to count passkeys")
+	}
+	remaining += passkeys
+
+	if remaining == 0 {
+		return echo.NewHTTPError(http.StatusConflict, "Can't remove the last login method")
+	}
+
+	if err := repository.DeleteOAuthTokenByAccountID(c.Request().Context(), tx, target.ID); err != nil {
+		zap.L().Error("Failed to delete oauth token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete oauth token")
+	}
+
+	if err := repository.DeleteAccount(c.Request().Context(), tx, target.ID, *userID); err != nil {
+		zap.L().Error("Failed to delete account", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete account")
+	}
+
+	// The password only works through the email account
+	if target.Provider == models.ProviderEmail && user.PasswordHash != nil {
+		if err := repository.ClearUserPasswordHash(c.Request().Context(), tx, *userID, time.Now()); err != nil {
+			zap.L().Error("Failed to clear password", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to clear password")
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Account unlinked", zap.Int64("user_id", *userID), zap.Int64("account_id", target.ID), zap.String("provider", string(target.Provider)))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Account unlinked successfully"))
+}
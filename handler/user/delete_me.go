@@ -0,0 +1,98 @@
+package user
+
+import (
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | DeleteMe                                     |
+// +----------------------------------------------+
+
+// DeleteMe godoc
+// @Summary Delete the current user
+// @Description Deletes the accounts, sessions, credentials, team memberships and document shares of the authenticated user and anonymizes the user, teams they own must be transferred or deleted first
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.SuccessResponse "User deleted successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 409 {object} response.ErrorResponse "The user still owns teams"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me [delete]
+// @Security BearerAuth
+func (h *UserHandler) DeleteMe(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Locking the user holds back the teams created and handed to them meanwhile
+	user, err := repository.GetUserByIDForUpdate(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+
+	if user == nil || user.DeletedAt != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	teams, err := repository.ListTeamsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list teams", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list teams")
+	}
+
+	// Teams would be left without an owner, the user decides who takes them over
+	for _, team := range teams {
+		if team.OwnerID == *userID {
+			return echo.NewHTTPError(http.StatusConflict, "Transfer or delete the teams you own first")
+		}
+	}
+
+	if err := repository.DeleteUserData(c.Request().Context(), tx, *userID); err != nil {
+		zap.L().Error("Failed to delete user data", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete user data")
+	}
+
+	// The user record stays so the documents and invitations they left behind keep pointing to someone
+	now := time.Now()
+	if err := repository.AnonymizeUser(c.Request().Context(), tx, *userID, models.DeletedUserDisplayName, now); err != nil {
+		zap.L().Error("Failed to anonymize user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to anonymize user")
+	}
+
+	if err := h.Revocations.RevokeUser(c.Request().Context(), tx, *userID, now); err != nil {
+		zap.L().Error("Failed to revoke access tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke access tokens")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	// The other instances reload when Postgres notifies them
+	if err := h.Revocations.Reload(c.Request().Context()); err != nil {
+		zap.L().Error("Failed to reload access token revocations", zap.Error(err))
+	}
+
+	zap.L().Info("User deleted", zap.Int64("user_id", *userID), zap.String("ip", c.RealIP()))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("User deleted successfully"))
+}
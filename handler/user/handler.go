@@ -0,0 +1,14 @@
+package user
+
+import (
+	"ridash/utils/keyring"
+	"ridash/utils/revocation"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UserHandler struct {
+	DB          *pgxpool.Pool
+	Keyring     *keyring.Keyring
+	Revocations *revocation.Store
+}
@@ -0,0 +1,139 @@
+package user
+
+import (
+	"net/http"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/response"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | ListPasskeys                                 |
+// +----------------------------------------------+
+
+// ListPasskeys godoc
+// @Summary List passkeys
+// @Description Lists the passkeys registered by the authenticated user, new ones are added through /auth/webauthn/register
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=[]models.WebAuthnCredential} "Passkeys retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/passkeys [get]
+// @Security BearerAuth
+func (h *UserHandler) ListPasskeys(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	passkeys, err := repository.ListWebAuthnCredentialsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list passkeys", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list passkeys")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Passkeys retrieved successfully", passkeys))
+}
+
+// +----------------------------------------------+
+// | DeletePasskey                                |
+// +----------------------------------------------+
+
+// DeletePasskey godoc
+// @Summary Delete a passkey
+// @Description Removes a passkey from the authenticated user, the last usable login method can't be removed
+// @Tags user
+// @Produce json
+// @Param passkeyID path string true "Passkey ID"
+// @Success 200 {object} response.SuccessResponse "Passkey deleted successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid passkey ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Passkey not found"
+// @Failure 409 {object} response.ErrorResponse "The passkey is the last usable login method"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/passkeys/{passkeyID} [delete]
+// @Security BearerAuth
+func (h *UserHandler) DeletePasskey(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	passkeyID, err := strconv.ParseInt(c.Param("passkeyID"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid passkey ID")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	// Lock the user so this can't race an unlink into leaving no login method
+	user, err := repository.GetUserByIDForUpdate(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	deleted, err := repository.DeleteWebAuthnCredential(c.Request().Context(), tx, passkeyID, *userID)
+	if err != nil {
+		zap.L().Error("Failed to delete passkey", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete passkey")
+	}
+
+	if !deleted {
+		return echo.NewHTTPError(http.StatusNotFound, "Passkey not found")
+	}
+
+	// Count what is left after the delete, it is rolled back if nothing is
+	remaining, err := repository.CountWebAuthnCredentialsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to count passkeys", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to count passkeys")
+	}
+
+	accounts, err := repository.ListAccountsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list accounts", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list accounts")
+	}
+
+	remaining += len(accounts)
+
+	if remaining == 0 {
+		return echo.NewHTTPError(http.StatusConflict, "Can't remove the last login method")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Passkey deleted", zap.Int64("user_id", *userID), zap.Int64("passkey_id", passkeyID))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Passkey deleted successfully"))
+}
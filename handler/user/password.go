@@ -0,0 +1,192 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"ridash/utils/response"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | ChangePassword                               |
+// +----------------------------------------------+
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password,omitempty" validate:"omitempty,max=255" example:"password123"` // Required when the user already has a password
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=255" example:"newpassword123"`
+}
+
+// ChangePassword godoc
+// @Summary Change or set password
+// @Description Changes the password of the authenticated user, users created through OAuth can set a first password which adds an email/password login. Every other session is signed out
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body changePasswordRequest true "Change password request"
+// @Success 200 {object} response.SuccessResponse "Password updated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body or current password"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 409 {object} response.ErrorResponse "The email address is used by another account"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/password [put]
+// @Security BearerAuth
+func (h *UserHandler) ChangePassword(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	user, err := repository.GetUserByID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	// Changing an existing password needs the current one
+	if user.PasswordHash != nil {
+		if req.CurrentPassword == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Current password is required")
+		}
+
+		match, err := encrypt.ComparePasswordAndHash(req.CurrentPassword, *user.PasswordHash)
+		if err != nil {
+			zap.L().Error("Failed to compare password and hash", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to compare password and hash")
+		}
+
+		if !match {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid current password")
+		}
+	}
+
+	now := time.Now()
+
+	// OAuth-only users get an email/password login for their address
+	accounts, err := repository.ListAccountsByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list accounts", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list accounts")
+	}
+
+	if !hasEmailAccount(accounts) {
+		source := primaryAccount(accounts)
+		if source == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "No email address is linked to this user")
+		}
+
+		existing, err := repository.GetAccountByProviderAndEmail(c.Request().Context(), tx, models.ProviderEmail, source.Email)
+		if err != nil {
+			zap.L().Error("Failed to check if email is in use", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check if email is in use")
+		}
+
+		if existing != nil {
+			return echo.NewHTTPError(http.StatusConflict, "This email is already in use")
+		}
+
+		accountID, err := id.GetID()
+		if err != nil {
+			zap.L().Error("Failed to generate account ID", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate account ID")
+		}
+
+		err = repository.CreateAccount(c.Request().Context(), tx, models.Account{
+			ID:             accountID,
+			Provider:       models.ProviderEmail,
+			ProviderUserID: strconv.FormatInt(*userID, 10),
+			UserID:         *userID,
+			Email:          source.Email,
+			VerifiedAt:     source.VerifiedAt,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+		if err != nil {
+			zap.L().Error("Failed to create account", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create account")
+		}
+	}
+
+	passwordHash, err := encrypt.CreateArgon2idHash(req.NewPassword, config.GetArgon2idParams())
+	if err != nil {
+		zap.L().Error("Failed to hash password", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to hash password")
+	}
+
+	if err := repository.UpdateUserPasswordHash(c.Request().Context(), tx, *userID, passwordHash, now); err != nil {
+		zap.L().Error("Failed to update password", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update password")
+	}
+
+	// Sign out every other device, the session of this request stays
+	currentFamilyID, err := currentSessionFamilyID(c, tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get refresh token by token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get refresh token by token")
+	}
+
+	if currentFamilyID != 0 {
+		err = repository.RevokeOtherRefreshTokensByUserID(c.Request().Context(), tx, *userID, currentFamilyID, now)
+	} else {
+		err = repository.RevokeRefreshTokensByUserID(c.Request().Context(), tx, *userID, now)
+	}
+	if err != nil {
+		zap.L().Error("Failed to revoke refresh tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke refresh tokens")
+	}
+
+	// Their access tokens stop working too, but the one of this request
+	validAccessToken, accessTokenClaims, _ := h.Keyring.ValidateAccessTokenAndGetClaims(strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer "))
+	if validAccessToken {
+		err = h.Revocations.RevokeOtherTokens(c.Request().Context(), tx, *userID, accessTokenClaims.ID, now)
+	} else {
+		err = h.Revocations.RevokeUser(c.Request().Context(), tx, *userID, now)
+	}
+	if err != nil {
+		zap.L().Error("Failed to revoke access tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke access tokens")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	// The other instances reload when Postgres notifies them
+	if err := h.Revocations.Reload(c.Request().Context()); err != nil {
+		zap.L().Error("Failed to reload access token revocations", zap.Error(err))
+	}
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Password updated successfully"))
+}
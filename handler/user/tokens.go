@@ -0,0 +1,213 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"ridash/utils/response"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | ListTokens                                   |
+// +----------------------------------------------+
+
+// ListTokens godoc
+// @Summary List personal access tokens
+// @Description Lists the personal access tokens of the authenticated user that aren't revoked, the tokens themselves are never shown again
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.SuccessResponse{data=[]models.PersonalAccessToken} "Tokens retrieved successfully"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/tokens [get]
+// @Security BearerAuth
+func (h *UserHandler) ListTokens(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	tokens, err := repository.ListPersonalAccessTokensByUserID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to list tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list tokens")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("Tokens retrieved successfully", tokens))
+}
+
+// +----------------------------------------------+
+// | CreateToken                                  |
+// +----------------------------------------------+
+
+type createTokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=255" example:"CI deploy"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,required" example:"documents:read"`    // See Scope for the list, write scopes include read
+	ExpiresInDays *int     `json:"expires_in_days,omitempty" validate:"omitempty,min=1,max=3650" example:"90"` // The token never expires when left out
+}
+
+type createTokenResponse struct {
+	models.PersonalAccessToken
+	Token string `json:"token" example:"rdpat_Xk29aB..."` // Shown only once
+}
+
+// CreateToken godoc
+// @Summary Create a personal access token
+// @Description Creates a long-lived token for scripts and CI, it is sent as a Bearer token like an access token and only reaches the routes its scopes cover. The token is only returned by this call. Tokens can't manage tokens
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body createTokenRequest true "Create token request"
+// @Success 200 {object} response.SuccessResponse{data=createTokenResponse} "Token created successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/tokens [post]
+// @Security BearerAuth
+func (h *UserHandler) CreateToken(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req createTokenRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+	for _, scope := range req.Scopes {
+		if !models.Scope(scope).IsValid() {
+			return echo.NewHTTPError(http.StatusBadRequest, "Unknown scope "+scope)
+		}
+	}
+
+	plain, err := encrypt.GeneratePersonalAccessToken()
+	if err != nil {
+		zap.L().Error("Failed to generate token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate token")
+	}
+
+	tokenID, err := id.GetID()
+	if err != nil {
+		zap.L().Error("Failed to generate ID", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate ID")
+	}
+
+	now := time.Now()
+	token := models.PersonalAccessToken{
+		ID:          tokenID,
+		UserID:      *userID,
+		Name:        req.Name,
+		TokenPrefix: plain[:len(encrypt.PersonalAccessTokenPrefix)+6],
+		TokenHash:   encrypt.HashToken(plain),
+		Scopes:      req.Scopes,
+		CreatedAt:   now,
+	}
+	if req.ExpiresInDays != nil {
+		expiresAt := now.AddDate(0, 0, *req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	if err := repository.CreatePersonalAccessToken(c.Request().Context(), tx, token); err != nil {
+		zap.L().Error("Failed to create token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create token")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Personal access token created", zap.Int64("user_id", *userID), zap.Int64("token_id", tokenID))
+
+	return c.JSON(http.StatusOK, response.Success("Token created successfully", createTokenResponse{
+		PersonalAccessToken: token,
+		Token:               plain,
+	}))
+}
+
+// +----------------------------------------------+
+// | RevokeToken                                  |
+// +----------------------------------------------+
+
+// RevokeToken godoc
+// @Summary Revoke a personal access token
+// @Description Revokes a personal access token of the authenticated user, requests made with it are refused right away
+// @Tags user
+// @Produce json
+// @Param tokenID path string true "Token ID"
+// @Success 200 {object} response.SuccessResponse "Token revoked successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid token ID"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Token not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me/tokens/{tokenID} [delete]
+// @Security BearerAuth
+func (h *UserHandler) RevokeToken(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	tokenID, err := strconv.ParseInt(c.Param("tokenID"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid token ID")
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	revoked, err := repository.RevokePersonalAccessToken(c.Request().Context(), tx, tokenID, *userID, time.Now())
+	if err != nil {
+		zap.L().Error("Failed to revoke token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke token")
+	}
+
+	if !revoked {
+		return echo.NewHTTPError(http.StatusNotFound, "Token not found")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	zap.L().Info("Personal access token revoked", zap.Int64("user_id", *userID), zap.Int64("token_id", tokenID))
+
+	return c.JSON(http.StatusOK, response.SuccessMessage("Token revoked successfully"))
+}
@@ -0,0 +1,94 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+	"ridash/utils/response"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// +----------------------------------------------+
+// | UpdateMe                                     |
+// +----------------------------------------------+
+
+type updateMeRequest struct {
+	DisplayName *string `json:"display_name,omitempty" validate:"omitempty,min=3,max=255" example:"John Doe"`
+	Avatar      *string `json:"avatar,omitempty" validate:"omitempty,url,max=2048" example:"https://example.com/avatar.jpg"` // An empty string removes the avatar
+}
+
+// UpdateMe godoc
+// @Summary Update current user
+// @Description Updates the display name and/or avatar of the authenticated user, fields left out are unchanged
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body updateMeRequest true "Update profile request"
+// @Success 200 {object} response.SuccessResponse{data=meResponse} "User updated successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request body"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /me [patch]
+// @Security BearerAuth
+func (h *UserHandler) UpdateMe(c echo.Context) error {
+	userID, err := authutil.GetUserIDFromContext(c)
+	if err != nil || userID == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req updateMeRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := validator.New().Struct(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body,"+err.Error())
+	}
+
+	tx, err := repository.StartTransaction(h.DB, c.Request().Context())
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to begin transaction")
+	}
+	defer repository.DeferRollback(tx, c.Request().Context())
+
+	user, err := repository.GetUserByID(c.Request().Context(), tx, *userID)
+	if err != nil {
+		zap.L().Error("Failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user")
+	}
+
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "User not found")
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+
+	if req.Avatar != nil {
+		if *req.Avatar == "" {
+			user.Avatar = nil
+		} else {
+			user.Avatar = req.Avatar
+		}
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := repository.UpdateUserProfile(c.Request().Context(), tx, user.ID, user.DisplayName, user.Avatar, user.UpdatedAt); err != nil {
+		zap.L().Error("Failed to update user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update user")
+	}
+
+	if err := repository.CommitTransaction(tx, c.Request().Context()); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to commit transaction")
+	}
+
+	return c.JSON(http.StatusOK, response.Success("User updated successfully", newMeResponse(*user)))
+}
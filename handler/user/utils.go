@@ -0,0 +1,74 @@
+package user
+
+import (
+	"ridash/models"
+	"ridash/repository"
+	authutil "ridash/utils/auth"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// meResponse is the current user without the password hash
+type meResponse struct {
+	models.User
+	HasPassword bool `json:"has_password" example:"true"` // Whether the user can log in with a password
+}
+
+// newMeResponse strips the password hash from the user
+func newMeResponse(user models.User) meResponse {
+	hasPassword := user.PasswordHash != nil
+	user.PasswordHash = nil
+
+	return meResponse{
+		User:        user,
+		HasPassword: hasPassword,
+	}
+}
+
+// hasEmailAccount reports whether the user can log in with email and password
+func hasEmailAccount(accounts []models.Account) bool {
+	for _, account := range accounts {
+		if account.Provider == models.ProviderEmail {
+			return true
+		}
+	}
+
+	return false
+}
+
+// primaryAccount picks the account whose address represents the user, the oldest verified one if any
+func primaryAccount(accounts []models.Account) *models.Account {
+	for i := range accounts {
+		if accounts[i].VerifiedAt != nil && accounts[i].Email != "" {
+			return &accounts[i]
+		}
+	}
+
+	for i := range accounts {
+		if accounts[i].Email != "" {
+			return &accounts[i]
+		}
+	}
+
+	return nil
+}
+
+// currentSessionFamilyID returns the session the refresh token cookie belongs to, 0 when there is none
+func currentSessionFamilyID(c echo.Context, tx pgx.Tx, userID int64) (int64, error) {
+	cookie, err := authutil.GetRefreshTokenCookie(c)
+	if err != nil || cookie.Value == "" {
+		return 0, nil
+	}
+
+	token, err := repository.GetRefreshTokenByToken(c.Request().Context(), tx, cookie.Value)
+	if err != nil {
+		return 0, err
+	}
+
+	if token == nil || token.UserID != userID || token.RevokedAt != nil {
+		return 0, nil
+	}
+
+	return token.FamilyID, nil
+}
@@ -0,0 +1,9 @@
+package wellknown
+
+import (
+	"ridash/utils/keyring"
+)
+
+type WellKnownHandler struct {
+	Keyring *keyring.Keyring
+}
@@ -0,0 +1,25 @@
+package wellknown
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// +----------------------------------------------+
+// | JWKS                                         |
+// +----------------------------------------------+
+
+// jwksMaxAge is how long consumers may cache the key set, well below JWT_KEY_PUBLISH_AHEAD
+const jwksMaxAge = "300"
+
+// JWKS godoc
+// @Summary Access token signing keys
+// @Description Publishes the public keys verifying access tokens as a JSON Web Key Set, tokens name their key in the kid header. Served at /.well-known/jwks.json, outside of /api
+// @Tags auth
+// @Produce json
+// @Success 200 {object} encrypt.JWKS "Signing keys"
+func (h *WellKnownHandler) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age="+jwksMaxAge)
+	return c.JSON(http.StatusOK, h.Keyring.JWKS())
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"ridash/utils/config"
+	"slices"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminRequiredMiddleware lets through the users listed in ADMIN_USER_IDS, it goes after AuthRequiredMiddleware
+func AdminRequiredMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		subject, ok := c.Get(string(UserIDKey)).(string)
+		if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+		}
+
+		userID, err := strconv.ParseInt(subject, 10, 64)
+		if err != nil || !slices.Contains(config.Env().AdminUserIDs, userID) {
+			return echo.NewHTTPError(http.StatusForbidden, "Admin access required")
+		}
+
+		return next(c)
+	}
+}
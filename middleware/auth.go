@@ -2,23 +2,32 @@ package middleware
 
 import (
 	"net/http"
-	"ridash/utils/config"
 	"ridash/utils/encrypt"
+	"ridash/utils/keyring"
+	"ridash/utils/revocation"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
-// authMiddlewareLogic is the logic for the auth middleware
-func authMiddlewareLogic(token string) (*encrypt.AccessTokenClaims, error) {
+// authIdentity is who the bearer token acts for
+type authIdentity struct {
+	Subject               string
+	PersonalAccessTokenID int64    // Zero for access tokens
+	Scopes                []string // Scopes of the personal access token
+}
+
+// authMiddlewareLogic is the logic for the auth middleware, personal access tokens are looked up and anything else is a JWT
+func authMiddlewareLogic(c echo.Context, token string) (*authIdentity, error) {
 	token = strings.TrimPrefix(token, "Bearer ")
 
-	JWTSecret := encrypt.JWTSecret{
-		Secret: config.Env().JWTSecretKey,
+	if strings.HasPrefix(token, encrypt.PersonalAccessTokenPrefix) {
+		return personalAccessTokenLogic(c, token)
 	}
 
-	valid, claims, err := JWTSecret.ValidateAccessTokenAndGetClaims(token)
+	valid, claims, err := keyring.Default().ValidateAccessTokenAndGetClaims(token)
 	if err != nil {
 		zap.L().Error("Internal server error", zap.Error(err))
 		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
@@ -28,7 +37,29 @@ func authMiddlewareLogic(token string) (*encrypt.AccessTokenClaims, error) {
 		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
 	}
 
-	return &claims, nil
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	// Logouts, password resets and disabled accounts take effect before the token expires
+	if revocation.Default().IsRevoked(userID, claims) {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Token revoked")
+	}
+
+	return &authIdentity{Subject: claims.Subject}, nil
+}
+
+// setIdentity stores the identity in the context for the handlers, personal access tokens only get a user once RequireScopes passes
+func setIdentity(c echo.Context, identity *authIdentity) {
+	if identity.PersonalAccessTokenID == 0 {
+		c.Set(string(UserIDKey), identity.Subject)
+		return
+	}
+
+	c.Set(string(PersonalAccessTokenIDKey), identity.PersonalAccessTokenID)
+	c.Set(string(ScopesKey), identity.Scopes)
+	c.Set(string(tokenSubjectKey), identity.Subject)
 }
 
 // AuthRequiredMiddleware is the middleware for the auth required
@@ -39,12 +70,12 @@ func AuthRequiredMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
 		}
 
-		claims, err := authMiddlewareLogic(token)
+		identity, err := authMiddlewareLogic(c, token)
 		if err != nil {
 			return err
 		}
 
-		c.Set(string(UserIDKey), claims.Subject)
+		setIdentity(c, identity)
 		return next(c)
 	}
 }
@@ -57,13 +88,13 @@ func AuthOptionalMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return next(c)
 		}
 
-		claims, err := authMiddlewareLogic(token)
+		identity, err := authMiddlewareLogic(c, token)
 		if err != nil {
 			// For optional auth, continue even if token is invalid
 			return next(c)
 		}
 
-		c.Set(string(UserIDKey), claims.Subject)
+		setIdentity(c, identity)
 		return next(c)
 	}
 }
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"ridash/repository"
+	"ridash/utils/encrypt"
+	"ridash/utils/revocation"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// personalAccessTokenDB is where the auth middlewares look personal access tokens up
+var personalAccessTokenDB *pgxpool.Pool
+
+// UsePersonalAccessTokens lets the auth middlewares accept the personal access tokens stored in db
+func UsePersonalAccessTokens(db *pgxpool.Pool) {
+	personalAccessTokenDB = db
+}
+
+// personalAccessTokenLogic checks the token is active and records its use
+func personalAccessTokenLogic(c echo.Context, token string) (*authIdentity, error) {
+	if personalAccessTokenDB == nil {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	ctx := c.Request().Context()
+	tx, err := repository.StartTransaction(personalAccessTokenDB, ctx)
+	if err != nil {
+		zap.L().Error("Failed to begin transaction", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+	defer repository.DeferRollback(tx, ctx)
+
+	stored, err := repository.GetPersonalAccessTokenByHash(ctx, tx, encrypt.HashToken(token))
+	if err != nil {
+		zap.L().Error("Failed to get personal access token", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	now := time.Now()
+	if stored == nil || stored.RevokedAt != nil || (stored.ExpiresAt != nil && now.After(*stored.ExpiresAt)) || revocation.Default().IsUserDisabled(stored.UserID) {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	if err := repository.TouchPersonalAccessToken(ctx, tx, stored.ID, now); err != nil {
+		zap.L().Error("Failed to update personal access token", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	if err := repository.CommitTransaction(tx, ctx); err != nil {
+		zap.L().Error("Failed to commit transaction", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return &authIdentity{
+		Subject:               strconv.FormatInt(stored.UserID, 10),
+		PersonalAccessTokenID: stored.ID,
+		Scopes:                stored.Scopes,
+	}, nil
+}
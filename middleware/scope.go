@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"ridash/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequireScopes declares the scopes a route needs from personal access tokens, sessions have every scope.
+// Routes that don't declare any scope can't be used with personal access tokens at all
+func RequireScopes(scopes ...models.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			granted, ok := c.Get(string(ScopesKey)).([]string)
+			if !ok {
+				return next(c)
+			}
+
+			for _, scope := range scopes {
+				if !models.ScopesGrant(granted, scope) {
+					return echo.NewHTTPError(http.StatusForbidden, "Token is missing the "+string(scope)+" scope")
+				}
+			}
+
+			c.Set(string(UserIDKey), c.Get(string(tokenSubjectKey)))
+			return next(c)
+		}
+	}
+}
@@ -5,5 +5,9 @@ type ContextKey string
 
 // ContextKey constants
 const (
-	UserIDKey ContextKey = "userID"
+	UserIDKey                ContextKey = "userID"
+	PersonalAccessTokenIDKey ContextKey = "personalAccessTokenID" // Set when the request is authenticated by a personal access token
+	ScopesKey                ContextKey = "scopes"                // Scopes of the personal access token
+
+	tokenSubjectKey ContextKey = "tokenSubject" // User of the personal access token, moved to UserIDKey by RequireScopes
 )
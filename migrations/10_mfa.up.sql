@@ -0,0 +1,24 @@
+CREATE TABLE "public"."user_totp" (
+    "user_id" bigint NOT NULL,
+    "secret" character varying(128) NOT NULL,
+    "confirmed_at" timestamp,
+    "last_used_step" bigint,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("user_id")
+);
+
+CREATE TABLE "public"."mfa_recovery_codes" (
+    "id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "code_hash" character varying(64) NOT NULL,
+    "used_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "mfa_recovery_codes_idx_mfa_recovery_codes_user_id" ON "public"."mfa_recovery_codes" ("user_id");
+CREATE UNIQUE INDEX "mfa_recovery_codes_idx_mfa_recovery_codes_user_id_code_hash" ON "public"."mfa_recovery_codes" ("user_id", "code_hash");
+
+ALTER TABLE "public"."user_totp" ADD CONSTRAINT "fk_user_totp_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
+ALTER TABLE "public"."mfa_recovery_codes" ADD CONSTRAINT "fk_mfa_recovery_codes_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
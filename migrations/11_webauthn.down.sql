@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "public"."webauthn_sessions";
+DROP TABLE IF EXISTS "public"."webauthn_credentials";
@@ -0,0 +1,34 @@
+CREATE TABLE "public"."webauthn_credentials" (
+    "id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "credential_id" bytea NOT NULL,
+    "public_key" bytea NOT NULL,
+    "attestation_type" character varying(32) NOT NULL,
+    "transports" text[] NOT NULL DEFAULT '{}',
+    "aaguid" bytea,
+    "sign_count" bigint NOT NULL DEFAULT 0,
+    "backup_eligible" boolean NOT NULL DEFAULT false,
+    "backup_state" boolean NOT NULL DEFAULT false,
+    "name" character varying(255) NOT NULL,
+    "last_used_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "public"."webauthn_sessions" (
+    "id" bigint NOT NULL,
+    "token_hash" character varying(64) NOT NULL,
+    "user_id" bigint,
+    "purpose" character varying(32) NOT NULL,
+    "data" jsonb NOT NULL,
+    "expires_at" timestamp NOT NULL,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "webauthn_credentials_idx_webauthn_credentials_user_id" ON "public"."webauthn_credentials" ("user_id");
+CREATE UNIQUE INDEX "webauthn_credentials_idx_webauthn_credentials_credential_id" ON "public"."webauthn_credentials" ("credential_id");
+CREATE UNIQUE INDEX "webauthn_sessions_idx_webauthn_sessions_token_hash" ON "public"."webauthn_sessions" ("token_hash");
+
+ALTER TABLE "public"."webauthn_credentials" ADD CONSTRAINT "fk_webauthn_credentials_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
+ALTER TABLE "public"."webauthn_sessions" ADD CONSTRAINT "fk_webauthn_sessions_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
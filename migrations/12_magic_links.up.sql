@@ -0,0 +1,11 @@
+CREATE TABLE "public"."magic_links" (
+    "id" bigint NOT NULL,
+    "email" character varying(255) NOT NULL,
+    "invitation_token" text,
+    "expires_at" timestamp NOT NULL,
+    "used_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "magic_links_idx_magic_links_email" ON "public"."magic_links" ("email");
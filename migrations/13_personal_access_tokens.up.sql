@@ -0,0 +1,17 @@
+CREATE TABLE "public"."personal_access_tokens" (
+    "id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "name" character varying(255) NOT NULL,
+    "token_prefix" character varying(16) NOT NULL,
+    "token_hash" character varying(64) NOT NULL,
+    "expires_at" timestamp,
+    "last_used_at" timestamp,
+    "revoked_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "personal_access_tokens_idx_personal_access_tokens_user_id" ON "public"."personal_access_tokens" ("user_id");
+CREATE UNIQUE INDEX "personal_access_tokens_idx_personal_access_tokens_token_hash" ON "public"."personal_access_tokens" ("token_hash");
+
+ALTER TABLE "public"."personal_access_tokens" ADD CONSTRAINT "fk_personal_access_tokens_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
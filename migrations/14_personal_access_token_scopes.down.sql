@@ -0,0 +1 @@
+ALTER TABLE "public"."personal_access_tokens" DROP COLUMN "scopes";
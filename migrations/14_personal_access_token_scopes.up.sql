@@ -0,0 +1,7 @@
+ALTER TABLE "public"."personal_access_tokens" ADD COLUMN "scopes" text[] NOT NULL DEFAULT '{}';
+
+-- Tokens created before scopes existed keep the access they had
+UPDATE "public"."personal_access_tokens"
+SET "scopes" = ARRAY['documents:write', 'teams:admin', 'user:write'];
+
+ALTER TABLE "public"."personal_access_tokens" ALTER COLUMN "scopes" DROP DEFAULT;
@@ -0,0 +1,12 @@
+CREATE TABLE "public"."signing_keys" (
+    "id" bigint NOT NULL,
+    "algorithm" character varying(16) NOT NULL,
+    "private_key" bytea NOT NULL,
+    "public_key" bytea NOT NULL,
+    "activates_at" timestamp NOT NULL,
+    "expires_at" timestamp NOT NULL,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "signing_keys_idx_signing_keys_expires_at" ON "public"."signing_keys" ("expires_at");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "public"."access_token_revocations";
+
+ALTER TABLE "public"."users" DROP COLUMN "disabled_at";
@@ -0,0 +1,17 @@
+ALTER TABLE "public"."users" ADD COLUMN "disabled_at" timestamp;
+
+CREATE TABLE "public"."access_token_revocations" (
+    "id" bigint NOT NULL,
+    "jti" character varying(64),
+    "user_id" bigint,
+    "issued_before" timestamp,
+    "expires_at" timestamp NOT NULL,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    -- Either one token or every token of a user issued before a point in time
+    CONSTRAINT "access_token_revocations_target" CHECK (("jti" IS NOT NULL) <> ("user_id" IS NOT NULL AND "issued_before" IS NOT NULL))
+);
+-- Indexes
+CREATE INDEX "access_token_revocations_idx_access_token_revocations_expires_at" ON "public"."access_token_revocations" ("expires_at");
+
+ALTER TABLE "public"."access_token_revocations" ADD CONSTRAINT "fk_access_token_revocations_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
@@ -0,0 +1,13 @@
+CREATE TABLE "public"."login_attempts" (
+    "kind" character varying(16) NOT NULL,
+    "key" character varying(255) NOT NULL,
+    "failures" integer NOT NULL DEFAULT 0,
+    "last_failed_at" timestamp,
+    "locked_until" timestamp,
+    "unlock_token_hash" character varying(255),
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("kind", "key")
+);
+-- Indexes
+CREATE UNIQUE INDEX "login_attempts_idx_login_attempts_unlock_token_hash" ON "public"."login_attempts" ("unlock_token_hash");
+CREATE INDEX "login_attempts_idx_login_attempts_updated_at" ON "public"."login_attempts" ("updated_at");
@@ -0,0 +1 @@
+ALTER TABLE "public"."users" DROP COLUMN "deleted_at";
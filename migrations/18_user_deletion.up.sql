@@ -0,0 +1,2 @@
+-- Deleted users are anonymized rather than removed, so what they left behind keeps pointing to a user
+ALTER TABLE "public"."users" ADD COLUMN "deleted_at" timestamp;
@@ -0,0 +1,15 @@
+-- The token handed out after the password step is single-use and only takes a few wrong codes
+CREATE TABLE "public"."mfa_challenges" (
+    "id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "expires_at" timestamp NOT NULL,
+    "used_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "mfa_challenges_idx_mfa_challenges_user_id" ON "public"."mfa_challenges" ("user_id");
+CREATE INDEX "mfa_challenges_idx_mfa_challenges_expires_at" ON "public"."mfa_challenges" ("expires_at");
+
+ALTER TABLE "public"."mfa_challenges" ADD CONSTRAINT "fk_mfa_challenges_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "public"."magic_links_idx_magic_links_ip_created_at";
+ALTER TABLE "public"."magic_links" DROP COLUMN IF EXISTS "ip";
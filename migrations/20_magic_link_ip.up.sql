@@ -0,0 +1,4 @@
+-- Login links are throttled per address and per requesting IP address
+ALTER TABLE "public"."magic_links" ADD COLUMN "ip" character varying(45);
+
+CREATE INDEX "magic_links_idx_magic_links_ip_created_at" ON "public"."magic_links" ("ip", "created_at");
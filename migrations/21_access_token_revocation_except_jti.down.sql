@@ -0,0 +1 @@
+ALTER TABLE "public"."access_token_revocations" DROP COLUMN IF EXISTS "except_jti";
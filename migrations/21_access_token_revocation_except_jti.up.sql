@@ -0,0 +1,2 @@
+-- Revoking every token of a user can keep the one of the session that asked for it
+ALTER TABLE "public"."access_token_revocations" ADD COLUMN "except_jti" character varying(64);
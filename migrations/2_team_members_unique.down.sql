@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "public"."team_members_idx_team_members_user_id";
+DROP INDEX IF EXISTS "public"."team_members_team_members_team_id_user_id_key";
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX "team_members_team_members_team_id_user_id_key" ON "public"."team_members" ("team_id", "user_id");
+CREATE INDEX "team_members_idx_team_members_user_id" ON "public"."team_members" ("user_id");
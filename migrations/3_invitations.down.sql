@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "public"."invitations";
+DROP TYPE IF EXISTS "invitation_status";
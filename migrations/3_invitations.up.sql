@@ -0,0 +1,21 @@
+CREATE TYPE "invitation_status" AS ENUM ('pending', 'accepted', 'declined');
+
+CREATE TABLE "public"."invitations" (
+    "id" bigint NOT NULL,
+    "team_id" bigint NOT NULL,
+    "email" character varying(255) NOT NULL,
+    "role" role NOT NULL,
+    "invited_by" bigint NOT NULL,
+    "status" invitation_status NOT NULL,
+    "expires_at" timestamp NOT NULL,
+    "responded_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "invitations_idx_invitations_team_id" ON "public"."invitations" ("team_id");
+CREATE INDEX "invitations_idx_invitations_email" ON "public"."invitations" ("email");
+
+ALTER TABLE "public"."invitations" ADD CONSTRAINT "fk_invitations_team_id_teams_id" FOREIGN KEY("team_id") REFERENCES "public"."teams"("id");
+ALTER TABLE "public"."invitations" ADD CONSTRAINT "fk_invitations_invited_by_users_id" FOREIGN KEY("invited_by") REFERENCES "public"."users"("id");
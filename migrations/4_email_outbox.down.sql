@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "public"."email_outbox";
+DROP TYPE IF EXISTS "email_status";
@@ -0,0 +1,20 @@
+CREATE TYPE "email_status" AS ENUM ('pending', 'sent', 'failed');
+
+CREATE TABLE "public"."email_outbox" (
+    "id" bigint NOT NULL,
+    "recipient" character varying(255) NOT NULL,
+    "template" character varying(64) NOT NULL,
+    "subject" text NOT NULL,
+    "text_body" text NOT NULL,
+    "html_body" text NOT NULL,
+    "status" email_status NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "last_error" text,
+    "next_attempt_at" timestamp NOT NULL,
+    "sent_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "email_outbox_idx_email_outbox_status_next_attempt_at" ON "public"."email_outbox" ("status", "next_attempt_at");
@@ -0,0 +1 @@
+ALTER TABLE "public"."accounts" DROP COLUMN IF EXISTS "verified_at";
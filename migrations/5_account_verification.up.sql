@@ -0,0 +1,4 @@
+ALTER TABLE "public"."accounts" ADD COLUMN "verified_at" timestamp;
+
+-- Addresses coming from an OAuth provider were verified by the provider
+UPDATE "public"."accounts" SET "verified_at" = "created_at" WHERE "provider" <> 'email';
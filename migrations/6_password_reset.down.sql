@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "public"."password_reset_tokens";
+ALTER TABLE "public"."refresh_tokens" DROP COLUMN IF EXISTS "revoked_at";
@@ -0,0 +1,16 @@
+ALTER TABLE "public"."refresh_tokens" ADD COLUMN "revoked_at" timestamp;
+
+CREATE TABLE "public"."password_reset_tokens" (
+    "id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "token_hash" character varying(64) NOT NULL,
+    "expires_at" timestamp NOT NULL,
+    "used_at" timestamp,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE UNIQUE INDEX "password_reset_tokens_idx_password_reset_tokens_token_hash" ON "public"."password_reset_tokens" ("token_hash");
+CREATE INDEX "password_reset_tokens_idx_password_reset_tokens_user_id" ON "public"."password_reset_tokens" ("user_id");
+
+ALTER TABLE "public"."password_reset_tokens" ADD CONSTRAINT "fk_password_reset_tokens_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
@@ -0,0 +1 @@
+ALTER TABLE "public"."refresh_tokens" DROP COLUMN IF EXISTS "family_id";
@@ -0,0 +1,7 @@
+-- Every login starts a family, rotated tokens stay in the family of the token they replace
+ALTER TABLE "public"."refresh_tokens" ADD COLUMN "family_id" bigint;
+UPDATE "public"."refresh_tokens" SET "family_id" = "id";
+ALTER TABLE "public"."refresh_tokens" ALTER COLUMN "family_id" SET NOT NULL;
+
+-- Indexes
+CREATE INDEX "refresh_tokens_idx_refresh_tokens_family_id" ON "public"."refresh_tokens" ("family_id");
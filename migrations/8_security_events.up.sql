@@ -0,0 +1,15 @@
+CREATE TABLE "public"."security_events" (
+    "id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "type" character varying(64) NOT NULL,
+    "ip" inet,
+    "user_agent" text,
+    "details" jsonb,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+-- Indexes
+CREATE INDEX "security_events_idx_security_events_user_id" ON "public"."security_events" ("user_id");
+CREATE INDEX "security_events_idx_security_events_created_at" ON "public"."security_events" ("created_at");
+
+ALTER TABLE "public"."security_events" ADD CONSTRAINT "fk_security_events_user_id_users_id" FOREIGN KEY("user_id") REFERENCES "public"."users"("id");
@@ -0,0 +1,8 @@
+CREATE TYPE "auth_provider" AS ENUM ('email', 'google');
+
+-- Accounts of generic OIDC providers have no enum value to go back to
+DELETE FROM "public"."oauth_tokens" WHERE "provider" NOT IN ('email', 'google');
+DELETE FROM "public"."accounts" WHERE "provider" NOT IN ('email', 'google');
+
+ALTER TABLE "public"."accounts" ALTER COLUMN "provider" TYPE auth_provider USING "provider"::auth_provider;
+ALTER TABLE "public"."oauth_tokens" ALTER COLUMN "provider" TYPE auth_provider USING "provider"::auth_provider;
@@ -0,0 +1,5 @@
+-- Providers are configured at runtime, so the column holds the provider name instead of an enum
+ALTER TABLE "public"."accounts" ALTER COLUMN "provider" TYPE character varying(64) USING "provider"::text;
+ALTER TABLE "public"."oauth_tokens" ALTER COLUMN "provider" TYPE character varying(64) USING "provider"::text;
+
+DROP TYPE IF EXISTS "auth_provider";
@@ -12,19 +12,78 @@ const (
 type RefreshToken struct {
 	ID        int64      `json:"id,string" example:"175928847299117063"`                                            // Unique identifier for the refresh token
 	UserID    int64      `json:"user_id,string" example:"175928847299117063"`                                       // User ID associated with this token
+	FamilyID  int64      `json:"family_id,string" example:"175928847299117063"`                                     // Login session the token was rotated from
 	Token     string     `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`                           // The actual refresh token
 	UserAgent *string    `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"` // User agent string from the client
 	IP        *string    `json:"ip" example:"192.168.1.100"`                                                        // IP address of the client
 	UsedAt    *time.Time `json:"used_at,omitempty" example:"2023-01-01T12:00:00Z"`                                  // Timestamp when the token was last used
+	RevokedAt *time.Time `json:"revoked_at,omitempty" example:"2023-01-01T12:00:00Z"`                               // Timestamp when the token was revoked
 	CreatedAt time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`                                         // Timestamp when the token was created
 }
 
-// Provider represents the authentication provider type
+// Session represents a login on a device, made of every refresh token rotated from that login
+type Session struct {
+	ID          int64     `json:"id,string" example:"175928847299117063"`                                            // Family ID of the refresh tokens
+	UserAgent   *string   `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"` // User agent of the latest token
+	IP          *string   `json:"ip" example:"192.168.1.100"`                                                        // IP address of the latest token
+	FirstSeenAt time.Time `json:"first_seen_at" example:"2023-01-01T12:00:00Z"`                                      // Timestamp of the login
+	LastUsedAt  time.Time `json:"last_used_at" example:"2023-01-02T12:00:00Z"`                                       // Timestamp of the latest refresh
+	Current     bool      `json:"current" example:"true"`                                                            // Whether this is the session making the request
+}
+
+// PasswordResetToken represents a single-use password reset token, only its hash is stored
+type PasswordResetToken struct {
+	ID        int64      `json:"id,string" example:"175928847299117063"`           // Unique identifier for the reset token
+	UserID    int64      `json:"user_id,string" example:"175928847299117063"`      // User whose password can be reset
+	TokenHash string     `json:"-"`                                                // SHA-256 hash of the token sent by email
+	ExpiresAt time.Time  `json:"expires_at" example:"2023-01-01T13:00:00Z"`        // Timestamp when the token expires
+	UsedAt    *time.Time `json:"used_at,omitempty" example:"2023-01-01T12:30:00Z"` // Timestamp when the token was used
+	CreatedAt time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`        // Timestamp when the token was created
+}
+
+// Provider represents the authentication provider type, generic OIDC providers use their configured name
 type Provider string
 
 // Provider constants
 const (
 	ProviderEmail  Provider = "email"  // Email/password authentication
 	ProviderGoogle Provider = "google" // Google OAuth authentication
-	// You can add more providers here
+	ProviderGitHub Provider = "github" // GitHub OAuth2 authentication
+	// Generic OIDC providers are configured through OIDC_PROVIDERS
 )
+
+// MagicLink represents a single-use login link sent by email, the user may not exist yet
+type MagicLink struct {
+	ID              int64      `json:"id,string" example:"175928847299117063"`           // Unique identifier, it is the subject of the signed token
+	Email           string     `json:"email" example:"user@example.com"`                 // Address the link was sent to
+	InvitationToken *string    `json:"-"`                                                // Invitation to accept when the link is used
+	IP              *string    `json:"-"`                                                // IP address the link was requested from
+	ExpiresAt       time.Time  `json:"expires_at" example:"2023-01-01T12:15:00Z"`        // Timestamp when the link expires
+	UsedAt          *time.Time `json:"used_at,omitempty" example:"2023-01-01T12:05:00Z"` // Timestamp when the link was used
+	CreatedAt       time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`        // Timestamp when the link was sent
+}
+
+// PersonalAccessToken represents a long-lived token for scripts and CI, only its hash is stored
+type PersonalAccessToken struct {
+	ID          int64      `json:"id,string" example:"175928847299117063"`                // Unique identifier for the token
+	UserID      int64      `json:"user_id,string" example:"175928847299117063"`           // User the token acts for
+	Name        string     `json:"name" example:"CI deploy"`                              // Label given by the user
+	TokenPrefix string     `json:"token_prefix" example:"rdpat_Xk29aB"`                   // Start of the token, to recognize it
+	TokenHash   string     `json:"-"`                                                     // SHA-256 hash of the token
+	Scopes      []string   `json:"scopes" example:"documents:read"`                       // What the token may be used for, see Scope
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2024-01-01T12:00:00Z"`   // Timestamp when the token expires, never when empty
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" example:"2023-01-02T12:00:00Z"` // Timestamp of the latest request made with the token
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" example:"2023-01-03T12:00:00Z"`   // Timestamp when the token was revoked
+	CreatedAt   time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`             // Timestamp when the token was created
+}
+
+// AccessTokenRevocation represents an entry of the access token denylist, it names one token or every token of a user issued before a point in time
+type AccessTokenRevocation struct {
+	ID           int64      `json:"id,string" example:"175928847299117063"`                 // Unique identifier for the entry
+	JTI          *string    `json:"jti,omitempty" example:"Xk29aBq8ZpLm3nR7sT1uVw"`         // ID of the revoked token
+	UserID       *int64     `json:"user_id,string,omitempty" example:"175928847299117063"`  // User whose tokens are revoked
+	IssuedBefore *time.Time `json:"issued_before,omitempty" example:"2023-01-01T12:00:00Z"` // Tokens of the user issued before are revoked
+	ExceptJTI    *string    `json:"except_jti,omitempty" example:"Xk29aBq8ZpLm3nR7sT1uVw"`  // Token of the user that stays valid
+	ExpiresAt    time.Time  `json:"expires_at" example:"2023-01-01T12:15:00Z"`              // Timestamp when every token it covers has expired
+	CreatedAt    time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`              // Timestamp when the entry was created
+}
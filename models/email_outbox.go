@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// EmailStatus represents the delivery state of an outbox email
+type EmailStatus string
+
+// EmailStatus constants
+const (
+	EmailStatusPending EmailStatus = "pending" // Waiting to be delivered or retried
+	EmailStatusSent    EmailStatus = "sent"    // Delivered to the transport
+	EmailStatusFailed  EmailStatus = "failed"  // Gave up after the maximum number of attempts
+)
+
+// OutboxEmail represents a rendered email waiting in the outbox
+type OutboxEmail struct {
+	ID            int64       `json:"id,string" example:"175928847299117063"`           // Unique identifier for the email
+	Recipient     string      `json:"recipient" example:"user@example.com"`             // Address the email is sent to
+	Template      string      `json:"template" example:"invitation"`                    // Template the email was rendered from
+	Subject       string      `json:"subject" example:"You have been invited"`          // Rendered subject line
+	TextBody      string      `json:"text_body"`                                        // Rendered plain text body
+	HTMLBody      string      `json:"html_body"`                                        // Rendered HTML body
+	Status        EmailStatus `json:"status" example:"pending"`                         // Current delivery state
+	Attempts      int         `json:"attempts" example:"0"`                             // Number of failed delivery attempts
+	LastError     *string     `json:"last_error,omitempty"`                             // Error returned by the last failed attempt
+	NextAttemptAt time.Time   `json:"next_attempt_at" example:"2023-01-01T12:00:00Z"`   // Earliest time the next attempt may run
+	SentAt        *time.Time  `json:"sent_at,omitempty" example:"2023-01-01T12:00:00Z"` // Timestamp when the email was delivered
+	CreatedAt     time.Time   `json:"created_at" example:"2023-01-01T12:00:00Z"`        // Timestamp when the email was queued
+	UpdatedAt     time.Time   `json:"updated_at" example:"2023-01-01T12:00:00Z"`        // Timestamp when the email was last updated
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// InvitationStatus represents the state of a team invitation
+type InvitationStatus string
+
+// InvitationStatus constants
+const (
+	InvitationStatusPending  InvitationStatus = "pending"  // Waiting for the invitee to respond
+	InvitationStatusAccepted InvitationStatus = "accepted" // The invitee joined the team
+	InvitationStatusDeclined InvitationStatus = "declined" // The invitee declined the invitation
+)
+
+// Invitation represents an email invitation to join a team
+type Invitation struct {
+	ID          int64            `json:"id,string" example:"175928847299117063"`                // Unique identifier for the invitation
+	TeamID      int64            `json:"team_id,string" example:"175928847299117063"`           // Team the invitee will join
+	Email       string           `json:"email" example:"user@example.com"`                      // Email address the invitation was sent to
+	Role        Role             `json:"role" example:"member"`                                 // Role the invitee will get in the team
+	InvitedBy   int64            `json:"invited_by,string" example:"175928847299117063"`        // User ID of the inviter
+	Status      InvitationStatus `json:"status" example:"pending"`                              // Current status of the invitation
+	ExpiresAt   time.Time        `json:"expires_at" example:"2023-01-08T12:00:00Z"`             // Timestamp when the invitation expires
+	RespondedAt *time.Time       `json:"responded_at,omitempty" example:"2023-01-02T12:00:00Z"` // Timestamp when the invitation was accepted or declined
+	CreatedAt   time.Time        `json:"created_at" example:"2023-01-01T12:00:00Z"`             // Timestamp when the invitation was created
+	UpdatedAt   time.Time        `json:"updated_at" example:"2023-01-01T12:00:00Z"`             // Timestamp when the invitation was last updated
+}
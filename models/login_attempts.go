@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// LoginAttemptKind names what the failed logins of a LoginAttempt are counted for
+type LoginAttemptKind string
+
+// LoginAttemptKind constants
+const (
+	LoginAttemptKindEmail LoginAttemptKind = "email" // Keyed by the lowercased email address, known or not
+	LoginAttemptKindIP    LoginAttemptKind = "ip"    // Keyed by the client IP address
+)
+
+// LoginAttempt counts the recent failed password logins of an email or an IP address
+type LoginAttempt struct {
+	Kind            LoginAttemptKind `json:"kind" example:"email"`                          // What the failures are counted for
+	Key             string           `json:"key" example:"user@example.com"`                // Email or IP address
+	Failures        int              `json:"failures" example:"3"`                          // Failures since the window started or the last lockout
+	LastFailedAt    *time.Time       `json:"last_failed_at" example:"2023-01-01T12:00:00Z"` // Timestamp of the last failure
+	LockedUntil     *time.Time       `json:"locked_until" example:"2023-01-01T12:15:00Z"`   // Logins are refused until then
+	UnlockTokenHash *string          `json:"-"`                                             // SHA-256 of the token in the unlock email
+	UpdatedAt       time.Time        `json:"updated_at" example:"2023-01-01T12:00:00Z"`     // Timestamp of the last change
+}
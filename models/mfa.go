@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// UserTOTP represents the TOTP authenticator of a user, it only protects logins once confirmed
+type UserTOTP struct {
+	UserID       int64      `json:"user_id,string" example:"175928847299117063"`           // User the authenticator belongs to
+	Secret       string     `json:"-"`                                                     // Base32 shared secret
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty" example:"2023-01-01T12:00:00Z"` // Timestamp when the first code was checked
+	LastUsedStep *int64     `json:"-"`                                                     // Time step of the last accepted code, older codes are replays
+	CreatedAt    time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`             // Timestamp when the enrollment started
+	UpdatedAt    time.Time  `json:"updated_at" example:"2023-01-01T12:00:00Z"`             // Timestamp when the authenticator was last updated
+}
+
+// MFARecoveryCode represents a single-use code that replaces a TOTP code
+type MFARecoveryCode struct {
+	ID        int64      `json:"id,string" example:"175928847299117063"`           // Unique identifier for the code
+	UserID    int64      `json:"user_id,string" example:"175928847299117063"`      // User the code belongs to
+	CodeHash  string     `json:"-"`                                                // SHA-256 of the normalized code
+	UsedAt    *time.Time `json:"used_at,omitempty" example:"2023-01-01T12:30:00Z"` // Timestamp when the code was used
+	CreatedAt time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`        // Timestamp when the code was created
+}
+
+// MFAChallenge represents the second step of a login, named by the jti of the MFA token
+type MFAChallenge struct {
+	ID        int64      `json:"id,string" example:"175928847299117063"`           // Unique identifier, the jti of the MFA token
+	UserID    int64      `json:"user_id,string" example:"175928847299117063"`      // User logging in
+	Attempts  int        `json:"attempts" example:"1"`                             // Wrong codes sent with the token
+	ExpiresAt time.Time  `json:"expires_at" example:"2023-01-01T12:05:00Z"`        // Timestamp when the token expires
+	UsedAt    *time.Time `json:"used_at,omitempty" example:"2023-01-01T12:01:00Z"` // Timestamp when the login was completed
+	CreatedAt time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`        // Timestamp when the password step succeeded
+}
@@ -0,0 +1,59 @@
+package models
+
+// Scope names what a personal access token may be used for
+type Scope string
+
+// Scope constants
+const (
+	ScopeDocumentsRead  Scope = "documents:read"  // Read documents and folders
+	ScopeDocumentsWrite Scope = "documents:write" // Create, edit, delete and share documents and folders
+	ScopeTeamsRead      Scope = "teams:read"      // Read teams and their members
+	ScopeTeamsWrite     Scope = "teams:write"     // Create teams and join them through invitations
+	ScopeTeamsAdmin     Scope = "teams:admin"     // Update and delete teams, manage members and invitations
+	ScopeUserRead       Scope = "user:read"       // Read the profile, linked accounts and passkeys
+	ScopeUserWrite      Scope = "user:write"      // Update the profile
+)
+
+// scopeImplies lists the scopes granted along with a scope
+var scopeImplies = map[Scope][]Scope{
+	ScopeDocumentsWrite: {ScopeDocumentsRead},
+	ScopeTeamsWrite:     {ScopeTeamsRead},
+	ScopeTeamsAdmin:     {ScopeTeamsWrite, ScopeTeamsRead},
+	ScopeUserWrite:      {ScopeUserRead},
+}
+
+// AllScopes lists every scope, in the order they are documented
+var AllScopes = []Scope{
+	ScopeDocumentsRead,
+	ScopeDocumentsWrite,
+	ScopeTeamsRead,
+	ScopeTeamsWrite,
+	ScopeTeamsAdmin,
+	ScopeUserRead,
+	ScopeUserWrite,
+}
+
+// IsValid reports whether the scope is known
+func (s Scope) IsValid() bool {
+	for _, scope := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesGrant reports whether the granted scopes cover the required one
+func ScopesGrant(granted []string, required Scope) bool {
+	for _, scope := range granted {
+		if Scope(scope) == required {
+			return true
+		}
+		for _, implied := range scopeImplies[Scope(scope)] {
+			if implied == required {
+				return true
+			}
+		}
+	}
+	return false
+}
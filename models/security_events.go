@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// SecurityEventType names something suspicious that happened to an account
+type SecurityEventType string
+
+// SecurityEventType constants
+const (
+	SecurityEventRefreshTokenReuse SecurityEventType = "refresh_token_reuse" // A rotated refresh token was replayed, its session was revoked
+	SecurityEventAccountLocked     SecurityEventType = "account_locked"      // Too many failed logins locked the email of the account
+)
+
+// SecurityEvent represents an entry in the security log of a user
+type SecurityEvent struct {
+	ID        int64             `json:"id,string" example:"175928847299117063"`                                            // Unique identifier for the event
+	UserID    int64             `json:"user_id,string" example:"175928847299117063"`                                       // User the event happened to
+	Type      SecurityEventType `json:"type" example:"refresh_token_reuse"`                                                // What happened
+	IP        *string           `json:"ip" example:"192.168.1.100"`                                                        // IP address of the request that triggered the event
+	UserAgent *string           `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"` // User agent of the request that triggered the event
+	Details   map[string]any    `json:"details,omitempty"`                                                                 // Event specific details
+	CreatedAt time.Time         `json:"created_at" example:"2023-01-01T12:00:00Z"`                                         // Timestamp when the event happened
+}
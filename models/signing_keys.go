@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SigningKey represents a key pair signing access tokens, the ID is the kid of the tokens it signs
+type SigningKey struct {
+	ID          int64     `json:"id,string" example:"175928847299117063"`      // Unique identifier for the key
+	Algorithm   string    `json:"algorithm" example:"EdDSA"`                   // RS256 or EdDSA
+	PrivateKey  []byte    `json:"-"`                                           // PKCS #8 private key, sealed with JWT_SECRET_KEY
+	PublicKey   []byte    `json:"-"`                                           // PKIX public key
+	ActivatesAt time.Time `json:"activates_at" example:"2023-01-01T12:00:00Z"` // Timestamp from which the key signs, it is published before
+	ExpiresAt   time.Time `json:"expires_at" example:"2023-02-01T12:00:00Z"`   // Timestamp when the last token it signed has expired
+	CreatedAt   time.Time `json:"created_at" example:"2023-01-01T11:00:00Z"`   // Timestamp when the key was generated
+}
@@ -2,25 +2,31 @@ package models
 
 import "time"
 
+// DeletedUserDisplayName replaces the display name of the users who deleted their account
+const DeletedUserDisplayName = "Deleted user"
+
 // User represents a user in the system
 type User struct {
-	ID           int64     `json:"id,string" example:"175928847299117063"`                    // Unique identifier for the user
-	PasswordHash *string   `json:"password_hash,omitempty" example:"hashed_password"`         // Hashed password (omitted in responses)
-	DisplayName  string    `json:"display_name" example:"John Doe"`                           // Display name for the user
-	Avatar       *string   `json:"avatar,omitempty" example:"https://example.com/avatar.jpg"` // URL to user's avatar image
-	CreatedAt    time.Time `json:"created_at" example:"2023-01-01T12:00:00Z"`                 // Timestamp when the user was created
-	UpdatedAt    time.Time `json:"updated_at" example:"2023-01-01T12:00:00Z"`                 // Timestamp when the user was last updated
+	ID           int64      `json:"id,string" example:"175928847299117063"`                    // Unique identifier for the user
+	PasswordHash *string    `json:"password_hash,omitempty" example:"hashed_password"`         // Hashed password (omitted in responses)
+	DisplayName  string     `json:"display_name" example:"John Doe"`                           // Display name for the user
+	Avatar       *string    `json:"avatar,omitempty" example:"https://example.com/avatar.jpg"` // URL to user's avatar image
+	DisabledAt   *time.Time `json:"disabled_at,omitempty" example:"2023-01-02T12:00:00Z"`      // Timestamp when an admin disabled the user, they can't sign in until enabled
+	DeletedAt    *time.Time `json:"deleted_at,omitempty" example:"2023-01-03T12:00:00Z"`       // Timestamp when the user deleted themselves, the record is anonymized
+	CreatedAt    time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`                 // Timestamp when the user was created
+	UpdatedAt    time.Time  `json:"updated_at" example:"2023-01-01T12:00:00Z"`                 // Timestamp when the user was last updated
 }
 
 // Account represents how a user can login to the system
 type Account struct {
-	ID             int64     `json:"id,string" example:"175928847299117063"`      // Unique identifier for the account
-	Provider       Provider  `json:"provider" example:"email"`                    // Authentication provider type
-	ProviderUserID string    `json:"provider_user_id" example:"user123"`          // User ID from the provider
-	UserID         int64     `json:"user_id,string" example:"175928847299117063"` // Associated user ID
-	Email          string    `json:"email" example:"user@example.com"`            // User's email address
-	CreatedAt      time.Time `json:"created_at" example:"2023-01-01T12:00:00Z"`   // Timestamp when the account was created
-	UpdatedAt      time.Time `json:"updated_at" example:"2023-01-01T12:00:00Z"`   // Timestamp when the account was last updated
+	ID             int64      `json:"id,string" example:"175928847299117063"`               // Unique identifier for the account
+	Provider       Provider   `json:"provider" example:"email"`                             // Authentication provider type
+	ProviderUserID string     `json:"provider_user_id" example:"user123"`                   // User ID from the provider
+	UserID         int64      `json:"user_id,string" example:"175928847299117063"`          // Associated user ID
+	Email          string     `json:"email" example:"user@example.com"`                     // User's email address
+	VerifiedAt     *time.Time `json:"verified_at,omitempty" example:"2023-01-01T12:00:00Z"` // Timestamp when the email address was verified
+	CreatedAt      time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`            // Timestamp when the account was created
+	UpdatedAt      time.Time  `json:"updated_at" example:"2023-01-01T12:00:00Z"`            // Timestamp when the account was last updated
 }
 
 // OAuthToken represents OAuth tokens for external providers
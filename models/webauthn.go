@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// CookieNameWebAuthnSession holds the token of the ceremony in progress
+const CookieNameWebAuthnSession = "webauthn_session"
+
+// WebAuthnCredential represents a passkey registered by a user, it is a login method like an account
+type WebAuthnCredential struct {
+	ID              int64      `json:"id,string" example:"175928847299117063"`                // Unique identifier for the credential
+	UserID          int64      `json:"user_id,string" example:"175928847299117063"`           // User the credential belongs to
+	CredentialID    []byte     `json:"-"`                                                     // ID chosen by the authenticator
+	PublicKey       []byte     `json:"-"`                                                     // COSE encoded public key
+	AttestationType string     `json:"-"`                                                     // Attestation format given at registration
+	Transports      []string   `json:"transports" example:"internal,hybrid"`                  // How the browser can reach the authenticator
+	AAGUID          []byte     `json:"-"`                                                     // Model of the authenticator
+	SignCount       int64      `json:"-"`                                                     // Last signature counter, a lower one means a cloned authenticator
+	BackupEligible  bool       `json:"backup_eligible" example:"true"`                        // Whether the credential can be synced
+	BackupState     bool       `json:"backup_state" example:"true"`                           // Whether the credential is currently synced
+	Name            string     `json:"name" example:"MacBook"`                                // Label given by the user
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty" example:"2023-01-02T12:00:00Z"` // Timestamp of the latest login with the credential
+	CreatedAt       time.Time  `json:"created_at" example:"2023-01-01T12:00:00Z"`             // Timestamp when the credential was registered
+}
+
+// WebAuthnSessionPurpose tells which ceremony a session was started for
+type WebAuthnSessionPurpose string
+
+// WebAuthnSessionPurpose constants
+const (
+	WebAuthnSessionRegistration WebAuthnSessionPurpose = "registration"
+	WebAuthnSessionLogin        WebAuthnSessionPurpose = "login"
+)
+
+// WebAuthnSession holds the challenge of a ceremony between its begin and finish steps, only its token hash is stored
+type WebAuthnSession struct {
+	ID        int64                  `json:"id,string"`
+	TokenHash string                 `json:"-"`
+	UserID    *int64                 `json:"user_id,string,omitempty"` // Set for registrations, logins find the user from the credential
+	Purpose   WebAuthnSessionPurpose `json:"purpose"`
+	Data      []byte                 `json:"-"` // JSON encoded webauthn.SessionData
+	ExpiresAt time.Time              `json:"expires_at"`
+	CreatedAt time.Time              `json:"created_at"`
+}
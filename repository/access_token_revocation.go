@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AccessTokenRevocationsChannel is notified when the denylist or the disabled users change, the listeners reload them
+const AccessTokenRevocationsChannel = "access_token_revocations"
+
+// NotifyAccessTokenRevocations tells every instance to reload the denylist once the transaction commits
+func NotifyAccessTokenRevocations(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, AccessTokenRevocationsChannel)
+	return err
+}
+
+// CreateAccessTokenRevocation inserts a denylist entry and notifies the other instances
+func CreateAccessTokenRevocation(ctx context.Context, tx pgx.Tx, revocation models.AccessTokenRevocation) error {
+	query := `INSERT INTO access_token_revocations (id, jti, user_id, issued_before, except_jti, expires_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := tx.Exec(ctx, query,
+		revocation.ID,
+		revocation.JTI,
+		revocation.UserID,
+		revocation.IssuedBefore,
+		revocation.ExceptJTI,
+		revocation.ExpiresAt,
+		revocation.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return NotifyAccessTokenRevocations(ctx, tx)
+}
+
+// ListActiveAccessTokenRevocations retrieves the denylist entries still covering unexpired tokens
+func ListActiveAccessTokenRevocations(ctx context.Context, tx pgx.Tx, now any) ([]models.AccessTokenRevocation, error) {
+	query := `SELECT id, jti, user_id, issued_before, except_jti, expires_at, created_at
+	          FROM access_token_revocations
+	          WHERE expires_at > $1`
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revocations := []models.AccessTokenRevocation{}
+	for rows.Next() {
+		var revocation models.AccessTokenRevocation
+		if err := rows.Scan(
+			&revocation.ID,
+			&revocation.JTI,
+			&revocation.UserID,
+			&revocation.IssuedBefore,
+			&revocation.ExceptJTI,
+			&revocation.ExpiresAt,
+			&revocation.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		revocations = append(revocations, revocation)
+	}
+
+	return revocations, rows.Err()
+}
+
+// DeleteExpiredAccessTokenRevocations deletes the entries whose tokens have all expired
+func DeleteExpiredAccessTokenRevocations(ctx context.Context, tx pgx.Tx, now any) (int64, error) {
+	tag, err := tx.Exec(ctx, `DELETE FROM access_token_revocations WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -12,7 +12,7 @@ import (
 // GetUserByEmail retrieves a user by email address (through the accounts table)
 func GetUserByEmail(ctx context.Context, tx pgx.Tx, email string) (*models.User, error) {
 	query := `
-		SELECT u.id, u.password_hash, u.display_name, u.avatar, u.created_at, u.updated_at
+		SELECT u.id, u.password_hash, u.display_name, u.avatar, u.disabled_at, u.deleted_at, u.created_at, u.updated_at
 		FROM users u
 		JOIN accounts a ON u.id = a.user_id
 		WHERE a.email = $1 AND a.provider = $2
@@ -24,6 +24,38 @@ func GetUserByEmail(ctx context.Context, tx pgx.Tx, email string) (*models.User,
 		&user.PasswordHash,
 		&user.DisplayName,
 		&user.Avatar,
+		&user.DisabledAt,
+		&user.DeletedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUserByID retrieves a user by ID
+func GetUserByID(ctx context.Context, tx pgx.Tx, userID int64) (*models.User, error) {
+	query := `SELECT id, password_hash, display_name, avatar, disabled_at, deleted_at, created_at, updated_at
+	          FROM users
+	          WHERE id = $1
+	          LIMIT 1`
+
+	var user models.User
+	err := tx.QueryRow(ctx, query, userID).Scan(
+		&user.ID,
+		&user.PasswordHash,
+		&user.DisplayName,
+		&user.Avatar,
+		&user.DisabledAt,
+		&user.DeletedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -41,7 +73,7 @@ func GetUserByEmail(ctx context.Context, tx pgx.Tx, email string) (*models.User,
 
 // GetAccountByEmail retrieves an account by email address
 func GetAccountByEmail(ctx context.Context, tx pgx.Tx, email string) (*models.Account, error) {
-	query := `SELECT id, provider, provider_user_id, user_id, email, created_at, updated_at
+	query := `SELECT id, provider, provider_user_id, user_id, email, verified_at, created_at, updated_at
 	          FROM accounts
 	          WHERE email = $1
 	          LIMIT 1`
@@ -53,6 +85,7 @@ func GetAccountByEmail(ctx context.Context, tx pgx.Tx, email string) (*models.Ac
 		&account.ProviderUserID,
 		&account.UserID,
 		&account.Email,
+		&account.VerifiedAt,
 		&account.CreatedAt,
 		&account.UpdatedAt,
 	)
@@ -68,6 +101,100 @@ func GetAccountByEmail(ctx context.Context, tx pgx.Tx, email string) (*models.Ac
 	return &account, nil
 }
 
+// GetAccountByProviderAndEmail retrieves the account of the provider with the email address
+func GetAccountByProviderAndEmail(ctx context.Context, tx pgx.Tx, provider models.Provider, email string) (*models.Account, error) {
+	query := `SELECT id, provider, provider_user_id, user_id, email, verified_at, created_at, updated_at
+	          FROM accounts
+	          WHERE provider = $1 AND email = $2
+	          LIMIT 1`
+
+	var account models.Account
+	err := tx.QueryRow(ctx, query, provider, email).Scan(
+		&account.ID,
+		&account.Provider,
+		&account.ProviderUserID,
+		&account.UserID,
+		&account.Email,
+		&account.VerifiedAt,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// GetAccountByID retrieves an account by ID
+func GetAccountByID(ctx context.Context, tx pgx.Tx, accountID int64) (*models.Account, error) {
+	query := `SELECT id, provider, provider_user_id, user_id, email, verified_at, created_at, updated_at
+	          FROM accounts
+	          WHERE id = $1
+	          LIMIT 1`
+
+	var account models.Account
+	err := tx.QueryRow(ctx, query, accountID).Scan(
+		&account.ID,
+		&account.Provider,
+		&account.ProviderUserID,
+		&account.UserID,
+		&account.Email,
+		&account.VerifiedAt,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// ListAccountsByUserID retrieves every login method of the user, oldest first
+func ListAccountsByUserID(ctx context.Context, tx pgx.Tx, userID int64) ([]models.Account, error) {
+	query := `SELECT id, provider, provider_user_id, user_id, email, verified_at, created_at, updated_at
+	          FROM accounts
+	          WHERE user_id = $1
+	          ORDER BY created_at`
+
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.Account{}
+	for rows.Next() {
+		var account models.Account
+		if err := rows.Scan(
+			&account.ID,
+			&account.Provider,
+			&account.ProviderUserID,
+			&account.UserID,
+			&account.Email,
+			&account.VerifiedAt,
+			&account.CreatedAt,
+			&account.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
 // GetAccountWithUserByProviderUserID retrieves the account and its associated user
 func GetAccountWithUserByProviderUserID(ctx context.Context, db pgx.Tx, provider models.Provider, providerUserID string) (*models.Account, *models.User, error) {
 	query := `
@@ -97,7 +224,7 @@ func GetAccountWithUserByProviderUserID(ctx context.Context, db pgx.Tx, provider
 
 // GetRefreshTokenByToken retrieves a refresh token by its token value
 func GetRefreshTokenByToken(ctx context.Context, tx pgx.Tx, token string) (*models.RefreshToken, error) {
-	query := `SELECT id, user_id, token, user_agent, ip, used_at, created_at
+	query := `SELECT id, user_id, family_id, token, user_agent, ip, used_at, revoked_at, created_at
 	          FROM refresh_tokens
 	          WHERE token = $1
 	          LIMIT 1`
@@ -106,10 +233,44 @@ func GetRefreshTokenByToken(ctx context.Context, tx pgx.Tx, token string) (*mode
 	err := tx.QueryRow(ctx, query, token).Scan(
 		&refreshToken.ID,
 		&refreshToken.UserID,
+		&refreshToken.FamilyID,
 		&refreshToken.Token,
 		&refreshToken.UserAgent,
 		&refreshToken.IP,
 		&refreshToken.UsedAt,
+		&refreshToken.RevokedAt,
+		&refreshToken.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &refreshToken, nil
+}
+
+// GetRefreshTokenByTokenForUpdate retrieves a refresh token by its token value and locks the row until the transaction ends
+func GetRefreshTokenByTokenForUpdate(ctx context.Context, tx pgx.Tx, token string) (*models.RefreshToken, error) {
+	query := `SELECT id, user_id, family_id, token, user_agent, ip, used_at, revoked_at, created_at
+	          FROM refresh_tokens
+	          WHERE token = $1
+	          LIMIT 1
+	          FOR UPDATE`
+
+	var refreshToken models.RefreshToken
+	err := tx.QueryRow(ctx, query, token).Scan(
+		&refreshToken.ID,
+		&refreshToken.UserID,
+		&refreshToken.FamilyID,
+		&refreshToken.Token,
+		&refreshToken.UserAgent,
+		&refreshToken.IP,
+		&refreshToken.UsedAt,
+		&refreshToken.RevokedAt,
 		&refreshToken.CreatedAt,
 	)
 
@@ -126,8 +287,8 @@ func GetRefreshTokenByToken(ctx context.Context, tx pgx.Tx, token string) (*mode
 
 // CreateAccount creates a new account
 func CreateAccount(ctx context.Context, tx pgx.Tx, account models.Account) error {
-	query := `INSERT INTO accounts (id, provider, provider_user_id, user_id, email, created_at, updated_at)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO accounts (id, provider, provider_user_id, user_id, email, verified_at, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	_, err := tx.Exec(ctx, query,
 		account.ID,
@@ -135,6 +296,7 @@ func CreateAccount(ctx context.Context, tx pgx.Tx, account models.Account) error
 		account.ProviderUserID,
 		account.UserID,
 		account.Email,
+		account.VerifiedAt,
 		account.CreatedAt,
 		account.UpdatedAt,
 	)
@@ -161,8 +323,8 @@ func CreateUserAndAccount(ctx context.Context, tx pgx.Tx, user models.User, acco
 	}
 
 	// Insert account
-	accountQuery := `INSERT INTO accounts (id, provider, provider_user_id, user_id, email, created_at, updated_at)
-	                 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	accountQuery := `INSERT INTO accounts (id, provider, provider_user_id, user_id, email, verified_at, created_at, updated_at)
+	                 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	_, err = tx.Exec(ctx, accountQuery,
 		account.ID,
@@ -170,6 +332,7 @@ func CreateUserAndAccount(ctx context.Context, tx pgx.Tx, user models.User, acco
 		account.ProviderUserID,
 		account.UserID,
 		account.Email,
+		account.VerifiedAt,
 		account.CreatedAt,
 		account.UpdatedAt,
 	)
@@ -180,6 +343,27 @@ func CreateUserAndAccount(ctx context.Context, tx pgx.Tx, user models.User, acco
 	return nil
 }
 
+// UpdateAccountVerifiedAt marks the account email address as verified
+func UpdateAccountVerifiedAt(ctx context.Context, tx pgx.Tx, accountID int64, verifiedAt any) error {
+	query := `UPDATE accounts
+	          SET verified_at = $2, updated_at = $2
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, accountID, verifiedAt)
+	return err
+}
+
+// IsUserVerified reports whether the user owns at least one account with a verified email address
+func IsUserVerified(ctx context.Context, tx pgx.Tx, userID int64) (bool, error) {
+	query := `SELECT EXISTS (
+	              SELECT 1 FROM accounts WHERE user_id = $1 AND verified_at IS NOT NULL
+	          )`
+
+	var verified bool
+	err := tx.QueryRow(ctx, query, userID).Scan(&verified)
+	return verified, err
+}
+
 // CreateOAuthToken creates a new OAuth token
 func CreateOAuthToken(ctx context.Context, db pgx.Tx, oauthToken models.OAuthToken) error {
 	query := `
@@ -219,12 +403,13 @@ func CreateOAuthToken(ctx context.Context, db pgx.Tx, oauthToken models.OAuthTok
 
 // CreateRefreshToken creates a new refresh token in the database
 func CreateRefreshToken(ctx context.Context, tx pgx.Tx, token models.RefreshToken) error {
-	query := `INSERT INTO refresh_tokens (id, user_id, token, user_agent, ip, used_at, created_at)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO refresh_tokens (id, user_id, family_id, token, user_agent, ip, used_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	_, err := tx.Exec(ctx, query,
 		token.ID,
 		token.UserID,
+		token.FamilyID,
 		token.Token,
 		token.UserAgent,
 		token.IP,
@@ -244,3 +429,111 @@ func UpdateRefreshTokenUsedAt(ctx context.Context, tx pgx.Tx, token models.Refre
 	_, err := tx.Exec(ctx, query, token.UsedAt, token.ID)
 	return err
 }
+
+// RevokeRefreshToken revokes a single refresh token
+func RevokeRefreshToken(ctx context.Context, tx pgx.Tx, tokenID int64, revokedAt any) error {
+	query := `UPDATE refresh_tokens
+	          SET revoked_at = $2
+	          WHERE id = $1 AND revoked_at IS NULL`
+
+	_, err := tx.Exec(ctx, query, tokenID, revokedAt)
+	return err
+}
+
+// RevokeRefreshTokenFamily revokes every token of one of the user's sessions and returns how many were revoked
+func RevokeRefreshTokenFamily(ctx context.Context, tx pgx.Tx, userID, familyID int64, revokedAt any) (int64, error) {
+	query := `UPDATE refresh_tokens
+	          SET revoked_at = $3
+	          WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL`
+
+	tag, err := tx.Exec(ctx, query, userID, familyID, revokedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ListActiveSessionsByUserID groups the user's refresh tokens by family and returns the families
+// that still hold a token usable for a refresh, the device and IP come from the latest token
+func ListActiveSessionsByUserID(ctx context.Context, tx pgx.Tx, userID int64, issuedAfter any) ([]models.Session, error) {
+	query := `SELECT family_id,
+	                 (ARRAY_AGG(user_agent ORDER BY created_at DESC))[1],
+	                 (ARRAY_AGG(host(ip) ORDER BY created_at DESC))[1],
+	                 MIN(created_at),
+	                 GREATEST(MAX(created_at), MAX(used_at))
+	          FROM refresh_tokens
+	          WHERE user_id = $1
+	          GROUP BY family_id
+	          HAVING BOOL_OR(used_at IS NULL AND revoked_at IS NULL AND created_at > $2)
+	          ORDER BY 5 DESC`
+
+	rows, err := tx.Query(ctx, query, userID, issuedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserAgent,
+			&session.IP,
+			&session.FirstSeenAt,
+			&session.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeRefreshTokensByUserID revokes every refresh token of the user that isn't revoked yet
+func RevokeRefreshTokensByUserID(ctx context.Context, tx pgx.Tx, userID int64, revokedAt any) error {
+	query := `UPDATE refresh_tokens
+	          SET revoked_at = $2
+	          WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := tx.Exec(ctx, query, userID, revokedAt)
+	return err
+}
+
+// RevokeOtherRefreshTokensByUserID revokes every refresh token of the user outside the kept session
+func RevokeOtherRefreshTokensByUserID(ctx context.Context, tx pgx.Tx, userID, keepFamilyID int64, revokedAt any) error {
+	query := `UPDATE refresh_tokens
+	          SET revoked_at = $3
+	          WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL`
+
+	_, err := tx.Exec(ctx, query, userID, keepFamilyID, revokedAt)
+	return err
+}
+
+// UpdateUserPasswordHash replaces the password hash of the user
+func UpdateUserPasswordHash(ctx context.Context, tx pgx.Tx, userID int64, passwordHash string, updatedAt any) error {
+	query := `UPDATE users
+	          SET password_hash = $2, updated_at = $3
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, userID, passwordHash, updatedAt)
+	return err
+}
+
+// DeleteOAuthTokenByAccountID removes the provider tokens stored for an account
+func DeleteOAuthTokenByAccountID(ctx context.Context, tx pgx.Tx, accountID int64) error {
+	query := `DELETE FROM oauth_tokens WHERE account_id = $1`
+
+	_, err := tx.Exec(ctx, query, accountID)
+	return err
+}
+
+// DeleteAccount removes an account of the user
+func DeleteAccount(ctx context.Context, tx pgx.Tx, accountID, userID int64) error {
+	query := `DELETE FROM accounts WHERE id = $1 AND user_id = $2`
+
+	_, err := tx.Exec(ctx, query, accountID, userID)
+	return err
+}
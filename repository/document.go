@@ -87,8 +87,10 @@ func ListDocumentsForUser(ctx context.Context, tx pgx.Tx, userID *int64) ([]mode
 	          FROM documents d
 	          JOIN folders f ON d.folder_id = f.id
 	          JOIN teams t ON f.team_id = t.id
+	          LEFT JOIN team_members tm ON tm.team_id = t.id AND tm.user_id = $1
 	          LEFT JOIN docs_shares s ON s.document_id = d.id AND s.user_id = $1
 	          WHERE t.owner_id = $1
+	             OR tm.id IS NOT NULL
 	             OR d.premission IN ('public', 'public_write')
 	             OR s.id IS NOT NULL
 	          ORDER BY d.created_at DESC`
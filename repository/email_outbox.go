@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateOutboxEmail queues a rendered email for delivery
+func CreateOutboxEmail(ctx context.Context, tx pgx.Tx, email models.OutboxEmail) error {
+	query := `INSERT INTO email_outbox (id, recipient, template, subject, text_body, html_body, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := tx.Exec(ctx, query,
+		email.ID,
+		email.Recipient,
+		email.Template,
+		email.Subject,
+		email.TextBody,
+		email.HTMLBody,
+		email.Status,
+		email.Attempts,
+		email.LastError,
+		email.NextAttemptAt,
+		email.SentAt,
+		email.CreatedAt,
+		email.UpdatedAt,
+	)
+
+	return err
+}
+
+// ClaimDueOutboxEmail locks the pending email whose next attempt is the most overdue,
+// rows locked by another worker are skipped
+func ClaimDueOutboxEmail(ctx context.Context, tx pgx.Tx, now any) (*models.OutboxEmail, error) {
+	query := `SELECT id, recipient, template, subject, text_body, html_body, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at
+	          FROM email_outbox
+	          WHERE status = $1 AND next_attempt_at <= $2
+	          ORDER BY next_attempt_at
+	          LIMIT 1
+	          FOR UPDATE SKIP LOCKED`
+
+	var email models.OutboxEmail
+	err := tx.QueryRow(ctx, query, models.EmailStatusPending, now).Scan(
+		&email.ID,
+		&email.Recipient,
+		&email.Template,
+		&email.Subject,
+		&email.TextBody,
+		&email.HTMLBody,
+		&email.Status,
+		&email.Attempts,
+		&email.LastError,
+		&email.NextAttemptAt,
+		&email.SentAt,
+		&email.CreatedAt,
+		&email.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, nothing is due
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+// MarkOutboxEmailSent records a successful delivery
+func MarkOutboxEmailSent(ctx context.Context, tx pgx.Tx, emailID int64, sentAt any) error {
+	query := `UPDATE email_outbox
+	          SET status = $2, sent_at = $3, updated_at = $3
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, emailID, models.EmailStatusSent, sentAt)
+	return err
+}
+
+// MarkOutboxEmailFailed records a failed attempt and schedules the next one,
+// status is failed once the worker gives up
+func MarkOutboxEmailFailed(ctx context.Context, tx pgx.Tx, emailID int64, status models.EmailStatus, attempts int, lastError string, nextAttemptAt any, updatedAt any) error {
+	query := `UPDATE email_outbox
+	          SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, updated_at = $6
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, emailID, status, attempts, lastError, nextAttemptAt, updatedAt)
+	return err
+}
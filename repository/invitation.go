@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateInvitation inserts a new invitation record
+func CreateInvitation(ctx context.Context, tx pgx.Tx, invitation models.Invitation) error {
+	query := `INSERT INTO invitations (id, team_id, email, role, invited_by, status, expires_at, responded_at, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := tx.Exec(ctx, query,
+		invitation.ID,
+		invitation.TeamID,
+		invitation.Email,
+		invitation.Role,
+		invitation.InvitedBy,
+		invitation.Status,
+		invitation.ExpiresAt,
+		invitation.RespondedAt,
+		invitation.CreatedAt,
+		invitation.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetInvitationByID retrieves an invitation by its ID
+func GetInvitationByID(ctx context.Context, tx pgx.Tx, invitationID int64) (*models.Invitation, error) {
+	query := `SELECT id, team_id, email, role, invited_by, status, expires_at, responded_at, created_at, updated_at
+	          FROM invitations
+	          WHERE id = $1
+	          LIMIT 1
+	          FOR UPDATE`
+
+	var invitation models.Invitation
+	err := tx.QueryRow(ctx, query, invitationID).Scan(
+		&invitation.ID,
+		&invitation.TeamID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.InvitedBy,
+		&invitation.Status,
+		&invitation.ExpiresAt,
+		&invitation.RespondedAt,
+		&invitation.CreatedAt,
+		&invitation.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// GetPendingInvitationByTeamAndEmail retrieves a pending, unexpired invitation for an email in a team
+func GetPendingInvitationByTeamAndEmail(ctx context.Context, tx pgx.Tx, teamID int64, email string, now any) (*models.Invitation, error) {
+	query := `SELECT id, team_id, email, role, invited_by, status, expires_at, responded_at, created_at, updated_at
+	          FROM invitations
+	          WHERE team_id = $1 AND email = $2 AND status = $3 AND expires_at > $4
+	          LIMIT 1`
+
+	var invitation models.Invitation
+	err := tx.QueryRow(ctx, query, teamID, email, models.InvitationStatusPending, now).Scan(
+		&invitation.ID,
+		&invitation.TeamID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.InvitedBy,
+		&invitation.Status,
+		&invitation.ExpiresAt,
+		&invitation.RespondedAt,
+		&invitation.CreatedAt,
+		&invitation.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// UpdateInvitationStatus records the invitee's response to a pending invitation,
+// and returns false when the invitation was already answered
+func UpdateInvitationStatus(ctx context.Context, tx pgx.Tx, invitationID int64, status models.InvitationStatus, respondedAt any) (bool, error) {
+	query := `UPDATE invitations
+	          SET status = $1, responded_at = $2, updated_at = $2
+	          WHERE id = $3 AND status = $4`
+
+	result, err := tx.Exec(ctx, query, status, respondedAt, invitationID, models.InvitationStatusPending)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// DeleteInvitationsByTeamID removes all invitations linked to a team
+func DeleteInvitationsByTeamID(ctx context.Context, tx pgx.Tx, teamID int64) error {
+	query := `DELETE FROM invitations WHERE team_id = $1`
+	_, err := tx.Exec(ctx, query, teamID)
+	return err
+}
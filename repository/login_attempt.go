@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LockLoginAttempt retrieves the counter and locks it for update, creating it when missing so concurrent logins
+// for the same key wait for each other. The upsert returns a row even when another transaction deleted it meanwhile.
+func LockLoginAttempt(ctx context.Context, tx pgx.Tx, kind models.LoginAttemptKind, key string, now any) (*models.LoginAttempt, error) {
+	query := `INSERT INTO login_attempts (kind, key, failures, updated_at)
+	          VALUES ($1, $2, 0, $3)
+	          ON CONFLICT (kind, key) DO UPDATE SET key = EXCLUDED.key
+	          RETURNING kind, key, failures, last_failed_at, locked_until, unlock_token_hash, updated_at`
+
+	var attempt models.LoginAttempt
+	err := tx.QueryRow(ctx, query, kind, key, now).Scan(
+		&attempt.Kind,
+		&attempt.Key,
+		&attempt.Failures,
+		&attempt.LastFailedAt,
+		&attempt.LockedUntil,
+		&attempt.UnlockTokenHash,
+		&attempt.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// GetLoginAttempt retrieves the counter of a key
+func GetLoginAttempt(ctx context.Context, tx pgx.Tx, kind models.LoginAttemptKind, key string) (*models.LoginAttempt, error) {
+	query := `SELECT kind, key, failures, last_failed_at, locked_until, unlock_token_hash, updated_at
+	          FROM login_attempts
+	          WHERE kind = $1 AND key = $2`
+
+	var attempt models.LoginAttempt
+	err := tx.QueryRow(ctx, query, kind, key).Scan(
+		&attempt.Kind,
+		&attempt.Key,
+		&attempt.Failures,
+		&attempt.LastFailedAt,
+		&attempt.LockedUntil,
+		&attempt.UnlockTokenHash,
+		&attempt.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// RecordLoginAttemptFailure counts a failure, the count restarts when the last one is older than windowStart
+func RecordLoginAttemptFailure(ctx context.Context, tx pgx.Tx, kind models.LoginAttemptKind, key string, now any, windowStart any) (*models.LoginAttempt, error) {
+	query := `INSERT INTO login_attempts (kind, key, failures, last_failed_at, updated_at)
+	          VALUES ($1, $2, 1, $3, $3)
+	          ON CONFLICT (kind, key) DO UPDATE SET
+	              failures = CASE
+	                  WHEN login_attempts.last_failed_at IS NULL OR login_attempts.last_failed_at < $4 THEN 1
+	                  ELSE login_attempts.failures + 1
+	              END,
+	              last_failed_at = $3,
+	              updated_at = $3
+	          RETURNING kind, key, failures, last_failed_at, locked_until, unlock_token_hash, updated_at`
+
+	var attempt models.LoginAttempt
+	err := tx.QueryRow(ctx, query, kind, key, now, windowStart).Scan(
+		&attempt.Kind,
+		&attempt.Key,
+		&attempt.Failures,
+		&attempt.LastFailedAt,
+		&attempt.LockedUntil,
+		&attempt.UnlockTokenHash,
+		&attempt.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// LockLoginAttemptUntil refuses the key until lockedUntil and restarts its count
+func LockLoginAttemptUntil(ctx context.Context, tx pgx.Tx, kind models.LoginAttemptKind, key string, lockedUntil any, unlockTokenHash *string, now any) error {
+	query := `UPDATE login_attempts
+	          SET failures = 0, locked_until = $3, unlock_token_hash = $4, updated_at = $5
+	          WHERE kind = $1 AND key = $2`
+
+	_, err := tx.Exec(ctx, query, kind, key, lockedUntil, unlockTokenHash, now)
+	return err
+}
+
+// ResetLoginAttempt forgets the failures of a key, the row stays so logins waiting on its lock still find it
+func ResetLoginAttempt(ctx context.Context, tx pgx.Tx, kind models.LoginAttemptKind, key string, now any) error {
+	query := `UPDATE login_attempts
+	          SET failures = 0, last_failed_at = NULL, updated_at = $3
+	          WHERE kind = $1 AND key = $2`
+
+	_, err := tx.Exec(ctx, query, kind, key, now)
+	return err
+}
+
+// DeleteLoginAttemptsByKeys forgets the failures of the keys, and returns how many had some
+func DeleteLoginAttemptsByKeys(ctx context.Context, tx pgx.Tx, kind models.LoginAttemptKind, keys []string) (int64, error) {
+	query := `DELETE FROM login_attempts WHERE kind = $1 AND key = ANY($2)`
+
+	result, err := tx.Exec(ctx, query, kind, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DeleteLockedLoginAttemptByUnlockTokenHash lifts the lockout the token was emailed for, and returns whether it was still locked
+func DeleteLockedLoginAttemptByUnlockTokenHash(ctx context.Context, tx pgx.Tx, unlockTokenHash string, now any) (bool, error) {
+	query := `DELETE FROM login_attempts WHERE unlock_token_hash = $1 AND locked_until > $2`
+
+	result, err := tx.Exec(ctx, query, unlockTokenHash, now)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// DeleteStaleLoginAttempts deletes the counters untouched since before and not locked at now
+func DeleteStaleLoginAttempts(ctx context.Context, tx pgx.Tx, before any, now any) (int64, error) {
+	query := `DELETE FROM login_attempts
+	          WHERE updated_at < $1 AND (locked_until IS NULL OR locked_until <= $2)`
+
+	result, err := tx.Exec(ctx, query, before, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
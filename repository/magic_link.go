@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateMagicLink inserts a new magic link
+func CreateMagicLink(ctx context.Context, tx pgx.Tx, link models.MagicLink) error {
+	query := `INSERT INTO magic_links (id, email, invitation_token, ip, expires_at, used_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := tx.Exec(ctx, query,
+		link.ID,
+		link.Email,
+		link.InvitationToken,
+		link.IP,
+		link.ExpiresAt,
+		link.UsedAt,
+		link.CreatedAt,
+	)
+
+	return err
+}
+
+// GetMagicLinkByIDForUpdate retrieves a magic link and locks it until the transaction ends
+func GetMagicLinkByIDForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*models.MagicLink, error) {
+	query := `SELECT id, email, invitation_token, ip, expires_at, used_at, created_at
+	          FROM magic_links
+	          WHERE id = $1
+	          FOR UPDATE`
+
+	var link models.MagicLink
+	err := tx.QueryRow(ctx, query, id).Scan(
+		&link.ID,
+		&link.Email,
+		&link.InvitationToken,
+		&link.IP,
+		&link.ExpiresAt,
+		&link.UsedAt,
+		&link.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// MarkMagicLinkUsed marks the magic link as used
+func MarkMagicLinkUsed(ctx context.Context, tx pgx.Tx, id int64, usedAt any) error {
+	query := `UPDATE magic_links SET used_at = $2 WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, id, usedAt)
+	return err
+}
+
+// CountMagicLinksByEmailSince returns how many links were sent to the address since then
+func CountMagicLinksByEmailSince(ctx context.Context, tx pgx.Tx, email string, since any) (int, error) {
+	query := `SELECT COUNT(*)
+	          FROM magic_links
+	          WHERE lower(email) = lower($1) AND created_at > $2`
+
+	var count int
+	err := tx.QueryRow(ctx, query, email, since).Scan(&count)
+	return count, err
+}
+
+// CountMagicLinksByIPSince returns how many links were requested from the IP address since then, and when the oldest of them was
+func CountMagicLinksByIPSince(ctx context.Context, tx pgx.Tx, ip string, since any) (int, *time.Time, error) {
+	query := `SELECT COUNT(*), MIN(created_at)
+	          FROM magic_links
+	          WHERE ip = $1 AND created_at > $2`
+
+	var count int
+	var oldest *time.Time
+	err := tx.QueryRow(ctx, query, ip, since).Scan(&count, &oldest)
+	return count, oldest, err
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetUserTOTP retrieves the TOTP authenticator of the user and locks it until the transaction ends
+func GetUserTOTP(ctx context.Context, tx pgx.Tx, userID int64) (*models.UserTOTP, error) {
+	query := `SELECT user_id, secret, confirmed_at, last_used_step, created_at, updated_at
+	          FROM user_totp
+	          WHERE user_id = $1
+	          FOR UPDATE`
+
+	var totp models.UserTOTP
+	err := tx.QueryRow(ctx, query, userID).Scan(
+		&totp.UserID,
+		&totp.Secret,
+		&totp.ConfirmedAt,
+		&totp.LastUsedStep,
+		&totp.CreatedAt,
+		&totp.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &totp, nil
+}
+
+// UpsertUserTOTP starts a new unconfirmed enrollment, replacing any previous one
+func UpsertUserTOTP(ctx context.Context, tx pgx.Tx, totp models.UserTOTP) error {
+	query := `INSERT INTO user_totp (user_id, secret, confirmed_at, last_used_step, created_at, updated_at)
+	          VALUES ($1, $2, NULL, NULL, $3, $4)
+	          ON CONFLICT (user_id)
+	          DO UPDATE SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = NULL,
+	                        created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at`
+
+	_, err := tx.Exec(ctx, query, totp.UserID, totp.Secret, totp.CreatedAt, totp.UpdatedAt)
+	return err
+}
+
+// ConfirmUserTOTP marks the authenticator as confirmed
+func ConfirmUserTOTP(ctx context.Context, tx pgx.Tx, userID int64, step int64, confirmedAt any) error {
+	query := `UPDATE user_totp
+	          SET confirmed_at = $2, last_used_step = $3, updated_at = $2
+	          WHERE user_id = $1`
+
+	_, err := tx.Exec(ctx, query, userID, confirmedAt, step)
+	return err
+}
+
+// UpdateUserTOTPLastUsedStep remembers the time step of the accepted code so it can't be replayed
+func UpdateUserTOTPLastUsedStep(ctx context.Context, tx pgx.Tx, userID int64, step int64, updatedAt any) error {
+	query := `UPDATE user_totp
+	          SET last_used_step = $2, updated_at = $3
+	          WHERE user_id = $1`
+
+	_, err := tx.Exec(ctx, query, userID, step, updatedAt)
+	return err
+}
+
+// DeleteUserTOTP removes the authenticator of the user
+func DeleteUserTOTP(ctx context.Context, tx pgx.Tx, userID int64) error {
+	query := `DELETE FROM user_totp WHERE user_id = $1`
+
+	_, err := tx.Exec(ctx, query, userID)
+	return err
+}
+
+// ReplaceMFARecoveryCodes removes the recovery codes of the user and stores the new ones
+func ReplaceMFARecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64, codes []models.MFARecoveryCode) error {
+	if err := DeleteMFARecoveryCodesByUserID(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO mfa_recovery_codes (id, user_id, code_hash, used_at, created_at)
+	          VALUES ($1, $2, $3, NULL, $4)`
+
+	batch := &pgx.Batch{}
+	for _, code := range codes {
+		batch.Queue(query, code.ID, code.UserID, code.CodeHash, code.CreatedAt)
+	}
+
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// DeleteMFARecoveryCodesByUserID removes every recovery code of the user
+func DeleteMFARecoveryCodesByUserID(ctx context.Context, tx pgx.Tx, userID int64) error {
+	query := `DELETE FROM mfa_recovery_codes WHERE user_id = $1`
+
+	_, err := tx.Exec(ctx, query, userID)
+	return err
+}
+
+// UseMFARecoveryCode marks an unused recovery code as used, it reports false when there was none
+func UseMFARecoveryCode(ctx context.Context, tx pgx.Tx, userID int64, codeHash string, usedAt any) (bool, error) {
+	query := `UPDATE mfa_recovery_codes
+	          SET used_at = $3
+	          WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	tag, err := tx.Exec(ctx, query, userID, codeHash, usedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// CountUnusedMFARecoveryCodes counts the recovery codes the user can still use
+func CountUnusedMFARecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var count int
+	err := tx.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// CreateMFAChallenge inserts the challenge behind a new MFA token
+func CreateMFAChallenge(ctx context.Context, tx pgx.Tx, challenge models.MFAChallenge) error {
+	query := `INSERT INTO mfa_challenges (id, user_id, attempts, expires_at, used_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := tx.Exec(ctx, query,
+		challenge.ID,
+		challenge.UserID,
+		challenge.Attempts,
+		challenge.ExpiresAt,
+		challenge.UsedAt,
+		challenge.CreatedAt,
+	)
+
+	return err
+}
+
+// GetMFAChallengeByID retrieves an MFA challenge and locks it until the transaction ends
+func GetMFAChallengeByID(ctx context.Context, tx pgx.Tx, challengeID int64) (*models.MFAChallenge, error) {
+	query := `SELECT id, user_id, attempts, expires_at, used_at, created_at
+	          FROM mfa_challenges
+	          WHERE id = $1
+	          FOR UPDATE`
+
+	var challenge models.MFAChallenge
+	err := tx.QueryRow(ctx, query, challengeID).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+		&challenge.UsedAt,
+		&challenge.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// IncrementMFAChallengeAttempts counts a wrong code sent with the challenge
+func IncrementMFAChallengeAttempts(ctx context.Context, tx pgx.Tx, challengeID int64) error {
+	query := `UPDATE mfa_challenges
+	          SET attempts = attempts + 1
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, challengeID)
+	return err
+}
+
+// MarkMFAChallengeUsed marks the challenge as used so its token can't complete another login
+func MarkMFAChallengeUsed(ctx context.Context, tx pgx.Tx, challengeID int64, usedAt any) error {
+	query := `UPDATE mfa_challenges
+	          SET used_at = $2
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, challengeID, usedAt)
+	return err
+}
+
+// DeleteExpiredMFAChallenges removes the challenges whose token has expired
+func DeleteExpiredMFAChallenges(ctx context.Context, tx pgx.Tx, now any) error {
+	query := `DELETE FROM mfa_challenges WHERE expires_at < $1`
+
+	_, err := tx.Exec(ctx, query, now)
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreatePasswordResetToken inserts a new password reset token
+func CreatePasswordResetToken(ctx context.Context, tx pgx.Tx, token models.PasswordResetToken) error {
+	query := `INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, used_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := tx.Exec(ctx, query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.UsedAt,
+		token.CreatedAt,
+	)
+
+	return err
+}
+
+// GetPasswordResetTokenByHash retrieves and locks a password reset token by its hash
+func GetPasswordResetTokenByHash(ctx context.Context, tx pgx.Tx, tokenHash string) (*models.PasswordResetToken, error) {
+	query := `SELECT id, user_id, token_hash, expires_at, used_at, created_at
+	          FROM password_reset_tokens
+	          WHERE token_hash = $1
+	          LIMIT 1
+	          FOR UPDATE`
+
+	var token models.PasswordResetToken
+	err := tx.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkPasswordResetTokensUsedByUserID marks every unused reset token of the user as used
+func MarkPasswordResetTokensUsedByUserID(ctx context.Context, tx pgx.Tx, userID int64, usedAt any) error {
+	query := `UPDATE password_reset_tokens
+	          SET used_at = $2
+	          WHERE user_id = $1 AND used_at IS NULL`
+
+	_, err := tx.Exec(ctx, query, userID, usedAt)
+	return err
+}
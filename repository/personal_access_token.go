@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreatePersonalAccessToken inserts a new personal access token
+func CreatePersonalAccessToken(ctx context.Context, tx pgx.Tx, token models.PersonalAccessToken) error {
+	query := `INSERT INTO personal_access_tokens (id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := tx.Exec(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenPrefix,
+		token.TokenHash,
+		token.Scopes,
+		token.ExpiresAt,
+		token.LastUsedAt,
+		token.RevokedAt,
+		token.CreatedAt,
+	)
+
+	return err
+}
+
+// ListPersonalAccessTokensByUserID retrieves the tokens of the user that aren't revoked, newest first
+func ListPersonalAccessTokensByUserID(ctx context.Context, tx pgx.Tx, userID int64) ([]models.PersonalAccessToken, error) {
+	query := `SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	          FROM personal_access_tokens
+	          WHERE user_id = $1 AND revoked_at IS NULL
+	          ORDER BY created_at DESC`
+
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		var token models.PersonalAccessToken
+		if err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.TokenPrefix,
+			&token.TokenHash,
+			&token.Scopes,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.RevokedAt,
+			&token.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// GetPersonalAccessTokenByHash retrieves a personal access token by its hash
+func GetPersonalAccessTokenByHash(ctx context.Context, tx pgx.Tx, tokenHash string) (*models.PersonalAccessToken, error) {
+	query := `SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	          FROM personal_access_tokens
+	          WHERE token_hash = $1
+	          LIMIT 1`
+
+	var token models.PersonalAccessToken
+	err := tx.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenPrefix,
+		&token.TokenHash,
+		&token.Scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// TouchPersonalAccessToken records a use of the token, at most once a minute so busy scripts don't write on every request
+func TouchPersonalAccessToken(ctx context.Context, tx pgx.Tx, id int64, usedAt any) error {
+	query := `UPDATE personal_access_tokens
+	          SET last_used_at = $2
+	          WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2::timestamp - interval '1 minute')`
+
+	_, err := tx.Exec(ctx, query, id, usedAt)
+	return err
+}
+
+// RevokePersonalAccessToken revokes a token of the user, it reports false when the user has no such active token
+func RevokePersonalAccessToken(ctx context.Context, tx pgx.Tx, id int64, userID int64, revokedAt any) (bool, error) {
+	query := `UPDATE personal_access_tokens
+	          SET revoked_at = $3
+	          WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	tag, err := tx.Exec(ctx, query, id, userID, revokedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
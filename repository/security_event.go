@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateSecurityEvent records a security event
+func CreateSecurityEvent(ctx context.Context, tx pgx.Tx, event models.SecurityEvent) error {
+	query := `INSERT INTO security_events (id, user_id, type, ip, user_agent, details, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := tx.Exec(ctx, query,
+		event.ID,
+		event.UserID,
+		event.Type,
+		event.IP,
+		event.UserAgent,
+		event.Details,
+		event.CreatedAt,
+	)
+
+	return err
+}
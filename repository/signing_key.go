@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LockSigningKeys locks the signing keys until the end of the transaction, so only one instance rotates them
+func LockSigningKeys(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `LOCK TABLE signing_keys IN EXCLUSIVE MODE`)
+	return err
+}
+
+// CreateSigningKey inserts a new signing key
+func CreateSigningKey(ctx context.Context, tx pgx.Tx, key models.SigningKey) error {
+	query := `INSERT INTO signing_keys (id, algorithm, private_key, public_key, activates_at, expires_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := tx.Exec(ctx, query,
+		key.ID,
+		key.Algorithm,
+		key.PrivateKey,
+		key.PublicKey,
+		key.ActivatesAt,
+		key.ExpiresAt,
+		key.CreatedAt,
+	)
+
+	return err
+}
+
+// ListUnexpiredSigningKeys retrieves the keys that may still have signed a valid token, in activation order
+func ListUnexpiredSigningKeys(ctx context.Context, tx pgx.Tx, now any) ([]models.SigningKey, error) {
+	query := `SELECT id, algorithm, private_key, public_key, activates_at, expires_at, created_at
+	          FROM signing_keys
+	          WHERE expires_at > $1
+	          ORDER BY activates_at ASC, id ASC`
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.SigningKey{}
+	for rows.Next() {
+		var key models.SigningKey
+		if err := rows.Scan(
+			&key.ID,
+			&key.Algorithm,
+			&key.PrivateKey,
+			&key.PublicKey,
+			&key.ActivatesAt,
+			&key.ExpiresAt,
+			&key.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// DeleteExpiredSigningKeys deletes the keys no valid token can be signed with anymore
+func DeleteExpiredSigningKeys(ctx context.Context, tx pgx.Tx, now any) (int64, error) {
+	tag, err := tx.Exec(ctx, `DELETE FROM signing_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -67,6 +67,33 @@ func GetTeamByID(ctx context.Context, tx pgx.Tx, teamID int64) (*models.Team, er
 	return &team, nil
 }
 
+// GetTeamByIDForUpdate retrieves a team by ID and locks the row until the transaction ends
+func GetTeamByIDForUpdate(ctx context.Context, tx pgx.Tx, teamID int64) (*models.Team, error) {
+	query := `SELECT id, owner_id, name, created_at, updated_at
+	          FROM teams
+	          WHERE id = $1
+	          FOR UPDATE`
+
+	var team models.Team
+	err := tx.QueryRow(ctx, query, teamID).Scan(
+		&team.ID,
+		&team.OwnerID,
+		&team.Name,
+		&team.CreatedAt,
+		&team.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
 // UpdateTeam updates an existing team
 func UpdateTeam(ctx context.Context, tx pgx.Tx, teamID int64, name string, updatedAt any) error {
 	query := `UPDATE teams
@@ -77,6 +104,16 @@ func UpdateTeam(ctx context.Context, tx pgx.Tx, teamID int64, name string, updat
 	return err
 }
 
+// UpdateTeamOwner hands the team over to another user
+func UpdateTeamOwner(ctx context.Context, tx pgx.Tx, teamID, ownerID int64, updatedAt any) error {
+	query := `UPDATE teams
+	          SET owner_id = $1, updated_at = $2
+	          WHERE id = $3`
+
+	_, err := tx.Exec(ctx, query, ownerID, updatedAt, teamID)
+	return err
+}
+
 // DeleteTeamMembersByTeamID removes all members linked to a team
 func DeleteTeamMembersByTeamID(ctx context.Context, tx pgx.Tx, teamID int64) error {
 	query := `DELETE FROM team_members WHERE team_id = $1`
@@ -120,3 +157,84 @@ func ListTeamsByUserID(ctx context.Context, tx pgx.Tx, userID int64) ([]models.T
 
 	return teams, nil
 }
+
+// GetTeamMemberByTeamAndUser retrieves a user's membership in a team
+func GetTeamMemberByTeamAndUser(ctx context.Context, tx pgx.Tx, teamID, userID int64) (*models.TeamMember, error) {
+	query := `SELECT id, team_id, user_id, role, created_at, updated_at
+	          FROM team_members
+	          WHERE team_id = $1 AND user_id = $2
+	          LIMIT 1`
+
+	var teamMember models.TeamMember
+	err := tx.QueryRow(ctx, query, teamID, userID).Scan(
+		&teamMember.ID,
+		&teamMember.TeamID,
+		&teamMember.UserID,
+		&teamMember.Role,
+		&teamMember.CreatedAt,
+		&teamMember.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &teamMember, nil
+}
+
+// ListTeamMembersByTeamID returns all members of a team
+func ListTeamMembersByTeamID(ctx context.Context, tx pgx.Tx, teamID int64) ([]models.TeamMember, error) {
+	query := `SELECT id, team_id, user_id, role, created_at, updated_at
+	          FROM team_members
+	          WHERE team_id = $1
+	          ORDER BY created_at ASC`
+
+	rows, err := tx.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teamMembers []models.TeamMember
+	for rows.Next() {
+		var teamMember models.TeamMember
+		if err := rows.Scan(
+			&teamMember.ID,
+			&teamMember.TeamID,
+			&teamMember.UserID,
+			&teamMember.Role,
+			&teamMember.CreatedAt,
+			&teamMember.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		teamMembers = append(teamMembers, teamMember)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return teamMembers, nil
+}
+
+// UpdateTeamMemberRole changes the role of a team member
+func UpdateTeamMemberRole(ctx context.Context, tx pgx.Tx, teamID, userID int64, role models.Role, updatedAt any) error {
+	query := `UPDATE team_members
+	          SET role = $1, updated_at = $2
+	          WHERE team_id = $3 AND user_id = $4`
+
+	_, err := tx.Exec(ctx, query, role, updatedAt, teamID, userID)
+	return err
+}
+
+// DeleteTeamMember removes a user from a team
+func DeleteTeamMember(ctx context.Context, tx pgx.Tx, teamID, userID int64) error {
+	query := `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`
+	_, err := tx.Exec(ctx, query, teamID, userID)
+	return err
+}
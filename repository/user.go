@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetUserByIDForUpdate retrieves a user by ID and locks the row until the transaction ends
+func GetUserByIDForUpdate(ctx context.Context, tx pgx.Tx, userID int64) (*models.User, error) {
+	query := `SELECT id, password_hash, display_name, avatar, disabled_at, deleted_at, created_at, updated_at
+	          FROM users
+	          WHERE id = $1
+	          FOR UPDATE`
+
+	var user models.User
+	err := tx.QueryRow(ctx, query, userID).Scan(
+		&user.ID,
+		&user.PasswordHash,
+		&user.DisplayName,
+		&user.Avatar,
+		&user.DisabledAt,
+		&user.DeletedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpdateUserProfile updates the display name and avatar of the user
+func UpdateUserProfile(ctx context.Context, tx pgx.Tx, userID int64, displayName string, avatar *string, updatedAt any) error {
+	query := `UPDATE users
+	          SET display_name = $2, avatar = $3, updated_at = $4
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, userID, displayName, avatar, updatedAt)
+	return err
+}
+
+// ClearUserPasswordHash removes the password of the user
+func ClearUserPasswordHash(ctx context.Context, tx pgx.Tx, userID int64, updatedAt any) error {
+	query := `UPDATE users
+	          SET password_hash = NULL, updated_at = $2
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, userID, updatedAt)
+	return err
+}
+
+// SetUserDisabledAt disables the user, or enables them again when disabledAt is nil
+func SetUserDisabledAt(ctx context.Context, tx pgx.Tx, userID int64, disabledAt any, updatedAt any) error {
+	query := `UPDATE users
+	          SET disabled_at = $2, updated_at = $3
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, userID, disabledAt, updatedAt)
+	return err
+}
+
+// ListDisabledUserIDs retrieves the IDs of the disabled users
+func ListDisabledUserIDs(ctx context.Context, tx pgx.Tx) ([]int64, error) {
+	rows, err := tx.Query(ctx, `SELECT id FROM users WHERE disabled_at IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []int64{}
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// DeleteUserData deletes the accounts, credentials, sessions, memberships and shares of the user, along with
+// the login throttling and magic links of their email addresses. The user record itself is kept, see AnonymizeUser
+func DeleteUserData(ctx context.Context, tx pgx.Tx, userID int64) error {
+	// The rows keyed by email go first, the accounts are how they are found
+	queries := []string{
+		`DELETE FROM login_attempts WHERE kind = 'email' AND key IN (SELECT lower(email) FROM accounts WHERE user_id = $1)`,
+		`DELETE FROM magic_links WHERE email IN (SELECT email FROM accounts WHERE user_id = $1)`,
+		`DELETE FROM oauth_tokens WHERE account_id IN (SELECT id FROM accounts WHERE user_id = $1)`,
+		`DELETE FROM accounts WHERE user_id = $1`,
+		`DELETE FROM refresh_tokens WHERE user_id = $1`,
+		`DELETE FROM personal_access_tokens WHERE user_id = $1`,
+		`DELETE FROM password_reset_tokens WHERE user_id = $1`,
+		`DELETE FROM user_totp WHERE user_id = $1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM mfa_challenges WHERE user_id = $1`,
+		`DELETE FROM webauthn_credentials WHERE user_id = $1`,
+		`DELETE FROM webauthn_sessions WHERE user_id = $1`,
+		`DELETE FROM security_events WHERE user_id = $1`,
+		`DELETE FROM team_members WHERE user_id = $1`,
+		`DELETE FROM docs_shares WHERE user_id = $1`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AnonymizeUser strips the user of their name, avatar and password and marks them deleted
+func AnonymizeUser(ctx context.Context, tx pgx.Tx, userID int64, displayName string, deletedAt any) error {
+	query := `UPDATE users
+	          SET display_name = $2, avatar = NULL, password_hash = NULL, deleted_at = $3, updated_at = $3
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, userID, displayName, deletedAt)
+	return err
+}
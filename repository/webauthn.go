@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"ridash/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateWebAuthnCredential inserts a new passkey
+func CreateWebAuthnCredential(ctx context.Context, tx pgx.Tx, credential models.WebAuthnCredential) error {
+	query := `INSERT INTO webauthn_credentials (id, user_id, credential_id, public_key, attestation_type, transports, aaguid,
+	                                            sign_count, backup_eligible, backup_state, name, last_used_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := tx.Exec(ctx, query,
+		credential.ID,
+		credential.UserID,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		credential.Transports,
+		credential.AAGUID,
+		credential.SignCount,
+		credential.BackupEligible,
+		credential.BackupState,
+		credential.Name,
+		credential.LastUsedAt,
+		credential.CreatedAt,
+	)
+
+	return err
+}
+
+// ListWebAuthnCredentialsByUserID retrieves the passkeys of the user, oldest first
+func ListWebAuthnCredentialsByUserID(ctx context.Context, tx pgx.Tx, userID int64) ([]models.WebAuthnCredential, error) {
+	query := `SELECT id, user_id, credential_id, public_key, attestation_type, transports, aaguid,
+	                 sign_count, backup_eligible, backup_state, name, last_used_at, created_at
+	          FROM webauthn_credentials
+	          WHERE user_id = $1
+	          ORDER BY created_at`
+
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []models.WebAuthnCredential{}
+	for rows.Next() {
+		var credential models.WebAuthnCredential
+		if err := rows.Scan(
+			&credential.ID,
+			&credential.UserID,
+			&credential.CredentialID,
+			&credential.PublicKey,
+			&credential.AttestationType,
+			&credential.Transports,
+			&credential.AAGUID,
+			&credential.SignCount,
+			&credential.BackupEligible,
+			&credential.BackupState,
+			&credential.Name,
+			&credential.LastUsedAt,
+			&credential.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+// CountWebAuthnCredentialsByUserID counts the passkeys of the user
+func CountWebAuthnCredentialsByUserID(ctx context.Context, tx pgx.Tx, userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM webauthn_credentials WHERE user_id = $1`
+
+	var count int
+	err := tx.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// UpdateWebAuthnCredentialUsage stores the signature counter and backup state of a successful login
+func UpdateWebAuthnCredentialUsage(ctx context.Context, tx pgx.Tx, id int64, signCount int64, backupState bool, usedAt any) error {
+	query := `UPDATE webauthn_credentials
+	          SET sign_count = $2, backup_state = $3, last_used_at = $4
+	          WHERE id = $1`
+
+	_, err := tx.Exec(ctx, query, id, signCount, backupState, usedAt)
+	return err
+}
+
+// DeleteWebAuthnCredential removes a passkey, it reports false when the user has no such credential
+func DeleteWebAuthnCredential(ctx context.Context, tx pgx.Tx, id int64, userID int64) (bool, error) {
+	query := `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`
+
+	tag, err := tx.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// CreateWebAuthnSession inserts the challenge of a ceremony
+func CreateWebAuthnSession(ctx context.Context, tx pgx.Tx, session models.WebAuthnSession) error {
+	query := `INSERT INTO webauthn_sessions (id, token_hash, user_id, purpose, data, expires_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := tx.Exec(ctx, query,
+		session.ID,
+		session.TokenHash,
+		session.UserID,
+		session.Purpose,
+		session.Data,
+		session.ExpiresAt,
+		session.CreatedAt,
+	)
+
+	return err
+}
+
+// TakeWebAuthnSession deletes the ceremony session and returns it, so each challenge is answered at most once
+func TakeWebAuthnSession(ctx context.Context, tx pgx.Tx, tokenHash string) (*models.WebAuthnSession, error) {
+	query := `DELETE FROM webauthn_sessions
+	          WHERE token_hash = $1
+	          RETURNING id, token_hash, user_id, purpose, data, expires_at, created_at`
+
+	var session models.WebAuthnSession
+	err := tx.QueryRow(ctx, query, tokenHash).Scan(
+		&session.ID,
+		&session.TokenHash,
+		&session.UserID,
+		&session.Purpose,
+		&session.Data,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil // Not an error, just not found
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// DeleteExpiredWebAuthnSessions removes the ceremonies that were never finished
+func DeleteExpiredWebAuthnSessions(ctx context.Context, tx pgx.Tx, now any) error {
+	query := `DELETE FROM webauthn_sessions WHERE expires_at < $1`
+
+	_, err := tx.Exec(ctx, query, now)
+	return err
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"ridash/handler/admin"
+	"ridash/middleware"
+	"ridash/utils/loginguard"
+	"ridash/utils/revocation"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+)
+
+// AdminRouter wires the routes reserved to the users in ADMIN_USER_IDS
+func AdminRouter(api *echo.Group, db *pgxpool.Pool) {
+	adminHandler := &admin.AdminHandler{
+		DB:          db,
+		Revocations: revocation.Default(),
+		LoginGuard:  loginguard.Default(),
+	}
+
+	r := api.Group("/admin", middleware.AuthRequiredMiddleware, middleware.AdminRequiredMiddleware)
+	r.POST("/users/:userID/disable", adminHandler.DisableUser)
+	r.POST("/users/:userID/enable", adminHandler.EnableUser)
+	r.POST("/users/:userID/unlock", adminHandler.UnlockUser)
+}
@@ -4,6 +4,10 @@ import (
 	"ridash/handler/auth"
 	"ridash/middleware"
 	"ridash/utils/config"
+	"ridash/utils/keyring"
+	"ridash/utils/loginguard"
+	"ridash/utils/mailer"
+	"ridash/utils/revocation"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
@@ -17,15 +21,54 @@ func AuthRouter(api *echo.Group, db *pgxpool.Pool) {
 		zap.L().Fatal("Failed to initialize OAuth config", zap.Error(err))
 	}
 
+	webAuthn, err := config.GetWebAuthn()
+	if err != nil {
+		zap.L().Fatal("Failed to initialize WebAuthn config", zap.Error(err))
+	}
+
 	authHandler := &auth.AuthHandler{
 		DB:          db,
 		OAuthConfig: oauthConfig,
+		Mailer:      mailer.Default(),
+		WebAuthn:    webAuthn,
+		Keyring:     keyring.Default(),
+		Revocations: revocation.Default(),
+		LoginGuard:  loginguard.Default(),
 	}
 
 	r := api.Group("/auth")
 	r.POST("/register", authHandler.Register)
 	r.POST("/login", authHandler.Login)
+	r.POST("/login/mfa", authHandler.LoginMFA)
+	r.POST("/login/unlock", authHandler.UnlockLogin)
+	r.POST("/magic-link", authHandler.RequestMagicLink)
+	r.POST("/magic-link/consume", authHandler.ConsumeMagicLink)
 	r.POST("/refresh", authHandler.RefreshToken)
+	r.POST("/logout", authHandler.Logout)
+	r.POST("/logout-all", authHandler.LogoutAll, middleware.AuthRequiredMiddleware)
+
+	sessions := r.Group("/sessions", middleware.AuthRequiredMiddleware)
+	sessions.GET("", authHandler.ListSessions)
+	sessions.DELETE("/:id", authHandler.RevokeSession)
+
+	mfa := r.Group("/mfa", middleware.AuthRequiredMiddleware)
+	mfa.GET("", authHandler.MFAStatus)
+	mfa.POST("/totp", authHandler.EnrollTOTP)
+	mfa.POST("/totp/confirm", authHandler.ConfirmTOTP)
+	mfa.DELETE("/totp", authHandler.DisableTOTP)
+	mfa.POST("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+	// Registering a passkey needs a session, logging in with one doesn't
+	webauthn := r.Group("/webauthn")
+	webauthn.POST("/register/begin", authHandler.BeginWebAuthnRegistration, middleware.AuthRequiredMiddleware)
+	webauthn.POST("/register/finish", authHandler.FinishWebAuthnRegistration, middleware.AuthRequiredMiddleware)
+	webauthn.POST("/login/begin", authHandler.BeginWebAuthnLogin)
+	webauthn.POST("/login/finish", authHandler.FinishWebAuthnLogin)
+
+	r.POST("/verify-email", authHandler.VerifyEmail)
+	r.POST("/verify-email/resend", authHandler.ResendVerificationEmail)
+	r.POST("/password/forgot", authHandler.ForgotPassword)
+	r.POST("/password/reset", authHandler.ResetPassword)
 
 	// OAuth routes allow optional auth for linking existing accounts
 	oauth := r.Group("/oauth", middleware.AuthOptionalMiddleware)
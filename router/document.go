@@ -3,6 +3,7 @@ package router
 import (
 	"ridash/handler/document"
 	"ridash/middleware"
+	"ridash/models"
 	"ridash/utils/config"
 	"ridash/utils/docmanager"
 
@@ -25,19 +26,19 @@ func DocumentRouter(api *echo.Group, db *pgxpool.Pool) {
 
 	// Publicly readable endpoints (respect document permission checks in handlers)
 	readable := api.Group("/documents", middleware.AuthOptionalMiddleware)
-	readable.GET("", documentHandler.ListDocuments)
-	readable.GET("/:id", documentHandler.GetDocument)
+	readable.GET("", documentHandler.ListDocuments, middleware.RequireScopes(models.ScopeDocumentsRead))
+	readable.GET("/:id", documentHandler.GetDocument, middleware.RequireScopes(models.ScopeDocumentsRead))
 
 	// Authenticated endpoints for owners/collaborators
 	protected := api.Group("/documents", middleware.AuthRequiredMiddleware)
-	protected.POST("", documentHandler.CreateDocument)
-	protected.PUT("/:id", documentHandler.UpdateDocument)
-	protected.DELETE("/:id", documentHandler.DeleteDocument)
-	protected.GET("/:id/socket", documentHandler.ProxyDocumentWebsocket)
+	protected.POST("", documentHandler.CreateDocument, middleware.RequireScopes(models.ScopeDocumentsWrite))
+	protected.PUT("/:id", documentHandler.UpdateDocument, middleware.RequireScopes(models.ScopeDocumentsWrite))
+	protected.DELETE("/:id", documentHandler.DeleteDocument, middleware.RequireScopes(models.ScopeDocumentsWrite))
+	protected.GET("/:id/socket", documentHandler.ProxyDocumentWebsocket, middleware.RequireScopes(models.ScopeDocumentsWrite))
 
 	shares := protected.Group("/:id/shares")
-	shares.GET("", documentHandler.ListShares)
-	shares.POST("", documentHandler.CreateShare)
-	shares.PUT("/:shareID", documentHandler.UpdateShare)
-	shares.DELETE("/:shareID", documentHandler.DeleteShare)
+	shares.GET("", documentHandler.ListShares, middleware.RequireScopes(models.ScopeDocumentsRead))
+	shares.POST("", documentHandler.CreateShare, middleware.RequireScopes(models.ScopeDocumentsWrite))
+	shares.PUT("/:shareID", documentHandler.UpdateShare, middleware.RequireScopes(models.ScopeDocumentsWrite))
+	shares.DELETE("/:shareID", documentHandler.DeleteShare, middleware.RequireScopes(models.ScopeDocumentsWrite))
 }
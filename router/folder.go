@@ -3,6 +3,7 @@ package router
 import (
 	"ridash/handler/folder"
 	"ridash/middleware"
+	"ridash/models"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
@@ -15,9 +16,9 @@ func FolderRouter(api *echo.Group, db *pgxpool.Pool) {
 	}
 
 	r := api.Group("/teams/:teamID/folders", middleware.AuthRequiredMiddleware)
-	r.POST("", folderHandler.CreateFolder)
-	r.GET("", folderHandler.GetFolders)
-	r.GET("/:id", folderHandler.GetFolder)
-	r.PUT("/:id", folderHandler.UpdateFolder)
-	r.DELETE("/:id", folderHandler.DeleteFolder)
+	r.POST("", folderHandler.CreateFolder, middleware.RequireScopes(models.ScopeDocumentsWrite))
+	r.GET("", folderHandler.GetFolders, middleware.RequireScopes(models.ScopeDocumentsRead))
+	r.GET("/:id", folderHandler.GetFolder, middleware.RequireScopes(models.ScopeDocumentsRead))
+	r.PUT("/:id", folderHandler.UpdateFolder, middleware.RequireScopes(models.ScopeDocumentsWrite))
+	r.DELETE("/:id", folderHandler.DeleteFolder, middleware.RequireScopes(models.ScopeDocumentsWrite))
 }
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"ridash/handler/invitation"
+	"ridash/middleware"
+	"ridash/models"
+	"ridash/utils/mailer"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+)
+
+// InvitationRouter wires team invitation routes
+func InvitationRouter(api *echo.Group, db *pgxpool.Pool) {
+	invitationHandler := &invitation.InvitationHandler{
+		DB:     db,
+		Mailer: mailer.Default(),
+	}
+
+	teams := api.Group("/teams/:teamID/invitations", middleware.AuthRequiredMiddleware)
+	teams.POST("", invitationHandler.CreateInvitation, middleware.RequireScopes(models.ScopeTeamsAdmin))
+
+	r := api.Group("/invitations")
+	r.POST("/accept", invitationHandler.AcceptInvitation, middleware.AuthRequiredMiddleware, middleware.RequireScopes(models.ScopeTeamsWrite))
+	r.POST("/decline", invitationHandler.DeclineInvitation)
+}
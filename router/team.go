@@ -3,6 +3,7 @@ package router
 import (
 	"ridash/handler/team"
 	"ridash/middleware"
+	"ridash/models"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
@@ -15,9 +16,16 @@ func TeamRouter(api *echo.Group, db *pgxpool.Pool) {
 	}
 
 	r := api.Group("/teams", middleware.AuthRequiredMiddleware)
-	r.GET("", teamHandler.ListTeams)
-	r.POST("", teamHandler.CreateTeam)
-	r.GET("/:id", teamHandler.GetTeam)
-	r.PUT("/:id", teamHandler.UpdateTeam)
-	r.DELETE("/:id", teamHandler.DeleteTeam)
+	r.GET("", teamHandler.ListTeams, middleware.RequireScopes(models.ScopeTeamsRead))
+	r.POST("", teamHandler.CreateTeam, middleware.RequireScopes(models.ScopeTeamsWrite))
+	r.GET("/:id", teamHandler.GetTeam, middleware.RequireScopes(models.ScopeTeamsRead))
+	r.PUT("/:id", teamHandler.UpdateTeam, middleware.RequireScopes(models.ScopeTeamsAdmin))
+	r.DELETE("/:id", teamHandler.DeleteTeam, middleware.RequireScopes(models.ScopeTeamsAdmin))
+	r.POST("/:id/transfer", teamHandler.TransferTeam, middleware.RequireScopes(models.ScopeTeamsAdmin))
+
+	members := api.Group("/teams/:teamID/members", middleware.AuthRequiredMiddleware)
+	members.GET("", teamHandler.ListMembers, middleware.RequireScopes(models.ScopeTeamsRead))
+	members.POST("", teamHandler.AddMember, middleware.RequireScopes(models.ScopeTeamsAdmin))
+	members.PUT("/:userID", teamHandler.UpdateMember, middleware.RequireScopes(models.ScopeTeamsAdmin))
+	members.DELETE("/:userID", teamHandler.RemoveMember, middleware.RequireScopes(models.ScopeTeamsAdmin))
 }
@@ -0,0 +1,35 @@
+package router
+
+import (
+	"ridash/handler/user"
+	"ridash/middleware"
+	"ridash/models"
+	"ridash/utils/keyring"
+	"ridash/utils/revocation"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+)
+
+// UserRouter wires the current user routes
+func UserRouter(api *echo.Group, db *pgxpool.Pool) {
+	userHandler := &user.UserHandler{
+		DB:          db,
+		Keyring:     keyring.Default(),
+		Revocations: revocation.Default(),
+	}
+
+	// Routes without scopes are for sessions only, personal access tokens can't change credentials
+	r := api.Group("/me", middleware.AuthRequiredMiddleware)
+	r.GET("", userHandler.GetMe, middleware.RequireScopes(models.ScopeUserRead))
+	r.PATCH("", userHandler.UpdateMe, middleware.RequireScopes(models.ScopeUserWrite))
+	r.DELETE("", userHandler.DeleteMe)
+	r.PUT("/password", userHandler.ChangePassword)
+	r.GET("/accounts", userHandler.ListAccounts, middleware.RequireScopes(models.ScopeUserRead))
+	r.DELETE("/accounts/:accountID", userHandler.UnlinkAccount)
+	r.GET("/passkeys", userHandler.ListPasskeys, middleware.RequireScopes(models.ScopeUserRead))
+	r.DELETE("/passkeys/:passkeyID", userHandler.DeletePasskey)
+	r.GET("/tokens", userHandler.ListTokens)
+	r.POST("/tokens", userHandler.CreateToken)
+	r.DELETE("/tokens/:tokenID", userHandler.RevokeToken)
+}
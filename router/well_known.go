@@ -0,0 +1,17 @@
+package router
+
+import (
+	"ridash/handler/wellknown"
+	"ridash/utils/keyring"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WellKnownRouter wires the /.well-known routes other services discover Ridash through
+func WellKnownRouter(root *echo.Group) {
+	wellKnownHandler := &wellknown.WellKnownHandler{
+		Keyring: keyring.Default(),
+	}
+
+	root.GET("/jwks.json", wellKnownHandler.JWKS)
+}
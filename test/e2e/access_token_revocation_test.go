@@ -0,0 +1,135 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+	"ridash/utils/config"
+)
+
+func requireMeStatus(t *testing.T, client *apiClient, token string, status int) {
+	t.Helper()
+
+	resp := client.doJSON(t, http.MethodGet, "/api/me", token, nil)
+	require.Equal(t, status, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestAccessTokenRevocation(t *testing.T) {
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	laptop := newAPIClient(t, server.URL)
+	phone := newAPIClient(t, server.URL)
+
+	laptop.Register(t, "revoke@example.com", "password123", "Revoked")
+	phone.Login(t, "revoke@example.com", "password123")
+	laptopToken := laptop.RefreshAccessToken(t)
+	phoneToken := phone.RefreshAccessToken(t)
+
+	// Logging out revokes the access token sent along, not the other sessions
+	resp := laptop.doJSON(t, http.MethodPost, "/api/auth/logout", laptopToken, struct{}{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	requireMeStatus(t, laptop, laptopToken, http.StatusUnauthorized)
+	requireMeStatus(t, phone, phoneToken, http.StatusOK)
+
+	// Logging out everywhere revokes every access token issued so far
+	laptop.Login(t, "revoke@example.com", "password123")
+	laptopToken = laptop.RefreshAccessToken(t)
+
+	resp = phone.doJSON(t, http.MethodPost, "/api/auth/logout-all", phoneToken, struct{}{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	requireMeStatus(t, phone, phoneToken, http.StatusUnauthorized)
+	requireMeStatus(t, laptop, laptopToken, http.StatusUnauthorized)
+
+	// New sessions aren't affected
+	laptop.Login(t, "revoke@example.com", "password123")
+	laptopToken = laptop.RefreshAccessToken(t)
+	requireMeStatus(t, laptop, laptopToken, http.StatusOK)
+
+	// Resetting the password revokes them too
+	resp = laptop.doJSON(t, http.MethodPost, "/api/auth/password/forgot", "", map[string]string{"email": "revoke@example.com"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = laptop.doJSON(t, http.MethodPost, "/api/auth/password/reset", "", map[string]string{
+		"token":    lastEmailToken(t, "revoke@example.com"),
+		"password": "newpassword123",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	requireMeStatus(t, laptop, laptopToken, http.StatusUnauthorized)
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	adminID, adminToken := createOAuthUser(t, pool, "admin@example.com", "Admin")
+	config.Env().AdminUserIDs = []int64{adminID}
+
+	client.Register(t, "disabled@example.com", "password123", "Disabled")
+	client.Login(t, "disabled@example.com", "password123")
+	userToken := client.RefreshAccessToken(t)
+	userID := getUserIDByEmail(t, pool, "disabled@example.com")
+	pat := client.CreatePersonalAccessToken(t, userToken, string(models.ScopeUserRead))
+	disablePath := "/api/admin/users/" + strconv.FormatInt(userID, 10) + "/disable"
+	enablePath := "/api/admin/users/" + strconv.FormatInt(userID, 10) + "/enable"
+
+	// Only admins can disable users, and not through a personal access token
+	resp := client.doJSON(t, http.MethodPost, disablePath, userToken, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, disablePath, pat, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/admin/users/"+strconv.FormatInt(adminID, 10)+"/disable", adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/admin/users/1/disable", adminToken, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, disablePath, adminToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Every way in is closed
+	requireMeStatus(t, client, userToken, http.StatusUnauthorized)
+	requireMeStatus(t, client, pat, http.StatusUnauthorized)
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "disabled@example.com",
+		"password": "password123",
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	// Enabling lets the user sign in again and brings the personal access token back
+	resp = client.doJSON(t, http.MethodPost, enablePath, adminToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	requireMeStatus(t, client, pat, http.StatusOK)
+	client.Login(t, "disabled@example.com", "password123")
+	requireMeStatus(t, client, client.RefreshAccessToken(t), http.StatusOK)
+}
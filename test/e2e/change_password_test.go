@@ -0,0 +1,106 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	laptop := newAPIClient(t, server.URL)
+	phone := newAPIClient(t, server.URL)
+
+	laptop.Register(t, "change-password@example.com", "password123", "Careful User")
+	token := laptop.RefreshAccessToken(t)
+	phone.Login(t, "change-password@example.com", "password123")
+	phoneToken := phone.RefreshAccessToken(t)
+
+	resp := laptop.doJSON(t, http.MethodPut, "/api/me/password", token, map[string]string{
+		"new_password": "newpassword123",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = laptop.doJSON(t, http.MethodPut, "/api/me/password", token, map[string]string{
+		"current_password": "wrongpassword",
+		"new_password":     "newpassword123",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = laptop.doJSON(t, http.MethodPut, "/api/me/password", token, map[string]string{
+		"current_password": "password123",
+		"new_password":     "newpassword123",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Other sessions are signed out, this one stays
+	resp = phone.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = phone.doJSON(t, http.MethodGet, "/api/me", phoneToken, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = laptop.doJSON(t, http.MethodGet, "/api/me", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.NotEmpty(t, laptop.RefreshAccessToken(t))
+
+	resp = phone.doJSON(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "change-password@example.com",
+		"password": "password123",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+	phone.Login(t, "change-password@example.com", "newpassword123")
+}
+
+func TestSetPasswordForOAuthUser(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	_, token := createOAuthUser(t, pool, "oauth-only@example.com", "Google User")
+
+	resp := client.doJSON(t, http.MethodGet, "/api/me", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var me successResponse[meResponse]
+	decodeSuccess(t, resp, &me)
+	require.False(t, me.Data.HasPassword)
+
+	// No current password to check
+	resp = client.doJSON(t, http.MethodPut, "/api/me/password", token, map[string]string{
+		"new_password": "password123",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me/accounts", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var accounts successResponse[[]models.Account]
+	decodeSuccess(t, resp, &accounts)
+	require.Len(t, accounts.Data, 2)
+	require.Equal(t, models.ProviderEmail, accounts.Data[1].Provider)
+	require.Equal(t, "oauth-only@example.com", accounts.Data[1].Email)
+	require.NotNil(t, accounts.Data[1].VerifiedAt)
+
+	client.Login(t, "oauth-only@example.com", "password123")
+
+	// Now that a password exists it must be confirmed
+	resp = client.doJSON(t, http.MethodPut, "/api/me/password", token, map[string]string{
+		"new_password": "otherpassword123",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
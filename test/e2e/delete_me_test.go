@@ -0,0 +1,148 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestDeleteMe(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	leaver := newAPIClient(t, server.URL)
+	heir := newAPIClient(t, server.URL)
+
+	leaver.Register(t, "leaver@example.com", "password123", "Leaver")
+	leaver.Login(t, "leaver@example.com", "password123")
+	leaverToken := leaver.RefreshAccessToken(t)
+	leaverID := getUserIDByEmail(t, pool, "leaver@example.com")
+	pat := leaver.CreatePersonalAccessToken(t, leaverToken, string(models.ScopeUserRead), string(models.ScopeTeamsAdmin))
+
+	heir.Register(t, "heir@example.com", "password123", "Heir")
+	heir.Login(t, "heir@example.com", "password123")
+	heirToken := heir.RefreshAccessToken(t)
+	heirID := getUserIDByEmail(t, pool, "heir@example.com")
+
+	team := leaver.CreateTeam(t, leaverToken, "Handed Over")
+	transferPath := "/api/teams/" + strconv.FormatInt(team.ID, 10) + "/transfer"
+
+	// Owned teams have to be handed over first, and personal access tokens can't delete the user
+	resp := leaver.doJSON(t, http.MethodDelete, "/api/me", leaverToken, nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = leaver.doJSON(t, http.MethodDelete, "/api/me", pat, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// Only members can take a team over, and only the owner can hand it over
+	resp = leaver.doJSON(t, http.MethodPost, transferPath, leaverToken, map[string]string{"user_id": strconv.FormatInt(heirID, 10)})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	leaver.AddTeamMember(t, leaverToken, team.ID, heirID, models.RoleAdmin)
+
+	resp = heir.doJSON(t, http.MethodPost, transferPath, heirToken, map[string]string{"user_id": strconv.FormatInt(heirID, 10)})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = leaver.doJSON(t, http.MethodPost, transferPath, leaverToken, map[string]string{"user_id": strconv.FormatInt(heirID, 10)})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var transferred successResponse[models.Team]
+	decodeSuccess(t, resp, &transferred)
+	require.Equal(t, heirID, transferred.Data.OwnerID)
+
+	roles := map[int64]models.Role{}
+	for _, member := range heir.ListTeamMembers(t, heirToken, team.ID) {
+		roles[member.UserID] = member.Role
+	}
+	require.Equal(t, models.RoleOwner, roles[heirID])
+	require.Equal(t, models.RoleAdmin, roles[leaverID])
+
+	resp = leaver.doJSON(t, http.MethodDelete, "/api/me", leaverToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Every way in is gone
+	requireMeStatus(t, leaver, leaverToken, http.StatusUnauthorized)
+	requireMeStatus(t, leaver, pat, http.StatusUnauthorized)
+	require.Equal(t, http.StatusBadRequest, leaver.loginStatus(t, "leaver@example.com", "password123").StatusCode)
+
+	resp = leaver.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	for _, table := range []string{"accounts", "refresh_tokens", "team_members", "personal_access_tokens", "docs_shares"} {
+		var rows int
+		require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM `+table+` WHERE user_id = $1`, leaverID).Scan(&rows))
+		require.Zero(t, rows, table)
+	}
+
+	// The user record is kept, anonymized
+	var displayName string
+	var passwordHash *string
+	var deletedAt *time.Time
+	require.NoError(t, pool.QueryRow(ctx, `SELECT display_name, password_hash, deleted_at FROM users WHERE id = $1`, leaverID).Scan(&displayName, &passwordHash, &deletedAt))
+	require.Equal(t, models.DeletedUserDisplayName, displayName)
+	require.Nil(t, passwordHash)
+	require.NotNil(t, deletedAt)
+
+	// The team carries on with its new owner
+	require.Len(t, heir.ListTeamMembers(t, heirToken, team.ID), 1)
+	require.Equal(t, heirID, heir.GetTeam(t, heirToken, team.ID).OwnerID)
+
+	// The address is free to sign up again as a new user
+	leaver.Register(t, "leaver@example.com", "password123", "Leaver again")
+	require.NotEqual(t, leaverID, getUserIDByEmail(t, pool, "leaver@example.com"))
+}
+
+func TestTransferTeamConcurrently(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	owner := newAPIClient(t, server.URL)
+	owner.Register(t, "owner@example.com", "password123", "Owner")
+	owner.Login(t, "owner@example.com", "password123")
+	ownerToken := owner.RefreshAccessToken(t)
+
+	team := owner.CreateTeam(t, ownerToken, "Contested")
+	transferPath := "/api/teams/" + strconv.FormatInt(team.ID, 10) + "/transfer"
+
+	var heirIDs []int64
+	for _, email := range []string{"first-heir@example.com", "second-heir@example.com"} {
+		heir := newAPIClient(t, server.URL)
+		heir.Register(t, email, "password123", "Heir")
+		heirID := getUserIDByEmail(t, pool, email)
+		owner.AddTeamMember(t, ownerToken, team.ID, heirID, models.RoleAdmin)
+		heirIDs = append(heirIDs, heirID)
+	}
+
+	// The owner hands the team to two members at once, only one of them gets it
+	statuses := make(chan int, len(heirIDs))
+	for _, heirID := range heirIDs {
+		go func(heirID int64) {
+			resp := owner.doJSON(t, http.MethodPost, transferPath, ownerToken, map[string]string{"user_id": strconv.FormatInt(heirID, 10)})
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}(heirID)
+	}
+
+	succeeded := 0
+	for range heirIDs {
+		if <-statuses == http.StatusOK {
+			succeeded++
+		}
+	}
+	require.Equal(t, 1, succeeded)
+
+	var owners int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM team_members WHERE team_id = $1 AND role = $2`, team.ID, models.RoleOwner).Scan(&owners))
+	require.Equal(t, 1, owners)
+}
@@ -0,0 +1,95 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestEmailVerificationRequiredToLogin(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_REQUIRED", "login")
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	client.Register(t, "verify-login@example.com", "password123", "Verifier")
+
+	// Registration doesn't start a session until the address is verified
+	resp := client.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "verify-login@example.com",
+		"password": "password123",
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/verify-email", "", map[string]string{"token": "not-a-token"})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Resending never reveals whether the address exists
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/verify-email/resend", "", map[string]string{"email": "nobody@example.com"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.Empty(t, deliverEmails(t, "nobody@example.com"))
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/verify-email/resend", "", map[string]string{"email": "verify-login@example.com"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.Len(t, deliverEmails(t, "verify-login@example.com"), 2)
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/verify-email", "", map[string]string{
+		"token": lastEmailToken(t, "verify-login@example.com"),
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	client.Login(t, "verify-login@example.com", "password123")
+	require.NotEmpty(t, client.RefreshAccessToken(t))
+}
+
+func TestEmailVerificationRequiredToShare(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_REQUIRED", "share")
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	owner := newAPIClient(t, server.URL)
+	reader := newAPIClient(t, server.URL)
+
+	owner.Register(t, "verify-share@example.com", "password123", "Sharer")
+	owner.Login(t, "verify-share@example.com", "password123")
+	ownerToken := owner.RefreshAccessToken(t)
+
+	reader.Register(t, "verify-reader@example.com", "password123", "Reader")
+	readerID := getUserIDByEmail(t, pool, "verify-reader@example.com")
+
+	team := owner.CreateTeam(t, ownerToken, "Verify Team")
+	folder := owner.CreateFolder(t, ownerToken, team.ID, "Docs", nil)
+	doc := owner.CreateDocument(t, ownerToken, folder.ID, "Spec", models.DocsPermissionPrivate)
+
+	shareBody := map[string]any{
+		"user_id": strconv.FormatInt(readerID, 10),
+		"roles":   string(models.DocsSharePermissionRead),
+	}
+	resp := owner.doJSON(t, http.MethodPost, "/api/documents/"+strconv.FormatInt(doc.ID, 10)+"/shares", ownerToken, shareBody)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = owner.doJSON(t, http.MethodPost, "/api/auth/verify-email", "", map[string]string{
+		"token": lastEmailToken(t, "verify-share@example.com"),
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	share := owner.CreateShare(t, ownerToken, doc.ID, readerID, models.DocsSharePermissionRead)
+	require.Equal(t, readerID, share.UserID)
+}
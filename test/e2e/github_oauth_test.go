@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+// startGitHubStub serves the GitHub OAuth token endpoint and the user API
+func startGitHubStub(t *testing.T, emails []map[string]any) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "github-code" {
+			writeJSON(t, w, http.StatusBadRequest, map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		writeJSON(t, w, http.StatusOK, map[string]string{
+			"access_token": "github-access-token",
+			"token_type":   "bearer",
+			"scope":        "read:user,user:email",
+		})
+	})
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer github-access-token", r.Header.Get("Authorization"))
+		writeJSON(t, w, http.StatusOK, map[string]any{
+			"id":         4242,
+			"login":      "octocat",
+			"name":       "",
+			"avatar_url": "https://avatars.example.com/octocat.png",
+		})
+	})
+	mux.HandleFunc("/api/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer github-access-token", r.Header.Get("Authorization"))
+		writeJSON(t, w, http.StatusOK, emails)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// signInWithGitHub runs the OAuth entry and callback against the stub and returns the callback response
+func signInWithGitHub(t *testing.T, client *apiClient) *http.Response {
+	t.Helper()
+
+	client.client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	defer func() { client.client.CheckRedirect = nil }()
+
+	resp := client.doJSON(t, http.MethodGet, "/api/auth/oauth/github", "", nil)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	state := location.Query().Get("state")
+	require.NotEmpty(t, state)
+
+	return client.doJSON(t, http.MethodGet, "/api/auth/oauth/github/callback?code=github-code&state="+url.QueryEscape(state), "", nil)
+}
+
+func setGitHubEnv(t *testing.T, stub *httptest.Server) {
+	t.Helper()
+
+	t.Setenv("GITHUB_CLIENT_ID", "github-client")
+	t.Setenv("GITHUB_CLIENT_SECRET", "github-secret")
+	t.Setenv("GITHUB_REDIRECT_URL", "http://localhost/api/auth/oauth/github/callback")
+	t.Setenv("GITHUB_AUTH_URL", stub.URL+"/login/oauth/authorize")
+	t.Setenv("GITHUB_TOKEN_URL", stub.URL+"/login/oauth/access_token")
+	t.Setenv("GITHUB_API_URL", stub.URL+"/api")
+}
+
+func TestGitHubOAuthLogin(t *testing.T) {
+	ctx := context.Background()
+
+	stub := startGitHubStub(t, []map[string]any{
+		{"email": "octocat-old@example.com", "primary": false, "verified": true},
+		{"email": "octocat@example.com", "primary": true, "verified": true},
+	})
+	setGitHubEnv(t, stub)
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	resp := signInWithGitHub(t, client)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	token := client.RefreshAccessToken(t)
+	resp = client.doJSON(t, http.MethodGet, "/api/me", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var me successResponse[meResponse]
+	decodeSuccess(t, resp, &me)
+	require.Equal(t, "octocat", me.Data.DisplayName)
+	require.False(t, me.Data.HasPassword)
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me/accounts", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var accounts successResponse[[]models.Account]
+	decodeSuccess(t, resp, &accounts)
+	require.Len(t, accounts.Data, 1)
+	require.Equal(t, models.ProviderGitHub, accounts.Data[0].Provider)
+	require.Equal(t, "4242", accounts.Data[0].ProviderUserID)
+	require.Equal(t, "octocat@example.com", accounts.Data[0].Email)
+	require.NotNil(t, accounts.Data[0].VerifiedAt)
+
+	// Signing in again finds the same user
+	second := newAPIClient(t, server.URL)
+	resp = signInWithGitHub(t, second)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	secondToken := second.RefreshAccessToken(t)
+	resp = second.doJSON(t, http.MethodGet, "/api/me", secondToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var secondMe successResponse[meResponse]
+	decodeSuccess(t, resp, &secondMe)
+	require.Equal(t, me.Data.ID, secondMe.Data.ID)
+}
+
+func TestGitHubOAuthRequiresVerifiedPrimaryEmail(t *testing.T) {
+	ctx := context.Background()
+
+	stub := startGitHubStub(t, []map[string]any{
+		{"email": "unverified@example.com", "primary": true, "verified": false},
+	})
+	setGitHubEnv(t, stub)
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	resp := signInWithGitHub(t, client)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -31,7 +32,11 @@ import (
 	"ridash/utils/config"
 	"ridash/utils/docmanager"
 	"ridash/utils/id"
+	"ridash/utils/keyring"
 	"ridash/utils/logger"
+	"ridash/utils/loginguard"
+	"ridash/utils/mailer"
+	"ridash/utils/revocation"
 )
 
 type docManagerStub struct {
@@ -169,13 +174,14 @@ func setTestEnv(t *testing.T, ctx context.Context, pg *postgres.PostgresContaine
 		"SMTP_USERNAME":            "user",
 		"SMTP_PASSWORD":            "pass",
 		"SMTP_FROM":                "noreply@example.com",
+		"MAIL_TRANSPORT":           "memory",
 		"GOOGLE_CLIENT_ID":         "test-client-id",
 		"GOOGLE_CLIENT_SECRET":     "test-client-secret",
 		"GOOGLE_REDIRECT_URL":      "http://localhost/callback",
 		"OAUTH_STATE_EXPIRES_AT":   "600",
 		"ACCESS_TOKEN_EXPIRES_AT":  "900",
 		"REFRESH_TOKEN_EXPIRES_AT": strconv.Itoa(int(time.Hour.Seconds())),
-		"JWT_SECRET_KEY":           "test-secret-key",
+		"JWT_SECRET_KEY":           "test-secret-key-long-enough-for-hmac",
 		"FRONTEND_DOMAIN":          "127.0.0.1",
 		"DOC_MANAGER_BASE_URL":     docStub.URL,
 		"DOC_MANAGER_API_TOKEN":    "stub-token",
@@ -193,11 +199,17 @@ func startAppServer(t *testing.T, pool *pgxpool.Pool) *httptest.Server {
 	e.Use(customMiddleware.ZapLogger(zap.L()))
 	e.Use(echomw.Recover())
 
+	customMiddleware.UsePersonalAccessTokens(pool)
+	router.WellKnownRouter(e.Group("/.well-known"))
+
 	api := e.Group("/api")
 	router.AuthRouter(api, pool)
 	router.TeamRouter(api, pool)
 	router.FolderRouter(api, pool)
 	router.DocumentRouter(api, pool)
+	router.InvitationRouter(api, pool)
+	router.UserRouter(api, pool)
+	router.AdminRouter(api, pool)
 
 	server := httptest.NewServer(e)
 	t.Cleanup(server.Close)
@@ -351,6 +363,52 @@ func (c *apiClient) DeleteTeam(t *testing.T, token string, teamID int64) {
 	decodeSuccess(t, resp, &successResponse[struct{}]{})
 }
 
+func (c *apiClient) ListTeamMembers(t *testing.T, token string, teamID int64) []models.TeamMember {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodGet, "/api/teams/"+strconv.FormatInt(teamID, 10)+"/members", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parsed successResponse[[]models.TeamMember]
+	decodeSuccess(t, resp, &parsed)
+	return parsed.Data
+}
+
+func (c *apiClient) AddTeamMember(t *testing.T, token string, teamID, userID int64, role models.Role) models.TeamMember {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPost, "/api/teams/"+strconv.FormatInt(teamID, 10)+"/members", token, map[string]any{
+		"user_id": strconv.FormatInt(userID, 10),
+		"role":    string(role),
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parsed successResponse[models.TeamMember]
+	decodeSuccess(t, resp, &parsed)
+	return parsed.Data
+}
+
+func (c *apiClient) UpdateTeamMember(t *testing.T, token string, teamID, userID int64, role models.Role) models.TeamMember {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPut, "/api/teams/"+strconv.FormatInt(teamID, 10)+"/members/"+strconv.FormatInt(userID, 10), token, map[string]any{
+		"role": string(role),
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parsed successResponse[models.TeamMember]
+	decodeSuccess(t, resp, &parsed)
+	return parsed.Data
+}
+
+func (c *apiClient) RemoveTeamMember(t *testing.T, token string, teamID, userID int64) {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodDelete, "/api/teams/"+strconv.FormatInt(teamID, 10)+"/members/"+strconv.FormatInt(userID, 10), token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	decodeSuccess(t, resp, &successResponse[struct{}]{})
+}
+
 func (c *apiClient) CreateFolder(t *testing.T, token string, teamID int64, name string, parentFolder *int64) models.Folder {
 	t.Helper()
 
@@ -522,6 +580,33 @@ func (c *apiClient) DeleteShare(t *testing.T, token string, documentID, shareID
 	decodeSuccess(t, resp, &successResponse[struct{}]{})
 }
 
+func (c *apiClient) CreateInvitation(t *testing.T, token string, teamID int64, email string, role models.Role) models.Invitation {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPost, "/api/teams/"+strconv.FormatInt(teamID, 10)+"/invitations", token, map[string]string{
+		"email": email,
+		"role":  string(role),
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parsed successResponse[models.Invitation]
+	decodeSuccess(t, resp, &parsed)
+	return parsed.Data
+}
+
+func (c *apiClient) AcceptInvitation(t *testing.T, token, invitationToken string) models.TeamMember {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPost, "/api/invitations/accept", token, map[string]string{
+		"token": invitationToken,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parsed successResponse[models.TeamMember]
+	decodeSuccess(t, resp, &parsed)
+	return parsed.Data
+}
+
 func initApp(t *testing.T, ctx context.Context) (*pgxpool.Pool, *httptest.Server, *docManagerStub) {
 	t.Helper()
 
@@ -542,6 +627,19 @@ func initApp(t *testing.T, ctx context.Context) (*pgxpool.Pool, *httptest.Server
 	require.NoError(t, err)
 	t.Cleanup(pool.Close)
 
+	_, err = mailer.Init(pool)
+	require.NoError(t, err)
+
+	_, err = keyring.Init(pool)
+	require.NoError(t, err)
+
+	revocations, err := revocation.Init(pool)
+	require.NoError(t, err)
+	runCtx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	go revocations.Run(runCtx)
+	loginguard.Init(pool)
+
 	server := startAppServer(t, pool)
 	return pool, server, docStub
 }
@@ -563,6 +661,70 @@ func getUserIDByEmail(t *testing.T, pool *pgxpool.Pool, email string) int64 {
 	return user.ID
 }
 
+// createOAuthUser inserts a user that signed up through Google and has no password, and returns an access token for it
+func createOAuthUser(t *testing.T, pool *pgxpool.Pool, email, displayName string) (int64, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	userID, err := id.GetID()
+	require.NoError(t, err)
+	accountID, err := id.GetID()
+	require.NoError(t, err)
+
+	now := time.Now()
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	require.NoError(t, repository.CreateUserAndAccount(ctx, tx, models.User{
+		ID:          userID,
+		DisplayName: displayName,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, models.Account{
+		ID:             accountID,
+		Provider:       models.ProviderGoogle,
+		ProviderUserID: "google-" + strconv.FormatInt(userID, 10),
+		UserID:         userID,
+		Email:          email,
+		VerifiedAt:     &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}))
+	require.NoError(t, tx.Commit(ctx))
+
+	token, err := keyring.Default().GenerateAccessToken(config.Env().AppName, strconv.FormatInt(userID, 10), now.Add(time.Hour))
+	require.NoError(t, err)
+
+	return userID, token
+}
+
+// deliverEmails drains the outbox into the in-memory transport and returns the messages sent to address
+func deliverEmails(t *testing.T, address string) []mailer.Message {
+	t.Helper()
+
+	_, err := mailer.Default().ProcessOutbox(context.Background())
+	require.NoError(t, err)
+
+	transport, ok := mailer.Default().Transport().(*mailer.MemoryTransport)
+	require.True(t, ok, "e2e tests must use the memory mail transport")
+	return transport.MessagesTo(address)
+}
+
+var emailTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9._-]+)`)
+
+// lastEmailToken returns the token in the link of the latest email sent to address
+func lastEmailToken(t *testing.T, address string) string {
+	t.Helper()
+
+	messages := deliverEmails(t, address)
+	require.NotEmpty(t, messages, "no email sent to %s", address)
+
+	match := emailTokenPattern.FindStringSubmatch(messages[len(messages)-1].Text)
+	require.Len(t, match, 2, "no token link in email to %s", address)
+	return match[1]
+}
+
 func waitForDocEdit(t *testing.T, stub *docManagerStub) {
 	t.Helper()
 
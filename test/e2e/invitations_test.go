@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestTeamInvitations(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	owner := newAPIClient(t, server.URL)
+	existing := newAPIClient(t, server.URL)
+	newcomer := newAPIClient(t, server.URL)
+	decliner := newAPIClient(t, server.URL)
+	bystander := newAPIClient(t, server.URL)
+
+	owner.Register(t, "invite-owner@example.com", "password123", "Owner")
+	owner.Login(t, "invite-owner@example.com", "password123")
+	ownerToken := owner.RefreshAccessToken(t)
+
+	existing.Register(t, "invite-existing@example.com", "password123", "Existing")
+	existing.Login(t, "invite-existing@example.com", "password123")
+	existingToken := existing.RefreshAccessToken(t)
+
+	team := owner.CreateTeam(t, ownerToken, "Invite Team")
+	teamPath := "/api/teams/" + strconv.FormatInt(team.ID, 10)
+
+	// An existing user accepts with the emailed token
+	invitation := owner.CreateInvitation(t, ownerToken, team.ID, "invite-existing@example.com", models.RoleAdmin)
+	require.Equal(t, models.InvitationStatusPending, invitation.Status)
+
+	// The verification email came first
+	messages := deliverEmails(t, "invite-existing@example.com")
+	require.Len(t, messages, 2)
+	require.Contains(t, messages[1].Subject, "Invite Team")
+	require.Contains(t, messages[1].HTML, "Invite Team")
+
+	existingInviteToken := lastEmailToken(t, "invite-existing@example.com")
+
+	// Holding the token isn't enough, it must have been sent to a verified address of the user
+	bystander.Register(t, "invite-bystander@example.com", "password123", "Bystander")
+	resp := bystander.doJSON(t, http.MethodPost, "/api/auth/verify-email", "", map[string]string{
+		"token": lastEmailToken(t, "invite-bystander@example.com"),
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	bystander.Login(t, "invite-bystander@example.com", "password123")
+	bystanderToken := bystander.RefreshAccessToken(t)
+
+	resp = bystander.doJSON(t, http.MethodPost, "/api/invitations/accept", bystanderToken, map[string]string{"token": existingInviteToken})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = existing.doJSON(t, http.MethodPost, "/api/invitations/accept", existingToken, map[string]string{"token": existingInviteToken})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "unverified address")
+	resp.Body.Close()
+
+	verificationToken := emailTokenPattern.FindStringSubmatch(messages[0].Text)
+	require.Len(t, verificationToken, 2)
+	resp = existing.doJSON(t, http.MethodPost, "/api/auth/verify-email", "", map[string]string{
+		"token": verificationToken[1],
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	member := existing.AcceptInvitation(t, existingToken, existingInviteToken)
+	require.Equal(t, models.RoleAdmin, member.Role)
+	require.Equal(t, getUserIDByEmail(t, pool, "invite-existing@example.com"), member.UserID)
+
+	// Tokens are single use
+	resp = existing.doJSON(t, http.MethodPost, "/api/invitations/accept", existingToken, map[string]string{"token": existingInviteToken})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Members cannot be invited again
+	resp = owner.doJSON(t, http.MethodPost, teamPath+"/invitations", ownerToken, map[string]string{
+		"email": "invite-existing@example.com",
+		"role":  string(models.RoleMember),
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Someone without an account joins while registering
+	owner.CreateInvitation(t, ownerToken, team.ID, "invite-newcomer@example.com", models.RoleMember)
+
+	resp = owner.doJSON(t, http.MethodPost, teamPath+"/invitations", ownerToken, map[string]string{
+		"email": "invite-newcomer@example.com",
+		"role":  string(models.RoleMember),
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "duplicate pending invitation")
+	resp.Body.Close()
+
+	newcomerInviteToken := lastEmailToken(t, "invite-newcomer@example.com")
+
+	// The token doesn't open the signup to another address
+	resp = newcomer.doJSON(t, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":            "invite-impostor@example.com",
+		"password":         "password123",
+		"display_name":     "Impostor",
+		"invitation_token": newcomerInviteToken,
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = newcomer.doJSON(t, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":            "invite-newcomer@example.com",
+		"password":         "password123",
+		"display_name":     "Newcomer",
+		"invitation_token": newcomerInviteToken,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	newcomer.Login(t, "invite-newcomer@example.com", "password123")
+	newcomerToken := newcomer.RefreshAccessToken(t)
+	require.Len(t, newcomer.ListTeamMembers(t, newcomerToken, team.ID), 3)
+
+	// Declining does not need an account and the token cannot be used afterwards
+	owner.CreateInvitation(t, ownerToken, team.ID, "invite-decliner@example.com", models.RoleMember)
+	declineToken := lastEmailToken(t, "invite-decliner@example.com")
+
+	resp = decliner.doJSON(t, http.MethodPost, "/api/invitations/decline", "", map[string]string{"token": declineToken})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = newcomer.doJSON(t, http.MethodPost, "/api/invitations/accept", newcomerToken, map[string]string{"token": declineToken})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Plain members cannot invite
+	resp = newcomer.doJSON(t, http.MethodPost, teamPath+"/invitations", newcomerToken, map[string]string{
+		"email": "someone@example.com",
+		"role":  string(models.RoleMember),
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
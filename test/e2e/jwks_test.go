@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"ridash/utils/config"
+	"ridash/utils/keyring"
+)
+
+type jwksResponse struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func fetchJWKS(t *testing.T, baseURL string) jwksResponse {
+	t.Helper()
+
+	resp, err := http.Get(baseURL + "/.well-known/jwks.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var jwks jwksResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jwks))
+	return jwks
+}
+
+func tokenKid(t *testing.T, token string) string {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestJWKSRotation(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	userID, token := createOAuthUser(t, pool, "jwks@example.com", "JWKS User")
+
+	jwks := fetchJWKS(t, server.URL)
+	require.Len(t, jwks.Keys, 1)
+	key := jwks.Keys[0]
+	require.Equal(t, "OKP", key.Kty)
+	require.Equal(t, "EdDSA", key.Alg)
+	require.Equal(t, key.Kid, tokenKid(t, token))
+
+	// Another service verifies the token with the published key alone
+	x, err := base64.RawURLEncoding.DecodeString(key.X)
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return ed25519.PublicKey(x), nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	require.NoError(t, err)
+	require.Equal(t, strconv.FormatInt(userID, 10), claims["sub"])
+
+	// A token signed with the HMAC secret is refused
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": config.Env().AppName,
+		"sub": strconv.FormatInt(userID, 10),
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}).SignedString([]byte(config.Env().JWTSecretKey))
+	require.NoError(t, err)
+	resp := client.doJSON(t, http.MethodGet, "/api/me", forged, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	shift := func(by time.Duration) {
+		_, err := pool.Exec(ctx, `UPDATE signing_keys SET activates_at = activates_at - $1::interval, expires_at = expires_at - $1::interval, created_at = created_at - $1::interval`,
+			strconv.Itoa(int(by.Seconds()))+" seconds")
+		require.NoError(t, err)
+		require.NoError(t, keyring.Default().Rotate(ctx))
+	}
+
+	// Close to the rotation the next key is published but doesn't sign yet
+	rotation := time.Duration(config.Env().JWTKeyRotationInterval) * time.Second
+	shift(rotation - 12*time.Hour)
+	jwks = fetchJWKS(t, server.URL)
+	require.Len(t, jwks.Keys, 2)
+	_, stillOld := createOAuthUser(t, pool, "jwks-old@example.com", "Old Key")
+	require.Equal(t, key.Kid, tokenKid(t, stillOld))
+
+	// Once it activates new tokens use it, and tokens from the old key keep working
+	shift(12*time.Hour + time.Minute)
+	_, rotated := createOAuthUser(t, pool, "jwks-new@example.com", "New Key")
+	require.NotEqual(t, key.Kid, tokenKid(t, rotated))
+
+	for _, accessToken := range []string{token, rotated} {
+		resp = client.doJSON(t, http.MethodGet, "/api/me", accessToken, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	// The old key is dropped once its last token expired
+	shift(rotation)
+	jwks = fetchJWKS(t, server.URL)
+	for _, published := range jwks.Keys {
+		require.NotEqual(t, key.Kid, published.Kid)
+	}
+}
+
+func TestJWKSRS256(t *testing.T) {
+	t.Setenv("JWT_SIGNING_ALGORITHM", "RS256")
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	_, token := createOAuthUser(t, pool, "rsa@example.com", "RSA User")
+
+	jwks := fetchJWKS(t, server.URL)
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "RSA", jwks.Keys[0].Kty)
+	require.Equal(t, "RS256", jwks.Keys[0].Alg)
+	require.NotEmpty(t, jwks.Keys[0].N)
+	require.Equal(t, "AQAB", jwks.Keys[0].E)
+
+	resp := client.doJSON(t, http.MethodGet, "/api/me", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/utils/config"
+)
+
+func (c *apiClient) loginStatus(t *testing.T, email, password string) *http.Response {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	resp.Body.Close()
+	return resp
+}
+
+func TestLoginThrottling(t *testing.T) {
+	t.Setenv("LOGIN_FREE_FAILURES", "1")
+	t.Setenv("LOGIN_BASE_DELAY", "1")
+	t.Setenv("LOGIN_MAX_DELAY", "1")
+	t.Setenv("LOGIN_LOCKOUT_FAILURES", "3")
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+	client.Register(t, "guessed@example.com", "password123", "Guessed")
+	deliverEmails(t, "guessed@example.com")
+
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "guessed@example.com", "wrong-password").StatusCode)
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "guessed@example.com", "wrong-password").StatusCode)
+
+	// The next attempt has to wait, even with the right password
+	resp := client.loginStatus(t, "guessed@example.com", "password123")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	// Another failure once the delay is over locks the email
+	time.Sleep(1100 * time.Millisecond)
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "guessed@example.com", "wrong-password").StatusCode)
+
+	resp = client.loginStatus(t, "guessed@example.com", "password123")
+	require.Equal(t, http.StatusLocked, resp.StatusCode)
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	require.Greater(t, retryAfter, 800)
+
+	// The unlock link is emailed and can be used once
+	unlockToken := lastEmailToken(t, "guessed@example.com")
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/login/unlock", "", map[string]string{"token": unlockToken})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/login/unlock", "", map[string]string{"token": unlockToken})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	client.Login(t, "guessed@example.com", "password123")
+
+	// Unknown emails are locked the same way but nothing is sent
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "nobody@example.com", "wrong-password").StatusCode)
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "nobody@example.com", "wrong-password").StatusCode)
+	time.Sleep(1100 * time.Millisecond)
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "nobody@example.com", "wrong-password").StatusCode)
+	require.Equal(t, http.StatusLocked, client.loginStatus(t, "nobody@example.com", "wrong-password").StatusCode)
+	require.Empty(t, deliverEmails(t, "nobody@example.com"))
+}
+
+func TestLoginLockoutAdminUnlock(t *testing.T) {
+	t.Setenv("LOGIN_FREE_FAILURES", "1")
+	t.Setenv("LOGIN_LOCKOUT_FAILURES", "2")
+	t.Setenv("LOGIN_IP_LOCKOUT_FAILURES", "4")
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	adminID, adminToken := createOAuthUser(t, pool, "admin@example.com", "Admin")
+	config.Env().AdminUserIDs = []int64{adminID}
+
+	client.Register(t, "locked@example.com", "password123", "Locked")
+	userID := getUserIDByEmail(t, pool, "locked@example.com")
+
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "locked@example.com", "wrong-password").StatusCode)
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "locked@example.com", "wrong-password").StatusCode)
+	require.Equal(t, http.StatusLocked, client.loginStatus(t, "locked@example.com", "password123").StatusCode)
+
+	var events int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM security_events WHERE user_id = $1 AND type = 'account_locked'`, userID).Scan(&events))
+	require.Equal(t, 1, events)
+
+	resp := client.doJSON(t, http.MethodPost, "/api/admin/users/"+strconv.FormatInt(userID, 10)+"/unlock", adminToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	client.Login(t, "locked@example.com", "password123")
+
+	// Failures across emails lock the IP address, a successful login doesn't reset it
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "first@example.com", "wrong-password").StatusCode)
+	require.Equal(t, http.StatusBadRequest, client.loginStatus(t, "second@example.com", "wrong-password").StatusCode)
+
+	resp = client.loginStatus(t, "locked@example.com", "password123")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
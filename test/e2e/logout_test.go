@@ -0,0 +1,52 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	laptop := newAPIClient(t, server.URL)
+	phone := newAPIClient(t, server.URL)
+	tablet := newAPIClient(t, server.URL)
+
+	laptop.Register(t, "logout@example.com", "password123", "Leaver")
+	phone.Login(t, "logout@example.com", "password123")
+	tablet.Login(t, "logout@example.com", "password123")
+
+	// Logging out only ends the current session
+	resp := laptop.doJSON(t, http.MethodPost, "/api/auth/logout", "", struct{}{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = laptop.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	phoneToken := phone.RefreshAccessToken(t)
+
+	// Logging out without a session is harmless
+	resp = laptop.doJSON(t, http.MethodPost, "/api/auth/logout", "", struct{}{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = phone.doJSON(t, http.MethodPost, "/api/auth/logout-all", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = phone.doJSON(t, http.MethodPost, "/api/auth/logout-all", phoneToken, struct{}{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	for _, client := range []*apiClient{phone, tablet} {
+		resp = client.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp.Body.Close()
+	}
+}
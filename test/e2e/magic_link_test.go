@@ -0,0 +1,132 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMagicLinkLogin(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	requestLink := func(c *apiClient, email string) string {
+		resp := c.doJSON(t, http.MethodPost, "/api/auth/magic-link", "", map[string]string{"email": email})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+		return lastEmailToken(t, email)
+	}
+
+	consumeLink := func(c *apiClient, token string) *http.Response {
+		return c.doJSON(t, http.MethodPost, "/api/auth/magic-link/consume", "", map[string]string{"token": token})
+	}
+
+	// The first link creates a passwordless user
+	token := requestLink(client, "guest@example.com")
+	resp := consumeLink(client, token)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var login successResponse[map[string]string]
+	decodeSuccess(t, resp, &login)
+	require.NotEmpty(t, login.Data["access_token"])
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me", login.Data["access_token"], nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var me successResponse[meResponse]
+	decodeSuccess(t, resp, &me)
+	require.False(t, me.Data.HasPassword)
+	guestID := me.Data.ID
+
+	// The refresh cookie is set like a password login
+	require.NotEmpty(t, client.RefreshAccessToken(t))
+
+	// Links are single use
+	resp = consumeLink(newAPIClient(t, server.URL), token)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// The next link logs the same user in
+	browser := newAPIClient(t, server.URL)
+	resp = consumeLink(browser, requestLink(browser, "guest@example.com"))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.Equal(t, guestID, getUserIDByEmail(t, pool, "guest@example.com"))
+
+	var accounts int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM accounts WHERE email = $1`, "guest@example.com").Scan(&accounts))
+	require.Equal(t, 1, accounts)
+
+	// Registered users can use links too, which verifies their address
+	registered := newAPIClient(t, server.URL)
+	registered.Register(t, "member@example.com", "password123", "Member")
+	deliverEmails(t, "member@example.com")
+	resp = consumeLink(registered, requestLink(registered, "member@example.com"))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	var verified bool
+	require.NoError(t, pool.QueryRow(ctx, `SELECT verified_at IS NOT NULL FROM accounts WHERE email = $1`, "member@example.com").Scan(&verified))
+	require.True(t, verified)
+
+	resp = consumeLink(client, "not-a-token")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestMagicLinkSelfSignupDisabled(t *testing.T) {
+	t.Setenv("SELF_SIGNUP_ENABLED", "false")
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	// Unknown addresses get the same answer and no email
+	resp := client.doJSON(t, http.MethodPost, "/api/auth/magic-link", "", map[string]string{"email": "stranger@example.com"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.Empty(t, deliverEmails(t, "stranger@example.com"))
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":        "stranger@example.com",
+		"password":     "password123",
+		"display_name": "Stranger",
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestMagicLinkThrottling(t *testing.T) {
+	t.Setenv("MAGIC_LINK_EMAIL_LIMIT", "2")
+	t.Setenv("MAGIC_LINK_IP_LIMIT", "3")
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	requestLink := func(email string) *http.Response {
+		return client.doJSON(t, http.MethodPost, "/api/auth/magic-link", "", map[string]string{"email": email})
+	}
+
+	// Past the limit of the address the answer doesn't change, but nothing more is sent
+	for i := 0; i < 3; i++ {
+		resp := requestLink("flooded@example.com")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+	require.Len(t, deliverEmails(t, "flooded@example.com"), 2)
+
+	resp := requestLink("other@example.com")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.Len(t, deliverEmails(t, "other@example.com"), 1)
+
+	// The IP address has used up its links
+	resp = requestLink("third@example.com")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+	resp.Body.Close()
+	require.Empty(t, deliverEmails(t, "third@example.com"))
+}
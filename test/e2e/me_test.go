@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+type meResponse struct {
+	models.User
+	HasPassword bool `json:"has_password"`
+}
+
+func TestMe(t *testing.T) {
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	client.Register(t, "me@example.com", "password123", "Profile Owner")
+	token := client.RefreshAccessToken(t)
+
+	resp := client.doJSON(t, http.MethodGet, "/api/me", "", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var me successResponse[meResponse]
+	decodeSuccess(t, resp, &me)
+	require.Equal(t, "Profile Owner", me.Data.DisplayName)
+	require.Nil(t, me.Data.PasswordHash)
+	require.Nil(t, me.Data.Avatar)
+	require.True(t, me.Data.HasPassword)
+
+	resp = client.doJSON(t, http.MethodPatch, "/api/me", token, map[string]any{
+		"display_name": "Renamed Owner",
+		"avatar":       "https://example.com/avatar.png",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	me = successResponse[meResponse]{}
+	decodeSuccess(t, resp, &me)
+	require.Equal(t, "Renamed Owner", me.Data.DisplayName)
+	require.NotNil(t, me.Data.Avatar)
+	require.Equal(t, "https://example.com/avatar.png", *me.Data.Avatar)
+
+	// Fields left out keep their value, an empty avatar clears it
+	resp = client.doJSON(t, http.MethodPatch, "/api/me", token, map[string]any{
+		"avatar": "",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	me = successResponse[meResponse]{}
+	decodeSuccess(t, resp, &me)
+	require.Equal(t, "Renamed Owner", me.Data.DisplayName)
+	require.Nil(t, me.Data.Avatar)
+
+	resp = client.doJSON(t, http.MethodPatch, "/api/me", token, map[string]any{
+		"display_name": "x",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPatch, "/api/me", token, map[string]any{
+		"avatar": "not a url",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me/accounts", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var accounts successResponse[[]models.Account]
+	decodeSuccess(t, resp, &accounts)
+	require.Len(t, accounts.Data, 1)
+	require.Equal(t, models.ProviderEmail, accounts.Data[0].Provider)
+	require.Equal(t, "me@example.com", accounts.Data[0].Email)
+}
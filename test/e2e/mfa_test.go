@@ -0,0 +1,173 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+type mfaChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+
+	code, err := totp.GenerateCode(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+// loginChallenge runs the password step of a login for a user with TOTP and returns the MFA token
+func (c *apiClient) loginChallenge(t *testing.T, email, password string) string {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var parsed successResponse[mfaChallenge]
+	decodeSuccess(t, resp, &parsed)
+	require.True(t, parsed.Data.MFARequired)
+	require.NotEmpty(t, parsed.Data.MFAToken)
+	return parsed.Data.MFAToken
+}
+
+func TestTOTPLogin(t *testing.T) {
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	client.Register(t, "mfa@example.com", "password123", "Second Factor")
+	token := client.RefreshAccessToken(t)
+
+	resp := client.doJSON(t, http.MethodPost, "/api/auth/mfa/totp", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var enrollment successResponse[struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}]
+	decodeSuccess(t, resp, &enrollment)
+	secret := enrollment.Data.Secret
+	require.NotEmpty(t, secret)
+	require.Contains(t, enrollment.Data.ProvisioningURI, "otpauth://totp/")
+	require.Contains(t, enrollment.Data.ProvisioningURI, "secret="+secret)
+
+	// Not enabled before it is confirmed
+	login := newAPIClient(t, server.URL)
+	login.Login(t, "mfa@example.com", "password123")
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/mfa/totp/confirm", token, map[string]string{"code": "000000"})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	now := time.Now()
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/mfa/totp/confirm", token, map[string]string{"code": totpCode(t, secret, now)})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var recovery successResponse[struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}]
+	decodeSuccess(t, resp, &recovery)
+	require.Len(t, recovery.Data.RecoveryCodes, 10)
+
+	// The password alone no longer starts a session
+	second := newAPIClient(t, server.URL)
+	mfaToken := second.loginChallenge(t, "mfa@example.com", "password123")
+	resp = second.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// The code used for the confirmation can't be replayed
+	resp = second.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+		"mfa_token": mfaToken,
+		"code":      totpCode(t, secret, now),
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = second.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+		"mfa_token": "not-a-token",
+		"code":      totpCode(t, secret, now.Add(30*time.Second)),
+	})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = second.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+		"mfa_token": mfaToken,
+		"code":      totpCode(t, secret, now.Add(30*time.Second)),
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.NotEmpty(t, second.RefreshAccessToken(t))
+
+	// The token completes a single login
+	resp = second.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+		"mfa_token": mfaToken,
+		"code":      totpCode(t, secret, now.Add(60*time.Second)),
+	})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// Guessing codes burns the token, even the right code is refused afterwards
+	guesser := newAPIClient(t, server.URL)
+	mfaToken = guesser.loginChallenge(t, "mfa@example.com", "password123")
+	for i := 0; i < 5; i++ {
+		resp = guesser.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+			"mfa_token": mfaToken,
+			"code":      "000000",
+		})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	resp = guesser.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+		"mfa_token": mfaToken,
+		"code":      totpCode(t, secret, now.Add(60*time.Second)),
+	})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// Recovery codes work once
+	third := newAPIClient(t, server.URL)
+	mfaToken = third.loginChallenge(t, "mfa@example.com", "password123")
+	resp = third.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+		"mfa_token":     mfaToken,
+		"recovery_code": recovery.Data.RecoveryCodes[0],
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	mfaToken = third.loginChallenge(t, "mfa@example.com", "password123")
+	resp = third.doJSON(t, http.MethodPost, "/api/auth/login/mfa", "", map[string]string{
+		"mfa_token":     mfaToken,
+		"recovery_code": recovery.Data.RecoveryCodes[0],
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/auth/mfa", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status successResponse[struct {
+		TOTPEnabled            bool `json:"totp_enabled"`
+		RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	}]
+	decodeSuccess(t, resp, &status)
+	require.True(t, status.Data.TOTPEnabled)
+	require.Equal(t, 9, status.Data.RecoveryCodesRemaining)
+
+	// Disabling brings back the single step login
+	resp = client.doJSON(t, http.MethodDelete, "/api/auth/mfa/totp", token, map[string]string{
+		"recovery_code": recovery.Data.RecoveryCodes[1],
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	newAPIClient(t, server.URL).Login(t, "mfa@example.com", "password123")
+}
@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// startOIDCDiscoveryStub serves the discovery document of an OIDC issuer while available is true
+func startOIDCDiscoveryStub(t *testing.T, available *atomic.Bool) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !available.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		writeJSON(t, w, http.StatusOK, map[string]any{
+			"issuer":                                server.URL,
+			"authorization_endpoint":                server.URL + "/auth",
+			"token_endpoint":                        server.URL + "/token",
+			"userinfo_endpoint":                     server.URL + "/userinfo",
+			"jwks_uri":                              server.URL + "/certs",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGenericOIDCProviderEntry(t *testing.T) {
+	ctx := context.Background()
+
+	var available atomic.Bool
+	available.Store(true)
+	issuer := startOIDCDiscoveryStub(t, &available)
+	t.Setenv("OIDC_PROVIDERS", "keycloak")
+	t.Setenv("OIDC_KEYCLOAK_ISSUER_URL", issuer.URL)
+	t.Setenv("OIDC_KEYCLOAK_CLIENT_ID", "ridash")
+	t.Setenv("OIDC_KEYCLOAK_CLIENT_SECRET", "keycloak-secret")
+	t.Setenv("OIDC_KEYCLOAK_REDIRECT_URL", "http://localhost/api/auth/oauth/keycloak/callback")
+	t.Setenv("OIDC_KEYCLOAK_SCOPES", "openid,email")
+
+	_, server, _ := initApp(t, ctx)
+	noRedirect := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := noRedirect.Get(server.URL + "/api/auth/oauth/keycloak")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, issuer.URL+"/auth", location.Scheme+"://"+location.Host+location.Path)
+	require.Equal(t, "ridash", location.Query().Get("client_id"))
+	require.Equal(t, "openid email", location.Query().Get("scope"))
+	require.NotEmpty(t, location.Query().Get("state"))
+
+	resp, err = noRedirect.Get(server.URL + "/api/auth/oauth/unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestOIDCProviderDiscoveryIsLazy(t *testing.T) {
+	ctx := context.Background()
+
+	// The issuer is down when the server starts
+	var available atomic.Bool
+	issuer := startOIDCDiscoveryStub(t, &available)
+	t.Setenv("OIDC_PROVIDERS", "keycloak")
+	t.Setenv("OIDC_KEYCLOAK_ISSUER_URL", issuer.URL)
+	t.Setenv("OIDC_KEYCLOAK_CLIENT_ID", "ridash")
+	t.Setenv("OIDC_KEYCLOAK_CLIENT_SECRET", "keycloak-secret")
+	t.Setenv("OIDC_KEYCLOAK_REDIRECT_URL", "http://localhost/api/auth/oauth/keycloak/callback")
+	t.Setenv("OAUTH_DISCOVERY_RETRY_INTERVAL", "0")
+
+	_, server, _ := initApp(t, ctx)
+	noRedirect := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Email login doesn't depend on the provider
+	client := newAPIClient(t, server.URL)
+	client.Register(t, "offline@example.com", "password123", "Offline User")
+	require.NotEmpty(t, client.RefreshAccessToken(t))
+
+	resp, err := noRedirect.Get(server.URL + "/api/auth/oauth/keycloak")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	// Discovery is retried once the issuer is back
+	available.Store(true)
+	resp, err = noRedirect.Get(server.URL + "/api/auth/oauth/keycloak")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+}
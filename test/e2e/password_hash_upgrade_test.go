@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	"ridash/utils/config"
+)
+
+func getPasswordHash(t *testing.T, pool *pgxpool.Pool, email string) string {
+	t.Helper()
+
+	var passwordHash string
+	err := pool.QueryRow(context.Background(), `
+		SELECT u.password_hash
+		FROM users u
+		JOIN accounts a ON a.user_id = u.id
+		WHERE a.email = $1 AND a.provider = 'email'`, email).Scan(&passwordHash)
+	require.NoError(t, err)
+	return passwordHash
+}
+
+func TestPasswordHashUpgrade(t *testing.T) {
+	t.Setenv("ARGON2ID_MEMORY", "8192")
+	t.Setenv("ARGON2ID_ITERATIONS", "2")
+	t.Setenv("ARGON2ID_PARALLELISM", "1")
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	client.Register(t, "upgrade@example.com", "password123", "Upgraded")
+	require.Contains(t, getPasswordHash(t, pool, "upgrade@example.com"), "$t=2$m=8192$p=1$")
+
+	// Raise the cost as a new deployment would
+	config.Env().Argon2idMemory = 16384
+	config.Env().Argon2idIterations = 3
+
+	// A wrong password doesn't touch the hash
+	resp := client.doJSON(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "upgrade@example.com",
+		"password": "wrong-password",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+	require.Contains(t, getPasswordHash(t, pool, "upgrade@example.com"), "$t=2$m=8192$p=1$")
+
+	client.Login(t, "upgrade@example.com", "password123")
+	upgraded := getPasswordHash(t, pool, "upgrade@example.com")
+	require.Contains(t, upgraded, "$t=3$m=16384$p=1$")
+
+	// The upgraded hash still verifies, and lowering the cost never downgrades it
+	config.Env().Argon2idIterations = 2
+	client.Login(t, "upgrade@example.com", "password123")
+	require.Equal(t, upgraded, getPasswordHash(t, pool, "upgrade@example.com"))
+}
+
+func TestPasswordHashUpgradeKeepsStrongerParameters(t *testing.T) {
+	t.Setenv("ARGON2ID_MEMORY", "16384")
+	t.Setenv("ARGON2ID_ITERATIONS", "2")
+	t.Setenv("ARGON2ID_PARALLELISM", "1")
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	client.Register(t, "mixed@example.com", "password123", "Mixed")
+	require.Contains(t, getPasswordHash(t, pool, "mixed@example.com"), "$t=2$m=16384$p=1$")
+
+	// More iterations but less memory, the upgrade takes the stronger of each
+	config.Env().Argon2idMemory = 8192
+	config.Env().Argon2idIterations = 3
+
+	client.Login(t, "mixed@example.com", "password123")
+	require.Contains(t, getPasswordHash(t, pool, "mixed@example.com"), "$t=3$m=16384$p=1$")
+}
@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+	otherDevice := newAPIClient(t, server.URL)
+
+	client.Register(t, "reset@example.com", "password123", "Forgetful")
+	otherDevice.Login(t, "reset@example.com", "password123")
+
+	// Unknown addresses get the same answer and no email
+	resp := client.doJSON(t, http.MethodPost, "/api/auth/password/forgot", "", map[string]string{"email": "unknown@example.com"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	require.Empty(t, deliverEmails(t, "unknown@example.com"))
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/password/forgot", "", map[string]string{"email": "reset@example.com"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	resetToken := lastEmailToken(t, "reset@example.com")
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/password/reset", "", map[string]string{
+		"token":    "wrong-token",
+		"password": "newpassword123",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/password/reset", "", map[string]string{
+		"token":    resetToken,
+		"password": "newpassword123",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Reset tokens are single use
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/password/reset", "", map[string]string{
+		"token":    resetToken,
+		"password": "anotherpassword123",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Existing sessions are signed out
+	resp = otherDevice.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    "reset@example.com",
+		"password": "password123",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	client.Login(t, "reset@example.com", "newpassword123")
+	require.NotEmpty(t, client.RefreshAccessToken(t))
+}
@@ -0,0 +1,162 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+type createdTokenResponse struct {
+	models.PersonalAccessToken
+	Token string `json:"token"`
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	userID, token := createOAuthUser(t, pool, "automation@example.com", "Automation")
+
+	resp := client.doJSON(t, http.MethodPost, "/api/me/tokens", token, map[string]any{"name": "CI deploy", "scopes": []string{"user:read"}, "expires_in_days": 30})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var created successResponse[createdTokenResponse]
+	decodeSuccess(t, resp, &created)
+	require.Contains(t, created.Data.Token, "rdpat_")
+	require.NotNil(t, created.Data.ExpiresAt)
+	pat := created.Data.Token
+
+	// Only the hash is stored
+	var stored string
+	require.NoError(t, pool.QueryRow(ctx, `SELECT token_hash FROM personal_access_tokens WHERE id = $1`, created.Data.ID).Scan(&stored))
+	require.NotEqual(t, pat, stored)
+
+	// The token authenticates like an access token
+	resp = client.doJSON(t, http.MethodGet, "/api/me", pat, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var me successResponse[meResponse]
+	decodeSuccess(t, resp, &me)
+	require.Equal(t, userID, me.Data.ID)
+
+	var used bool
+	require.NoError(t, pool.QueryRow(ctx, `SELECT last_used_at IS NOT NULL FROM personal_access_tokens WHERE id = $1`, created.Data.ID).Scan(&used))
+	require.True(t, used)
+
+	// Tokens can't manage tokens, credentials or sessions, those routes have no scope
+	resp = client.doJSON(t, http.MethodPost, "/api/me/tokens", pat, map[string]any{"name": "Escalation", "scopes": []string{"user:write"}})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPut, "/api/me/password", pat, map[string]string{"new_password": "takeover123"})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/mfa/totp", pat, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/logout-all", pat, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me/tokens", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed successResponse[[]models.PersonalAccessToken]
+	decodeSuccess(t, resp, &listed)
+	require.Len(t, listed.Data, 1)
+	require.Equal(t, "CI deploy", listed.Data[0].Name)
+
+	// Someone else can't revoke it
+	_, otherToken := createOAuthUser(t, pool, "other-automation@example.com", "Other")
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/tokens/"+strconv.FormatInt(created.Data.ID, 10), otherToken, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/tokens/"+strconv.FormatInt(created.Data.ID, 10), token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// A revoked token is refused right away
+	resp = client.doJSON(t, http.MethodGet, "/api/me", pat, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// Expired tokens are refused too
+	resp = client.doJSON(t, http.MethodPost, "/api/me/tokens", token, map[string]any{"name": "Short lived", "scopes": []string{"user:read"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	decodeSuccess(t, resp, &created)
+	_, err := pool.Exec(ctx, `UPDATE personal_access_tokens SET expires_at = NOW() - interval '1 hour' WHERE id = $1`, created.Data.ID)
+	require.NoError(t, err)
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me", created.Data.Token, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me", "rdpat_unknown", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func (c *apiClient) CreatePersonalAccessToken(t *testing.T, token string, scopes ...string) string {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPost, "/api/me/tokens", token, map[string]any{"name": "Scoped", "scopes": scopes})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var parsed successResponse[createdTokenResponse]
+	decodeSuccess(t, resp, &parsed)
+	return parsed.Data.Token
+}
+
+func TestPersonalAccessTokenScopes(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	_, token := createOAuthUser(t, pool, "scoped@example.com", "Scoped Owner")
+	team := client.CreateTeam(t, token, "Scoped Team")
+	folder := client.CreateFolder(t, token, team.ID, "Reports", nil)
+	doc := client.CreateDocument(t, token, folder.ID, "Quarterly", models.DocsPermissionPrivate)
+	docPath := "/api/documents/" + strconv.FormatInt(doc.ID, 10)
+
+	// A read-only token can read, but can't write even though its user owns the team
+	readOnly := client.CreatePersonalAccessToken(t, token, "documents:read")
+	require.Equal(t, doc.ID, client.GetDocument(t, readOnly, doc.ID).ID)
+
+	resp := client.doJSON(t, http.MethodPut, docPath, readOnly, map[string]any{"name": "Edited", "permission": "private"})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/teams", readOnly, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	// Write includes read
+	writer := client.CreatePersonalAccessToken(t, token, "documents:write")
+	require.Equal(t, "Edited", client.UpdateDocument(t, writer, doc.ID, "Edited", models.DocsPermissionPrivate).Name)
+	require.Len(t, client.ListDocuments(t, writer), 1)
+
+	// Team administration needs teams:admin, which includes teams:read
+	teamReader := client.CreatePersonalAccessToken(t, token, "teams:read")
+	require.Len(t, client.ListTeams(t, teamReader), 1)
+	resp = client.doJSON(t, http.MethodPut, "/api/teams/"+strconv.FormatInt(team.ID, 10), teamReader, map[string]any{"name": "Renamed"})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	teamAdmin := client.CreatePersonalAccessToken(t, token, "teams:admin")
+	require.Equal(t, "Renamed", client.UpdateTeam(t, teamAdmin, team.ID, "Renamed").Name)
+
+	// Sessions keep every scope
+	require.Equal(t, "Edited", client.GetDocument(t, token, doc.ID).Name)
+
+	resp = client.doJSON(t, http.MethodPost, "/api/me/tokens", token, map[string]any{"name": "Bad", "scopes": []string{"everything"}})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	victim := newAPIClient(t, server.URL)
+	otherDevice := newAPIClient(t, server.URL)
+
+	victim.Register(t, "reuse@example.com", "password123", "Victim")
+	otherDevice.Login(t, "reuse@example.com", "password123")
+
+	refreshURL, err := url.Parse(server.URL + "/api/auth/refresh")
+	require.NoError(t, err)
+
+	// Steal the token before it is rotated
+	var stolen *http.Cookie
+	for _, cookie := range victim.client.Jar.Cookies(refreshURL) {
+		if cookie.Name == models.CookieNameRefreshToken {
+			stolen = cookie
+		}
+	}
+	require.NotNil(t, stolen)
+
+	victim.RefreshAccessToken(t)
+
+	attacker := newAPIClient(t, server.URL)
+	attacker.client.Jar.SetCookies(refreshURL, []*http.Cookie{{Name: stolen.Name, Value: stolen.Value}})
+
+	resp := attacker.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// The whole session is gone, including the token the victim holds
+	resp = victim.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// Other sessions are untouched
+	require.NotEmpty(t, otherDevice.RefreshAccessToken(t))
+
+	var events int
+	require.NoError(t, pool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM security_events WHERE user_id = $1 AND type = $2",
+		getUserIDByEmail(t, pool, "reuse@example.com"), models.SecurityEventRefreshTokenReuse,
+	).Scan(&events))
+	require.Equal(t, 1, events)
+
+	// Replaying again doesn't warn the user twice
+	resp = attacker.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	var alerts int
+	for _, message := range deliverEmails(t, "reuse@example.com") {
+		if message.Subject == "Security alert: one of your ridash-e2e sessions was signed out" {
+			alerts++
+		}
+	}
+	require.Equal(t, 1, alerts)
+}
+
+func TestRefreshTokenCookieAtLegacyPath(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	client.Register(t, "legacy-cookie@example.com", "password123", "Legacy Cookie")
+
+	refreshURL, err := url.Parse(server.URL + "/api/auth/refresh")
+	require.NoError(t, err)
+
+	refreshTokenCookies := func() []*http.Cookie {
+		var cookies []*http.Cookie
+		for _, cookie := range client.client.Jar.Cookies(refreshURL) {
+			if cookie.Name == models.CookieNameRefreshToken {
+				cookies = append(cookies, cookie)
+			}
+		}
+		return cookies
+	}
+
+	previous := refreshTokenCookies()
+	require.Len(t, previous, 1)
+
+	client.RefreshAccessToken(t)
+
+	// A browser still holding the rotated token at the paths the cookie used to live at sends those first
+	for _, path := range []string{"/api/auth/refresh", "/api/auth"} {
+		client.client.Jar.SetCookies(refreshURL, []*http.Cookie{{Name: models.CookieNameRefreshToken, Value: previous[0].Value, Path: path}})
+	}
+	require.Len(t, refreshTokenCookies(), 3)
+
+	client.RefreshAccessToken(t)
+
+	// The legacy cookies are expired and the session carries on
+	require.Len(t, refreshTokenCookies(), 1)
+	client.RefreshAccessToken(t)
+
+	var events int
+	require.NoError(t, pool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM security_events WHERE user_id = $1 AND type = $2",
+		getUserIDByEmail(t, pool, "legacy-cookie@example.com"), models.SecurityEventRefreshTokenReuse,
+	).Scan(&events))
+	require.Zero(t, events)
+}
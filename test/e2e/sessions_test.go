@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+
+	_, server, _ := initApp(t, ctx)
+	laptop := newAPIClient(t, server.URL)
+	phone := newAPIClient(t, server.URL)
+
+	laptop.Register(t, "sessions@example.com", "password123", "Traveller")
+	laptop.RefreshAccessToken(t)
+	laptopToken := laptop.RefreshAccessToken(t)
+
+	phone.Login(t, "sessions@example.com", "password123")
+	phone.RefreshAccessToken(t)
+
+	// Rotated tokens stay in the session they came from
+	resp := laptop.doJSON(t, http.MethodGet, "/api/auth/sessions", laptopToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var parsed successResponse[[]models.Session]
+	decodeSuccess(t, resp, &parsed)
+	require.Len(t, parsed.Data, 2)
+
+	var phoneSession models.Session
+	currentCount := 0
+	for _, session := range parsed.Data {
+		require.False(t, session.LastUsedAt.Before(session.FirstSeenAt))
+		if session.Current {
+			currentCount++
+		} else {
+			phoneSession = session
+		}
+	}
+	require.Equal(t, 1, currentCount)
+
+	resp = laptop.doJSON(t, http.MethodDelete, "/api/auth/sessions/"+strconv.FormatInt(phoneSession.ID, 10), laptopToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = phone.doJSON(t, http.MethodPost, "/api/auth/refresh", "", struct{}{})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = laptop.doJSON(t, http.MethodDelete, "/api/auth/sessions/"+strconv.FormatInt(phoneSession.ID, 10), laptopToken, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = laptop.doJSON(t, http.MethodGet, "/api/auth/sessions", laptopToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	parsed = successResponse[[]models.Session]{}
+	decodeSuccess(t, resp, &parsed)
+	require.Len(t, parsed.Data, 1)
+	require.True(t, parsed.Data[0].Current)
+
+	// Laptop session keeps working
+	require.NotEmpty(t, laptop.RefreshAccessToken(t))
+}
@@ -0,0 +1,66 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestTeamMemberManagement(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	owner := newAPIClient(t, server.URL)
+	member := newAPIClient(t, server.URL)
+
+	owner.Register(t, "team-owner@example.com", "password123", "Owner")
+	owner.Login(t, "team-owner@example.com", "password123")
+	ownerToken := owner.RefreshAccessToken(t)
+
+	member.Register(t, "team-member@example.com", "password123", "Member")
+	member.Login(t, "team-member@example.com", "password123")
+	memberToken := member.RefreshAccessToken(t)
+
+	ownerID := getUserIDByEmail(t, pool, "team-owner@example.com")
+	memberID := getUserIDByEmail(t, pool, "team-member@example.com")
+
+	team := owner.CreateTeam(t, ownerToken, "Members Team")
+
+	members := owner.ListTeamMembers(t, ownerToken, team.ID)
+	require.Len(t, members, 1)
+	require.Equal(t, ownerID, members[0].UserID)
+	require.Equal(t, models.RoleOwner, members[0].Role)
+
+	// Non-members cannot see the member list
+	resp := member.doJSON(t, http.MethodGet, "/api/teams/"+strconv.FormatInt(team.ID, 10)+"/members", memberToken, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	added := owner.AddTeamMember(t, ownerToken, team.ID, memberID, models.RoleMember)
+	require.Equal(t, models.RoleMember, added.Role)
+	require.Len(t, member.ListTeamMembers(t, memberToken, team.ID), 2)
+
+	// Plain members cannot change roles
+	resp = member.doJSON(t, http.MethodPut, "/api/teams/"+strconv.FormatInt(team.ID, 10)+"/members/"+strconv.FormatInt(memberID, 10), memberToken, map[string]string{
+		"role": string(models.RoleAdmin),
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	updated := owner.UpdateTeamMember(t, ownerToken, team.ID, memberID, models.RoleAdmin)
+	require.Equal(t, models.RoleAdmin, updated.Role)
+
+	// The owner can never be removed
+	resp = member.doJSON(t, http.MethodDelete, "/api/teams/"+strconv.FormatInt(team.ID, 10)+"/members/"+strconv.FormatInt(ownerID, 10), memberToken, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	// Members can leave the team themselves
+	member.RemoveTeamMember(t, memberToken, team.ID, memberID)
+	require.Len(t, owner.ListTeamMembers(t, ownerToken, team.ID), 1)
+}
@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+)
+
+func TestTeamRolePermissions(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	owner := newAPIClient(t, server.URL)
+	admin := newAPIClient(t, server.URL)
+	member := newAPIClient(t, server.URL)
+	outsider := newAPIClient(t, server.URL)
+
+	owner.Register(t, "roles-owner@example.com", "password123", "Owner")
+	owner.Login(t, "roles-owner@example.com", "password123")
+	ownerToken := owner.RefreshAccessToken(t)
+
+	admin.Register(t, "roles-admin@example.com", "password123", "Admin")
+	admin.Login(t, "roles-admin@example.com", "password123")
+	adminToken := admin.RefreshAccessToken(t)
+
+	member.Register(t, "roles-member@example.com", "password123", "Member")
+	member.Login(t, "roles-member@example.com", "password123")
+	memberToken := member.RefreshAccessToken(t)
+
+	outsider.Register(t, "roles-outsider@example.com", "password123", "Outsider")
+	outsider.Login(t, "roles-outsider@example.com", "password123")
+	outsiderToken := outsider.RefreshAccessToken(t)
+
+	team := owner.CreateTeam(t, ownerToken, "Roles Team")
+	owner.AddTeamMember(t, ownerToken, team.ID, getUserIDByEmail(t, pool, "roles-admin@example.com"), models.RoleAdmin)
+	owner.AddTeamMember(t, ownerToken, team.ID, getUserIDByEmail(t, pool, "roles-member@example.com"), models.RoleMember)
+
+	// Admins can create folders and documents
+	folder := admin.CreateFolder(t, adminToken, team.ID, "Admin Folder", nil)
+	doc := admin.CreateDocument(t, adminToken, folder.ID, "Team Doc", models.DocsPermissionPrivate)
+
+	// Members can read and edit team documents
+	require.Len(t, member.ListFolders(t, memberToken, team.ID), 1)
+	require.Equal(t, doc.ID, member.GetDocument(t, memberToken, doc.ID).ID)
+	require.Len(t, member.ListDocuments(t, memberToken), 1)
+	updated := member.UpdateDocument(t, memberToken, doc.ID, "Team Doc v2", models.DocsPermissionPrivate)
+	require.Equal(t, "Team Doc v2", updated.Name)
+
+	// Members cannot create folders or delete documents
+	resp := member.doJSON(t, http.MethodPost, "/api/teams/"+strconv.FormatInt(team.ID, 10)+"/folders", memberToken, map[string]string{
+		"name": "Member Folder",
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = member.doJSON(t, http.MethodDelete, "/api/documents/"+strconv.FormatInt(doc.ID, 10), memberToken, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	// Outsiders cannot see anything in the team
+	resp = outsider.doJSON(t, http.MethodGet, "/api/documents/"+strconv.FormatInt(doc.ID, 10), outsiderToken, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = outsider.doJSON(t, http.MethodGet, "/api/teams/"+strconv.FormatInt(team.ID, 10)+"/folders", outsiderToken, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	// Admins cannot rename or delete the team
+	resp = admin.doJSON(t, http.MethodPut, "/api/teams/"+strconv.FormatInt(team.ID, 10), adminToken, map[string]string{
+		"name": "Hijacked",
+	})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	admin.DeleteDocument(t, adminToken, doc.ID)
+	admin.DeleteFolder(t, adminToken, team.ID, folder.ID)
+}
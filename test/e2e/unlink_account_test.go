@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+	"ridash/repository"
+)
+
+func TestUnlinkAccount(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	_, token := createOAuthUser(t, pool, "unlink@example.com", "Linked User")
+
+	listAccounts := func() []models.Account {
+		resp := client.doJSON(t, http.MethodGet, "/api/me/accounts", token, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var parsed successResponse[[]models.Account]
+		decodeSuccess(t, resp, &parsed)
+		return parsed.Data
+	}
+
+	accounts := listAccounts()
+	require.Len(t, accounts, 1)
+	googleAccount := accounts[0]
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repository.CreateOAuthToken(ctx, tx, models.OAuthToken{
+		AccountID:   googleAccount.ID,
+		AccessToken: "google-access-token",
+		Expiry:      time.Now().Add(time.Hour),
+		TokenType:   "Bearer",
+		Provider:    models.ProviderGoogle,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}))
+	require.NoError(t, tx.Commit(ctx))
+
+	// Google is the only way in
+	resp := client.doJSON(t, http.MethodDelete, "/api/me/accounts/"+strconv.FormatInt(googleAccount.ID, 10), token, nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodPut, "/api/me/password", token, map[string]string{
+		"new_password": "password123",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/accounts/"+strconv.FormatInt(googleAccount.ID, 10), token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	var tokens int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM oauth_tokens WHERE account_id = $1`, googleAccount.ID).Scan(&tokens))
+	require.Zero(t, tokens)
+
+	accounts = listAccounts()
+	require.Len(t, accounts, 1)
+	require.Equal(t, models.ProviderEmail, accounts[0].Provider)
+
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/accounts/"+strconv.FormatInt(googleAccount.ID, 10), token, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/accounts/"+strconv.FormatInt(accounts[0].ID, 10), token, nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	// Someone else's account is not found
+	other := newAPIClient(t, server.URL)
+	other.Register(t, "unlink-other@example.com", "password123", "Other User")
+	otherToken := other.RefreshAccessToken(t)
+	resp = other.doJSON(t, http.MethodDelete, "/api/me/accounts/"+strconv.FormatInt(accounts[0].ID, 10), otherToken, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	client.Login(t, "unlink@example.com", "password123")
+}
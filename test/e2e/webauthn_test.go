@@ -0,0 +1,232 @@
+package e2e
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/require"
+
+	"ridash/models"
+	"ridash/utils/config"
+)
+
+// softAuthenticator is a passkey authenticator kept in memory, it answers the ceremonies like a browser would
+type softAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+
+	return &softAuthenticator{t: t, key: key, credentialID: credentialID}
+}
+
+type webauthnOptions struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		User      struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	} `json:"publicKey"`
+}
+
+func (a *softAuthenticator) clientData(kind string, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      kind,
+		"challenge": challenge,
+		"origin":    config.Env().WebAuthnRPOrigins[0],
+	})
+	require.NoError(a.t, err)
+	return data
+}
+
+// authData builds the authenticator data, flags are user present and verified
+func (a *softAuthenticator) authData(attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(config.Env().WebAuthnRPID))
+	flags := byte(0x01 | 0x04)
+	if attested != nil {
+		flags |= 0x40
+	}
+
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+// register answers navigator.credentials.create with a "none" attestation
+func (a *softAuthenticator) register(options webauthnOptions) map[string]any {
+	userHandle, err := base64.RawURLEncoding.DecodeString(options.PublicKey.User.ID)
+	require.NoError(a.t, err)
+	a.userHandle = userHandle
+
+	publicKey, err := cbor.Marshal(map[int]any{
+		1:  2,  // EC2
+		3:  -7, // ES256
+		-1: 1,  // P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(a.t, err)
+
+	attested := make([]byte, 16) // Zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestationObject, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(attested),
+	})
+	require.NoError(a.t, err)
+
+	return map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", options.PublicKey.Challenge)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+		},
+	}
+}
+
+// assert answers navigator.credentials.get, signing with the next counter value
+func (a *softAuthenticator) assert(options webauthnOptions) map[string]any {
+	a.signCount++
+
+	clientData := a.clientData("webauthn.get", options.PublicKey.Challenge)
+	authData := a.authData(nil)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	return map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+		},
+	}
+}
+
+func (c *apiClient) beginWebAuthn(t *testing.T, path, token string) webauthnOptions {
+	t.Helper()
+
+	resp := c.doJSON(t, http.MethodPost, path, token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var parsed successResponse[webauthnOptions]
+	decodeSuccess(t, resp, &parsed)
+	require.NotEmpty(t, parsed.Data.PublicKey.Challenge)
+	return parsed.Data
+}
+
+func TestWebAuthnPasskeys(t *testing.T) {
+	ctx := context.Background()
+
+	pool, server, _ := initApp(t, ctx)
+	client := newAPIClient(t, server.URL)
+
+	userID, token := createOAuthUser(t, pool, "passkey@example.com", "Passkey User")
+	authenticator := newSoftAuthenticator(t)
+
+	// Register a passkey from the signed in session
+	options := client.beginWebAuthn(t, "/api/auth/webauthn/register/begin", token)
+	resp := client.doJSON(t, http.MethodPost, "/api/auth/webauthn/register/finish?name=Laptop", token, authenticator.register(options))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var registered successResponse[models.WebAuthnCredential]
+	decodeSuccess(t, resp, &registered)
+	require.Equal(t, "Laptop", registered.Data.Name)
+	require.Equal(t, userID, registered.Data.UserID)
+	require.Equal(t, []byte(strconv.FormatInt(userID, 10)), authenticator.userHandle)
+
+	// The challenge can't be answered twice
+	resp = client.doJSON(t, http.MethodPost, "/api/auth/webauthn/register/finish", token, authenticator.register(options))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodGet, "/api/me/passkeys", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var passkeys successResponse[[]models.WebAuthnCredential]
+	decodeSuccess(t, resp, &passkeys)
+	require.Len(t, passkeys.Data, 1)
+
+	// A new browser logs in with the passkey alone
+	browser := newAPIClient(t, server.URL)
+	options = browser.beginWebAuthn(t, "/api/auth/webauthn/login/begin", "")
+	resp = browser.doJSON(t, http.MethodPost, "/api/auth/webauthn/login/finish", "", authenticator.assert(options))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	accessToken := browser.RefreshAccessToken(t)
+	resp = browser.doJSON(t, http.MethodGet, "/api/me", accessToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var me successResponse[meResponse]
+	decodeSuccess(t, resp, &me)
+	require.Equal(t, userID, me.Data.ID)
+
+	var signCount int64
+	require.NoError(t, pool.QueryRow(ctx, `SELECT sign_count FROM webauthn_credentials WHERE id = $1`, registered.Data.ID).Scan(&signCount))
+	require.Equal(t, int64(1), signCount)
+
+	// A cloned authenticator replays an old counter
+	authenticator.signCount = 0
+	options = browser.beginWebAuthn(t, "/api/auth/webauthn/login/begin", "")
+	resp = browser.doJSON(t, http.MethodPost, "/api/auth/webauthn/login/finish", "", authenticator.assert(options))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Finishing without a started ceremony fails
+	resp = newAPIClient(t, server.URL).doJSON(t, http.MethodPost, "/api/auth/webauthn/login/finish", "", authenticator.assert(options))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// The passkey counts as a login method, so Google can go but the passkey then can't
+	resp = client.doJSON(t, http.MethodGet, "/api/me/accounts", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var accounts successResponse[[]models.Account]
+	decodeSuccess(t, resp, &accounts)
+	require.Len(t, accounts.Data, 1)
+
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/accounts/"+strconv.FormatInt(accounts.Data[0].ID, 10), token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/passkeys/"+strconv.FormatInt(registered.Data.ID, 10), token, nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	// Someone else's passkey is not found
+	_, otherToken := createOAuthUser(t, pool, "other-passkey@example.com", "Other User")
+	resp = client.doJSON(t, http.MethodDelete, "/api/me/passkeys/"+strconv.FormatInt(registered.Data.ID, 10), otherToken, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
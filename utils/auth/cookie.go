@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"net/http"
+
+	"ridash/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetRefreshTokenCookie returns the refresh token cookie set at the current path.
+// Browsers may still hold one at a path it was set at before, cookies with longer paths
+// are sent first and the current path is the shortest, so the last one wins.
+func GetRefreshTokenCookie(c echo.Context) (*http.Cookie, error) {
+	var refreshTokenCookie *http.Cookie
+	for _, cookie := range c.Cookies() {
+		if cookie.Name == models.CookieNameRefreshToken {
+			refreshTokenCookie = cookie
+		}
+	}
+
+	if refreshTokenCookie == nil {
+		return nil, http.ErrNoCookie
+	}
+
+	return refreshTokenCookie, nil
+}
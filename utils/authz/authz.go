@@ -0,0 +1,120 @@
+package authz
+
+import (
+	"context"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Action is an operation a user can perform inside a team
+type Action string
+
+// Action constants
+const (
+	ActionTeamRead      Action = "team:read"      // View the team
+	ActionTeamUpdate    Action = "team:update"    // Rename the team
+	ActionTeamDelete    Action = "team:delete"    // Delete the team
+	ActionTeamTransfer  Action = "team:transfer"  // Hand the team over to another member
+	ActionMembersManage Action = "members:manage" // Add, re-role and remove team members
+
+	ActionFolderRead   Action = "folder:read"   // View folders
+	ActionFolderCreate Action = "folder:create" // Create folders
+	ActionFolderUpdate Action = "folder:update" // Rename and move folders
+	ActionFolderDelete Action = "folder:delete" // Delete folders
+
+	ActionDocumentRead   Action = "document:read"   // Read team documents
+	ActionDocumentCreate Action = "document:create" // Create documents
+	ActionDocumentUpdate Action = "document:update" // Edit documents
+	ActionDocumentDelete Action = "document:delete" // Delete documents
+	ActionDocumentShare  Action = "document:share"  // Manage document shares
+)
+
+// policy is the role/action matrix applied to every team resource
+var policy = map[models.Role]map[Action]bool{
+	models.RoleOwner: {
+		ActionTeamRead:       true,
+		ActionTeamUpdate:     true,
+		ActionTeamDelete:     true,
+		ActionTeamTransfer:   true,
+		ActionMembersManage:  true,
+		ActionFolderRead:     true,
+		ActionFolderCreate:   true,
+		ActionFolderUpdate:   true,
+		ActionFolderDelete:   true,
+		ActionDocumentRead:   true,
+		ActionDocumentCreate: true,
+		ActionDocumentUpdate: true,
+		ActionDocumentDelete: true,
+		ActionDocumentShare:  true,
+	},
+	models.RoleAdmin: {
+		ActionTeamRead:       true,
+		ActionMembersManage:  true,
+		ActionFolderRead:     true,
+		ActionFolderCreate:   true,
+		ActionFolderUpdate:   true,
+		ActionFolderDelete:   true,
+		ActionDocumentRead:   true,
+		ActionDocumentCreate: true,
+		ActionDocumentUpdate: true,
+		ActionDocumentDelete: true,
+		ActionDocumentShare:  true,
+	},
+	models.RoleMember: {
+		ActionTeamRead:       true,
+		ActionFolderRead:     true,
+		ActionDocumentRead:   true,
+		ActionDocumentUpdate: true,
+	},
+}
+
+// Can reports whether the role is allowed to perform the action
+func Can(role models.Role, action Action) bool {
+	return policy[role][action]
+}
+
+// GetTeamRole resolves the role the user holds in the team.
+// The role is empty when the user is not a member of the team.
+func GetTeamRole(ctx context.Context, tx pgx.Tx, team *models.Team, userID int64) (models.Role, error) {
+	if team == nil {
+		return "", nil
+	}
+
+	// teams.owner_id is the source of truth for ownership
+	if team.OwnerID == userID {
+		return models.RoleOwner, nil
+	}
+
+	member, err := repository.GetTeamMemberByTeamAndUser(ctx, tx, team.ID, userID)
+	if err != nil {
+		return "", err
+	}
+	if member == nil {
+		return "", nil
+	}
+
+	return member.Role, nil
+}
+
+// Authorize resolves the user's role in the team and checks it against the policy
+func Authorize(ctx context.Context, tx pgx.Tx, team *models.Team, userID int64, action Action) (bool, error) {
+	role, err := GetTeamRole(ctx, tx, team, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return Can(role, action), nil
+}
+
+// CanShare reports whether the user may share documents or invite people,
+// users must verify an email address first unless EMAIL_VERIFICATION_REQUIRED is off
+func CanShare(ctx context.Context, tx pgx.Tx, userID int64) (bool, error) {
+	if config.Env().EmailVerificationRequired == config.EmailVerificationOff {
+		return true, nil
+	}
+
+	return repository.IsUserVerified(ctx, tx, userID)
+}
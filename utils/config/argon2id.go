@@ -0,0 +1,14 @@
+package config
+
+import (
+	"ridash/utils/encrypt"
+)
+
+// GetArgon2idParams returns the cost new password hashes are made with
+func GetArgon2idParams() encrypt.Argon2idParams {
+	return encrypt.Argon2idParams{
+		Memory:      Env().Argon2idMemory,
+		Iterations:  Env().Argon2idIterations,
+		Parallelism: Env().Argon2idParallelism,
+	}
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/caarlos0/env/v10"
@@ -14,6 +17,32 @@ const (
 	AppEnvProd AppEnv = "prod"
 )
 
+type MailTransport string
+
+const (
+	MailTransportSMTP   MailTransport = "smtp"
+	MailTransportLog    MailTransport = "log"
+	MailTransportMemory MailTransport = "memory"
+)
+
+type EmailVerificationPolicy string
+
+const (
+	EmailVerificationOff   EmailVerificationPolicy = "off"   // Unverified accounts can do everything
+	EmailVerificationLogin EmailVerificationPolicy = "login" // Unverified email accounts can't log in
+	EmailVerificationShare EmailVerificationPolicy = "share" // Unverified users can't share documents or invite people
+)
+
+// OIDCProviderConfig is a generic OpenID Connect provider, read from OIDC_<NAME>_* variables
+type OIDCProviderConfig struct {
+	Name         string   `env:"-"`
+	IssuerURL    string   `env:"ISSUER_URL,required"`
+	ClientID     string   `env:"CLIENT_ID,required"`
+	ClientSecret string   `env:"CLIENT_SECRET,required"`
+	RedirectURL  string   `env:"REDIRECT_URL,required"`
+	Scopes       []string `env:"SCOPES" envSeparator:"," envDefault:"openid,email,profile"`
+}
+
 // EnvConfig holds all environment variables for the application
 type EnvConfig struct {
 	// PostgreSQL Settings
@@ -29,23 +58,89 @@ type EnvConfig struct {
 	AppMachineID int16  `env:"APP_MACHINE_ID" envDefault:"1"`
 	AppPort      string `env:"APP_PORT" envDefault:"8000"`
 
-	SMTPHost     string `env:"SMTP_HOST,required"`
+	SMTPHost     string `env:"SMTP_HOST"`
 	SMTPPort     int    `env:"SMTP_PORT" envDefault:"587"`
-	SMTPUsername string `env:"SMTP_USERNAME,required"`
-	SMTPPassword string `env:"SMTP_PASSWORD,required"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
 	SMTPFrom     string `env:"SMTP_FROM,required"`
 
-	GoogleClientID     string `env:"GOOGLE_CLIENT_ID,required"`
-	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET,required"`
-	GoogleRedirectURL  string `env:"GOOGLE_REDIRECT_URL,required"`
+	// Mail delivery, SMTP_HOST is only required by the smtp transport
+	MailTransport          MailTransport `env:"MAIL_TRANSPORT" envDefault:"smtp"`
+	MailLogFile            string        `env:"MAIL_LOG_FILE"`                            // log transport appends here, empty writes to the logger
+	MailOutboxPollInterval int           `env:"MAIL_OUTBOX_POLL_INTERVAL" envDefault:"5"` // 5 seconds
+	MailOutboxMaxAttempts  int           `env:"MAIL_OUTBOX_MAX_ATTEMPTS" envDefault:"8"`
+
+	// Google login, enabled when the client ID is set
+	GoogleClientID     string `env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET"`
+	GoogleRedirectURL  string `env:"GOOGLE_REDIRECT_URL"`
+
+	// GitHub OAuth2 login, enabled when the client ID is set
+	GitHubClientID     string `env:"GITHUB_CLIENT_ID"`
+	GitHubClientSecret string `env:"GITHUB_CLIENT_SECRET"`
+	GitHubRedirectURL  string `env:"GITHUB_REDIRECT_URL"`
+	GitHubAPIURL       string `env:"GITHUB_API_URL" envDefault:"https://api.github.com"`
+	GitHubAuthURL      string `env:"GITHUB_AUTH_URL" envDefault:"https://github.com/login/oauth/authorize"`
+	GitHubTokenURL     string `env:"GITHUB_TOKEN_URL" envDefault:"https://github.com/login/oauth/access_token"`
+
+	// Generic OIDC providers, each name in the list is configured by OIDC_<NAME>_* variables
+	OIDCProviderNames []string             `env:"OIDC_PROVIDERS" envSeparator:","`
+	OIDCProviders     []OIDCProviderConfig `env:"-"`
+
+	// Passkeys, the relying party ID is the domain the credentials are bound to
+	WebAuthnRPID      string   `env:"WEBAUTHN_RP_ID" envDefault:"localhost"`
+	WebAuthnRPOrigins []string `env:"WEBAUTHN_RP_ORIGINS" envSeparator:"," envDefault:"http://localhost:8000"`
 
 	// Optional Settings
-	OAuthStateExpiresAt   int `env:"OAUTH_STATE_EXPIRES_AT" envDefault:"600"`        // 10 minutes
-	AccessTokenExpiresAt  int `env:"ACCESS_TOKEN_EXPIRES_AT" envDefault:"900"`       // 15 minutes
-	RefreshTokenExpiresAt int `env:"REFRESH_TOKEN_EXPIRES_AT" envDefault:"31536000"` // 365 days
+	OAuthStateExpiresAt         int `env:"OAUTH_STATE_EXPIRES_AT" envDefault:"600"`        // 10 minutes
+	OAuthDiscoveryRetryInterval int `env:"OAUTH_DISCOVERY_RETRY_INTERVAL" envDefault:"30"` // 30 seconds between OIDC discovery attempts
+	AccessTokenExpiresAt        int `env:"ACCESS_TOKEN_EXPIRES_AT" envDefault:"900"`       // 15 minutes
+	RefreshTokenExpiresAt       int `env:"REFRESH_TOKEN_EXPIRES_AT" envDefault:"31536000"` // 365 days
 
-	JWTSecretKey   string `env:"JWT_SECRET_KEY,required" envDefault:"change_me_to_a_secure_key"`
-	FrontendDomain string `env:"FRONTEND_DOMAIN" envDefault:"localhost"`
+	AccessTokenRevocationReloadInterval int `env:"ACCESS_TOKEN_REVOCATION_RELOAD_INTERVAL" envDefault:"60"` // 1 minute between reloads of the denylist, besides the ones notified by Postgres
+
+	InvitationExpiresAt        int `env:"INVITATION_EXPIRES_AT" envDefault:"604800"`        // 7 days
+	EmailVerificationExpiresAt int `env:"EMAIL_VERIFICATION_EXPIRES_AT" envDefault:"86400"` // 1 day
+	PasswordResetExpiresAt     int `env:"PASSWORD_RESET_EXPIRES_AT" envDefault:"3600"`      // 1 hour
+	MagicLinkExpiresAt         int `env:"MAGIC_LINK_EXPIRES_AT" envDefault:"900"`           // 15 minutes
+	MFAChallengeExpiresAt      int `env:"MFA_CHALLENGE_EXPIRES_AT" envDefault:"300"`        // 5 minutes
+	WebAuthnChallengeExpiresAt int `env:"WEBAUTHN_CHALLENGE_EXPIRES_AT" envDefault:"300"`   // 5 minutes
+
+	MFAChallengeMaxAttempts int `env:"MFA_CHALLENGE_MAX_ATTEMPTS" envDefault:"5"` // Wrong codes an MFA token takes before the password step has to be redone
+
+	// Login links sent within the window, past the email limit requests are answered the same but send nothing
+	MagicLinkEmailLimit  int `env:"MAGIC_LINK_EMAIL_LIMIT" envDefault:"5"`
+	MagicLinkIPLimit     int `env:"MAGIC_LINK_IP_LIMIT" envDefault:"50"`
+	MagicLinkLimitWindow int `env:"MAGIC_LINK_LIMIT_WINDOW" envDefault:"3600"` // 1 hour
+
+	// Cost of new password hashes, weaker hashes are upgraded when their user logs in
+	Argon2idMemory      uint32 `env:"ARGON2ID_MEMORY" envDefault:"131072"` // 128 MiB, in KiB
+	Argon2idIterations  uint32 `env:"ARGON2ID_ITERATIONS" envDefault:"15"`
+	Argon2idParallelism uint8  `env:"ARGON2ID_PARALLELISM" envDefault:"4"`
+
+	// Brute-force protection of the password login, counted per email and per IP
+	LoginFreeFailures      int `env:"LOGIN_FREE_FAILURES" envDefault:"3"`         // Failed logins of an email before each attempt has to wait
+	LoginBaseDelay         int `env:"LOGIN_BASE_DELAY" envDefault:"1"`            // 1 second to wait after the first delayed failure, doubled by each one after
+	LoginMaxDelay          int `env:"LOGIN_MAX_DELAY" envDefault:"60"`            // 1 minute at most between attempts
+	LoginLockoutFailures   int `env:"LOGIN_LOCKOUT_FAILURES" envDefault:"10"`     // Failed logins that lock an email, an unlock link is emailed
+	LoginIPLockoutFailures int `env:"LOGIN_IP_LOCKOUT_FAILURES" envDefault:"100"` // Failed logins that lock an IP address, across every email
+	LoginLockoutDuration   int `env:"LOGIN_LOCKOUT_DURATION" envDefault:"900"`    // 15 minutes
+	LoginFailureWindow     int `env:"LOGIN_FAILURE_WINDOW" envDefault:"3600"`     // 1 hour without failures forgets the previous ones
+
+	EmailVerificationRequired EmailVerificationPolicy `env:"EMAIL_VERIFICATION_REQUIRED" envDefault:"off"`
+	SelfSignupEnabled         bool                    `env:"SELF_SIGNUP_ENABLED" envDefault:"true"`   // Without it only invited people can create a user
+	SecurityAlertEmails       bool                    `env:"SECURITY_ALERT_EMAILS" envDefault:"true"` // Email users when a security event is recorded
+
+	// Users allowed on the /admin routes, such as disabling an account
+	AdminUserIDs []int64 `env:"ADMIN_USER_IDS" envSeparator:","`
+
+	JWTSecretKey           string `env:"JWT_SECRET_KEY,required"`                        // Signs the tokens only Ridash reads and seals the stored signing keys
+	JWTSigningAlgorithm    string `env:"JWT_SIGNING_ALGORITHM" envDefault:"EdDSA"`       // RS256 or EdDSA, used for the keys generated from now on
+	JWTKeyRotationInterval int    `env:"JWT_KEY_ROTATION_INTERVAL" envDefault:"2592000"` // 30 days between signing keys
+	JWTKeyPublishAhead     int    `env:"JWT_KEY_PUBLISH_AHEAD" envDefault:"86400"`       // 1 day in the JWKS before a new key signs
+	JWTKeyRefreshInterval  int    `env:"JWT_KEY_REFRESH_INTERVAL" envDefault:"300"`      // 5 minutes between reloads of the signing keys
+	FrontendDomain         string `env:"FRONTEND_DOMAIN" envDefault:"localhost"`
+	FrontendURL            string `env:"FRONTEND_URL" envDefault:"http://localhost:8000"`
 
 	// Document manager
 	DocManagerBaseURL  string `env:"DOC_MANAGER_BASE_URL,required"`
@@ -57,6 +152,9 @@ var (
 	once      sync.Once
 )
 
+// minJWTSecretKeyLength keeps the HMAC secret out of reach of brute force
+const minJWTSecretKeyLength = 32
+
 // loadConfig loads and validates all environment variables
 func loadConfig() (*EnvConfig, error) {
 	cfg := &EnvConfig{}
@@ -64,9 +162,89 @@ func loadConfig() (*EnvConfig, error) {
 		return nil, err
 	}
 
+	if len(cfg.JWTSecretKey) < minJWTSecretKeyLength {
+		return nil, fmt.Errorf("JWT_SECRET_KEY must be at least %d characters", minJWTSecretKeyLength)
+	}
+
+	// time.NewTicker panics on anything else
+	if cfg.MailOutboxPollInterval <= 0 {
+		return nil, fmt.Errorf("MAIL_OUTBOX_POLL_INTERVAL must be positive")
+	}
+
+	if cfg.MagicLinkEmailLimit <= 0 || cfg.MagicLinkIPLimit <= 0 || cfg.MagicLinkLimitWindow <= 0 {
+		return nil, fmt.Errorf("MAGIC_LINK_EMAIL_LIMIT, MAGIC_LINK_IP_LIMIT and MAGIC_LINK_LIMIT_WINDOW must be positive")
+	}
+
+	if cfg.MFAChallengeMaxAttempts <= 0 {
+		return nil, fmt.Errorf("MFA_CHALLENGE_MAX_ATTEMPTS must be positive")
+	}
+
+	if cfg.AccessTokenRevocationReloadInterval <= 0 {
+		return nil, fmt.Errorf("ACCESS_TOKEN_REVOCATION_RELOAD_INTERVAL must be positive")
+	}
+
+	// Argon2 needs 8 KiB per lane
+	if cfg.Argon2idIterations == 0 || cfg.Argon2idParallelism == 0 || cfg.Argon2idMemory < 8*uint32(cfg.Argon2idParallelism) {
+		return nil, fmt.Errorf("Argon2id parameters must satisfy ARGON2ID_ITERATIONS > 0, ARGON2ID_PARALLELISM > 0 and ARGON2ID_MEMORY >= 8 * ARGON2ID_PARALLELISM")
+	}
+
+	if cfg.LoginBaseDelay <= 0 || cfg.LoginMaxDelay < cfg.LoginBaseDelay || cfg.LoginLockoutDuration <= 0 || cfg.LoginFailureWindow <= 0 {
+		return nil, fmt.Errorf("LOGIN_BASE_DELAY, LOGIN_MAX_DELAY, LOGIN_LOCKOUT_DURATION and LOGIN_FAILURE_WINDOW must be positive, with LOGIN_BASE_DELAY <= LOGIN_MAX_DELAY")
+	}
+
+	if cfg.LoginFreeFailures < 0 || cfg.LoginLockoutFailures <= cfg.LoginFreeFailures || cfg.LoginIPLockoutFailures <= 0 {
+		return nil, fmt.Errorf("login failures must satisfy 0 <= LOGIN_FREE_FAILURES < LOGIN_LOCKOUT_FAILURES and LOGIN_IP_LOCKOUT_FAILURES > 0")
+	}
+
+	// Every instance must load a new key before it signs, and consumers must see it in the JWKS first
+	if cfg.JWTKeyRefreshInterval <= 0 || cfg.JWTKeyPublishAhead < cfg.JWTKeyRefreshInterval || cfg.JWTKeyRotationInterval <= cfg.JWTKeyPublishAhead {
+		return nil, fmt.Errorf("JWT key intervals must satisfy 0 < JWT_KEY_REFRESH_INTERVAL <= JWT_KEY_PUBLISH_AHEAD < JWT_KEY_ROTATION_INTERVAL")
+	}
+
+	providers, err := loadOIDCProviders(cfg.OIDCProviderNames)
+	if err != nil {
+		return nil, err
+	}
+	cfg.OIDCProviders = providers
+
 	return cfg, nil
 }
 
+var oidcProviderNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// loadOIDCProviders reads the settings of every configured OIDC provider
+func loadOIDCProviders(names []string) ([]OIDCProviderConfig, error) {
+	providers := make([]OIDCProviderConfig, 0, len(names))
+	seen := make(map[string]bool, len(names))
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if !oidcProviderNamePattern.MatchString(name) || len(name) > 64 {
+			return nil, fmt.Errorf("invalid OIDC provider name %q", name)
+		}
+
+		// email, google and github have their own handling, and names differing only by - and _ would share their settings
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		if name == "email" || name == "google" || name == "github" || seen[prefix] {
+			return nil, fmt.Errorf("OIDC provider name %q is reserved or duplicated", name)
+		}
+		seen[prefix] = true
+
+		provider := OIDCProviderConfig{Name: name}
+		if err := env.ParseWithOptions(&provider, env.Options{Prefix: prefix}); err != nil {
+			return nil, fmt.Errorf("failed to load OIDC provider %q: %w", name, err)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
 // InitConfig initializes the config only once
 func InitConfig() (*EnvConfig, error) {
 	var err error
@@ -2,7 +2,11 @@ package config
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"ridash/models"
+	"sync"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"go.uber.org/zap"
@@ -10,36 +14,132 @@ import (
 	"golang.org/x/oauth2/google"
 )
 
+// ErrOAuthProviderUnavailable is returned while the discovery document of a provider can't be fetched
+var ErrOAuthProviderUnavailable = errors.New("oauth provider unavailable")
+
 // OAuthConfig is the configuration for the OAuth providers
 type OAuthConfig struct {
-	Providers     map[models.Provider]*oauth2.Config
-	OIDCProviders map[models.Provider]*oidc.Provider
+	Providers map[models.Provider]*OAuthProvider
 }
 
-// GetOAuthConfig returns the OAuth2 configuration for Google
+// OAuthProvider is one configured OAuth provider. OIDC providers discover their endpoints
+// on first use, so the server starts even when the issuer can't be reached
+type OAuthProvider struct {
+	Name      models.Provider
+	IssuerURL string // Empty for plain OAuth2 providers
+
+	config        oauth2.Config
+	retryInterval time.Duration
+
+	mu          sync.Mutex
+	oidc        *oidc.Provider
+	lastAttempt time.Time
+	lastErr     error
+}
+
+// GetOAuthConfig returns the configured OAuth providers, only the ones with a client ID are enabled.
+// Nothing is fetched here, OIDC discovery happens on the first request using the provider
 func GetOAuthConfig() (*OAuthConfig, error) {
-	googleOauthConfig := &oauth2.Config{
-		RedirectURL:  Env().GoogleRedirectURL,
-		ClientID:     Env().GoogleClientID,
-		ClientSecret: Env().GoogleClientSecret,
-		Scopes:       []string{"openid", "email", "profile"},
-		Endpoint:     google.Endpoint,
+	retryInterval := time.Duration(Env().OAuthDiscoveryRetryInterval) * time.Second
+	oauthConfig := &OAuthConfig{
+		Providers: map[models.Provider]*OAuthProvider{},
+	}
+
+	if Env().GoogleClientID != "" {
+		oauthConfig.Providers[models.ProviderGoogle] = &OAuthProvider{
+			Name:      models.ProviderGoogle,
+			IssuerURL: "https://accounts.google.com",
+			config: oauth2.Config{
+				RedirectURL:  Env().GoogleRedirectURL,
+				ClientID:     Env().GoogleClientID,
+				ClientSecret: Env().GoogleClientSecret,
+				Scopes:       []string{"openid", "email", "profile"},
+				Endpoint:     google.Endpoint,
+			},
+			retryInterval: retryInterval,
+		}
+	}
+
+	// GitHub is plain OAuth2, the profile comes from its API instead of an ID token
+	if Env().GitHubClientID != "" {
+		oauthConfig.Providers[models.ProviderGitHub] = &OAuthProvider{
+			Name: models.ProviderGitHub,
+			config: oauth2.Config{
+				RedirectURL:  Env().GitHubRedirectURL,
+				ClientID:     Env().GitHubClientID,
+				ClientSecret: Env().GitHubClientSecret,
+				Scopes:       []string{"read:user", "user:email"},
+				Endpoint: oauth2.Endpoint{
+					AuthURL:  Env().GitHubAuthURL,
+					TokenURL: Env().GitHubTokenURL,
+				},
+			},
+		}
+	}
+
+	// Generic providers take their endpoints from the issuer discovery document
+	for _, settings := range Env().OIDCProviders {
+		provider := models.Provider(settings.Name)
+		oauthConfig.Providers[provider] = &OAuthProvider{
+			Name:      provider,
+			IssuerURL: settings.IssuerURL,
+			config: oauth2.Config{
+				RedirectURL:  settings.RedirectURL,
+				ClientID:     settings.ClientID,
+				ClientSecret: settings.ClientSecret,
+				Scopes:       settings.Scopes,
+			},
+			retryInterval: retryInterval,
+		}
+	}
+
+	for _, provider := range oauthConfig.Providers {
+		if provider.config.ClientSecret == "" || provider.config.RedirectURL == "" {
+			return nil, fmt.Errorf("oauth provider %s needs a client secret and a redirect URL", provider.Name)
+		}
+	}
+
+	return oauthConfig, nil
+}
+
+// HasProvider reports whether the OAuth provider is configured
+func (o *OAuthConfig) HasProvider(provider models.Provider) bool {
+	_, ok := o.Providers[provider]
+	return ok
+}
+
+// Resolve returns the OAuth2 config of the provider and, for OIDC providers, the discovered OIDC provider.
+// A failed discovery is retried by the first call after the retry interval, calls in between get the error back
+func (p *OAuthProvider) Resolve(ctx context.Context) (*oauth2.Config, *oidc.Provider, error) {
+	if p.IssuerURL == "" {
+		return &p.config, nil, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oidc != nil {
+		return &p.config, p.oidc, nil
+	}
+
+	if p.lastErr != nil && time.Since(p.lastAttempt) < p.retryInterval {
+		return nil, nil, p.lastErr
 	}
 
-	// Create OIDC provider for Google
-	ctx := context.Background()
-	googleOIDCProvider, err := oidc.NewProvider(ctx, "https://accounts.google.com")
+	p.lastAttempt = time.Now()
+	oidcProvider, err := oidc.NewProvider(ctx, p.IssuerURL)
 	if err != nil {
-		zap.L().Error("failed to create google oidc provider", zap.Error(err))
-		return nil, err
-	}
-
-	return &OAuthConfig{
-		Providers: map[models.Provider]*oauth2.Config{
-			models.ProviderGoogle: googleOauthConfig,
-		},
-		OIDCProviders: map[models.Provider]*oidc.Provider{
-			models.ProviderGoogle: googleOIDCProvider,
-		},
-	}, nil
+		zap.L().Warn("failed to discover oidc provider", zap.String("provider", string(p.Name)), zap.Error(err))
+		p.lastErr = fmt.Errorf("%w: %s: %v", ErrOAuthProviderUnavailable, p.Name, err)
+		return nil, nil, p.lastErr
+	}
+
+	// Providers without a fixed endpoint use the discovered one
+	if p.config.Endpoint.AuthURL == "" {
+		p.config.Endpoint = oidcProvider.Endpoint()
+	}
+	p.oidc = oidcProvider
+	p.lastErr = nil
+
+	return &p.config, p.oidc, nil
 }
@@ -0,0 +1,14 @@
+package config
+
+import (
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// GetWebAuthn returns the relying party used for passkey ceremonies
+func GetWebAuthn() (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          Env().WebAuthnRPID,
+		RPDisplayName: Env().AppName,
+		RPOrigins:     Env().WebAuthnRPOrigins,
+	})
+}
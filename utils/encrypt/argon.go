@@ -19,14 +19,28 @@ type params struct {
 	keyLength   uint32
 }
 
+// Salt and key lengths of new hashes, they aren't configurable
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2idParams is the cost of new hashes. Each hash records its own parameters, so raising them
+// only affects the hashes made from then on
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
 // CreateArgon2idHash generate a Argon2id hash for the password
-func CreateArgon2idHash(password string) (string, error) {
+func CreateArgon2idHash(password string, target Argon2idParams) (string, error) {
 	p := &params{
-		memory:      128 * 1024,
-		iterations:  15,
-		parallelism: 4,
-		saltLength:  16,
-		keyLength:   32,
+		memory:      target.Memory,
+		iterations:  target.Iterations,
+		parallelism: target.Parallelism,
+		saltLength:  argon2idSaltLength,
+		keyLength:   argon2idKeyLength,
 	}
 
 	salt := make([]byte, p.saltLength)
@@ -60,6 +74,28 @@ func ComparePasswordAndHash(password, encodedHash string) (match bool, err error
 	return false, nil
 }
 
+// Argon2idHashNeedsUpgrade reports whether the hash was made with any parameter weaker than the target,
+// the password should then be hashed again while it is known with the returned parameters. Those keep
+// whatever the hash already had stronger than the target, so an upgrade never lowers a parameter.
+func Argon2idHashNeedsUpgrade(encodedHash string, target Argon2idParams) (bool, Argon2idParams, error) {
+	p, _, _, err := decodeHash(encodedHash)
+	if err != nil {
+		return false, Argon2idParams{}, err
+	}
+
+	needsUpgrade := p.memory < target.Memory ||
+		p.iterations < target.Iterations ||
+		p.parallelism < target.Parallelism ||
+		p.saltLength < argon2idSaltLength ||
+		p.keyLength < argon2idKeyLength
+
+	return needsUpgrade, Argon2idParams{
+		Memory:      max(p.memory, target.Memory),
+		Iterations:  max(p.iterations, target.Iterations),
+		Parallelism: max(p.parallelism, target.Parallelism),
+	}, nil
+}
+
 // decodeHash decode the hash
 func decodeHash(encodedHash string) (p *params, salt, hash []byte, err error) {
 	vals := strings.Split(encodedHash, "$")
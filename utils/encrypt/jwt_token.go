@@ -2,12 +2,14 @@ package encrypt
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTSecret is the secret for the JWT
+// JWTSecret is the secret for the JWTs only Ridash reads, access tokens are signed by a SigningKey instead
 // We doing this because this make the function more testable
 type JWTSecret struct {
 	Secret string
@@ -15,48 +17,73 @@ type JWTSecret struct {
 
 // AccessTokenClaims is the claims for the access token
 type AccessTokenClaims struct {
-	Issuer    string `json:"iss"`
-	Subject   string `json:"sub"`
-	ExpiresAt int64  `json:"exp"`
-	IssuedAt  int64  `json:"iat"`
+	ID        string    `json:"jti"` // Names the token in the revocation store
+	Issuer    string    `json:"iss"`
+	Subject   string    `json:"sub"`
+	ExpiresAt int64     `json:"exp"`
+	IssuedAt  time.Time `json:"iat"` // Millisecond precision, so a revocation can tell apart the tokens issued in the same second
 }
 
-// GenerateAccessToken generate an access token
-func (j *JWTSecret) GenerateAccessToken(issuer string, subject string, expiresAt time.Time) (string, error) {
+// accessTokenIDLength is the length of the random jti
+const accessTokenIDLength = 22
+
+// GenerateAccessToken generate an access token signed by the key, the kid header names the key
+func (k *SigningKey) GenerateAccessToken(issuer string, subject string, expiresAt time.Time) (string, error) {
+	tokenID, err := GenerateRandomString(accessTokenIDLength)
+	if err != nil {
+		return "", err
+	}
+
 	claims := AccessTokenClaims{
+		ID:        tokenID,
 		Issuer:    issuer,
 		Subject:   subject,
 		ExpiresAt: expiresAt.Unix(),
-		IssuedAt:  time.Now().Unix(),
+		IssuedAt:  time.Now(),
 	}
 
 	// TODO: Maybe need a way to covert the struct to map
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	token := jwt.NewWithClaims(k.signingMethod(), jwt.MapClaims{
+		"jti": claims.ID,
 		"iss": claims.Issuer,
 		"sub": claims.Subject,
 		"exp": claims.ExpiresAt,
-		"iat": claims.IssuedAt,
+		"iat": float64(claims.IssuedAt.UnixMilli()) / 1000,
 	})
+	token.Header["kid"] = k.ID
 
-	return token.SignedString([]byte(j.Secret))
+	return token.SignedString(k.Private)
 }
 
-// ValidateAccessTokenAndGetClaims validate the access token and get the claims
-func (j *JWTSecret) ValidateAccessTokenAndGetClaims(token string) (bool, AccessTokenClaims, error) {
+// ValidateAccessTokenAndGetClaims validate the access token with the key its kid header names and get the claims.
+// Tokens that don't verify are reported as invalid rather than as an error
+func ValidateAccessTokenAndGetClaims(token string, keyByID func(kid string) *SigningKey) (bool, AccessTokenClaims, error) {
 	claims := jwt.MapClaims{}
 	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
-		return []byte(j.Secret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		key := keyByID(kid)
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
 
-	if err != nil {
-		if err == jwt.ErrTokenInvalidClaims {
-			return false, AccessTokenClaims{}, nil
+		// The algorithm comes from the key, never from the token
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("signing key %q doesn't use %s", kid, token.Method.Alg())
 		}
 
-		return false, AccessTokenClaims{}, err
+		return key.Public, nil
+	}, jwt.WithValidMethods([]string{SigningAlgorithmRS256, SigningAlgorithmEdDSA}))
+
+	if err != nil {
+		return false, AccessTokenClaims{}, nil
 	}
 
 	// TODO: Maybe need a more clean way to covert the map to struct
+	tokenID, ok := claims["jti"].(string)
+	if !ok {
+		return false, AccessTokenClaims{}, nil
+	}
+
 	issuer, ok := claims["iss"].(string)
 	if !ok {
 		return false, AccessTokenClaims{}, nil
@@ -78,10 +105,11 @@ func (j *JWTSecret) ValidateAccessTokenAndGetClaims(token string) (bool, AccessT
 	}
 
 	accessTokenClaims := AccessTokenClaims{
+		ID:        tokenID,
 		Issuer:    issuer,
 		Subject:   subject,
 		ExpiresAt: int64(expiresAt),
-		IssuedAt:  int64(issuedAt),
+		IssuedAt:  time.UnixMilli(int64(math.Round(issuedAt * 1000))),
 	}
 
 	return true, accessTokenClaims, nil
@@ -89,27 +117,30 @@ func (j *JWTSecret) ValidateAccessTokenAndGetClaims(token string) (bool, AccessT
 
 // OAuthStateClaims is the claims for the oauth state
 type OAuthStateClaims struct {
-	State       string `json:"state"`
-	RedirectURI string `json:"redirect_uri"`
-	ExpiresAt   int64  `json:"exp"`
-	Subject     string `json:"sub"`
+	State           string `json:"state"`
+	RedirectURI     string `json:"redirect_uri"`
+	ExpiresAt       int64  `json:"exp"`
+	Subject         string `json:"sub"`
+	InvitationToken string `json:"invitation_token,omitempty"`
 }
 
 // GenerateOAuthState generate an oauth state
-func (j *JWTSecret) GenerateOAuthState(state string, redirectURI string, expiresAt time.Time, userID string) (string, error) {
+func (j *JWTSecret) GenerateOAuthState(state string, redirectURI string, expiresAt time.Time, userID string, invitationToken string) (string, error) {
 	claims := OAuthStateClaims{
-		State:       state,
-		RedirectURI: redirectURI,
-		ExpiresAt:   expiresAt.Unix(),
-		Subject:     userID,
+		State:           state,
+		RedirectURI:     redirectURI,
+		ExpiresAt:       expiresAt.Unix(),
+		Subject:         userID,
+		InvitationToken: invitationToken,
 	}
 
 	// TODO: Maybe need a way to covert the struct to map
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":          claims.Subject,
-		"state":        claims.State,
-		"redirect_uri": claims.RedirectURI,
-		"exp":          claims.ExpiresAt,
+		"sub":              claims.Subject,
+		"state":            claims.State,
+		"redirect_uri":     claims.RedirectURI,
+		"exp":              claims.ExpiresAt,
+		"invitation_token": claims.InvitationToken,
 	})
 
 	return token.SignedString([]byte(j.Secret))
@@ -146,11 +177,164 @@ func (j *JWTSecret) ValidateOAuthStateAndGetClaims(token string) (bool, OAuthSta
 		return false, OAuthStateClaims{}, nil
 	}
 
+	// The subject is empty when the flow was started without a logged in user
+	subject, _ := claims["sub"].(string)
+
+	// The invitation token is only set when the flow was started from an invitation
+	invitationToken, _ := claims["invitation_token"].(string)
+
 	oauthStateClaims := OAuthStateClaims{
-		State:       state,
-		RedirectURI: redirectURI,
-		ExpiresAt:   int64(expiresAt),
+		State:           state,
+		RedirectURI:     redirectURI,
+		ExpiresAt:       int64(expiresAt),
+		Subject:         subject,
+		InvitationToken: invitationToken,
 	}
 
 	return true, oauthStateClaims, nil
 }
+
+// EmailTokenClaims is the claims for tokens sent by email, such as team invitations
+type EmailTokenClaims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+	Purpose   string `json:"purpose"`
+}
+
+// Token purposes, so an email token can't be used as any other token
+const (
+	TokenPurposeInvitation        = "invitation"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMagicLink         = "magic_link"
+)
+
+// GenerateEmailToken generate a signed token for the purpose, bound to the email address
+func (j *JWTSecret) GenerateEmailToken(purpose string, subject string, email string, expiresAt time.Time) (string, error) {
+	claims := EmailTokenClaims{
+		Subject:   subject,
+		Email:     email,
+		ExpiresAt: expiresAt.Unix(),
+		Purpose:   purpose,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     claims.Subject,
+		"email":   claims.Email,
+		"exp":     claims.ExpiresAt,
+		"purpose": claims.Purpose,
+	})
+
+	return token.SignedString([]byte(j.Secret))
+}
+
+// ValidateEmailTokenAndGetClaims validate the email token was issued for the purpose and get the claims
+func (j *JWTSecret) ValidateEmailTokenAndGetClaims(token string, purpose string) (bool, EmailTokenClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+		return []byte(j.Secret), nil
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenInvalidClaims) || errors.Is(err, jwt.ErrTokenExpired) {
+			return false, EmailTokenClaims{}, nil
+		}
+
+		return false, EmailTokenClaims{}, err
+	}
+
+	tokenPurpose, ok := claims["purpose"].(string)
+	if !ok || tokenPurpose != purpose {
+		return false, EmailTokenClaims{}, nil
+	}
+
+	subject, ok := claims["sub"].(string)
+	if !ok {
+		return false, EmailTokenClaims{}, nil
+	}
+
+	email, ok := claims["email"].(string)
+	if !ok {
+		return false, EmailTokenClaims{}, nil
+	}
+
+	expiresAt, ok := claims["exp"].(float64)
+	if !ok {
+		return false, EmailTokenClaims{}, nil
+	}
+
+	emailTokenClaims := EmailTokenClaims{
+		Subject:   subject,
+		Email:     email,
+		ExpiresAt: int64(expiresAt),
+		Purpose:   tokenPurpose,
+	}
+
+	return true, emailTokenClaims, nil
+}
+
+// MFAChallengeClaims is the claims for the token proving the password step of a login succeeded
+type MFAChallengeClaims struct {
+	ID        string `json:"jti"`
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+	Purpose   string `json:"purpose"`
+}
+
+// TokenPurposeMFAChallenge marks the token handed out between the password and the second factor
+const TokenPurposeMFAChallenge = "mfa_challenge"
+
+// GenerateMFAChallengeToken generate a short-lived token to exchange for a session once the second factor is checked
+func (j *JWTSecret) GenerateMFAChallengeToken(challengeID string, subject string, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"jti":     challengeID,
+		"sub":     subject,
+		"exp":     expiresAt.Unix(),
+		"purpose": TokenPurposeMFAChallenge,
+	})
+
+	return token.SignedString([]byte(j.Secret))
+}
+
+// ValidateMFAChallengeTokenAndGetClaims validate the mfa challenge token and get the claims
+func (j *JWTSecret) ValidateMFAChallengeTokenAndGetClaims(token string) (bool, MFAChallengeClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+		return []byte(j.Secret), nil
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenInvalidClaims) || errors.Is(err, jwt.ErrTokenExpired) {
+			return false, MFAChallengeClaims{}, nil
+		}
+
+		return false, MFAChallengeClaims{}, err
+	}
+
+	tokenPurpose, ok := claims["purpose"].(string)
+	if !ok || tokenPurpose != TokenPurposeMFAChallenge {
+		return false, MFAChallengeClaims{}, nil
+	}
+
+	challengeID, ok := claims["jti"].(string)
+	if !ok {
+		return false, MFAChallengeClaims{}, nil
+	}
+
+	subject, ok := claims["sub"].(string)
+	if !ok {
+		return false, MFAChallengeClaims{}, nil
+	}
+
+	expiresAt, ok := claims["exp"].(float64)
+	if !ok {
+		return false, MFAChallengeClaims{}, nil
+	}
+
+	return true, MFAChallengeClaims{
+		ID:        challengeID,
+		Subject:   subject,
+		ExpiresAt: int64(expiresAt),
+		Purpose:   tokenPurpose,
+	}, nil
+}
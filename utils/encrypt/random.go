@@ -92,3 +92,16 @@ func GenerateRandomUserDisplayName() string {
 
 	return randomName
 }
+
+// PersonalAccessTokenPrefix starts every personal access token, so they are told apart from JWTs and found by secret scanners
+const PersonalAccessTokenPrefix = "rdpat_"
+
+// GeneratePersonalAccessToken generate a personal access token
+func GeneratePersonalAccessToken() (string, error) {
+	token, err := GenerateRandomString(40)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return PersonalAccessTokenPrefix + token, nil
+}
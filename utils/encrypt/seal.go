@@ -0,0 +1,49 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// newSecretCipher derives an AES-256-GCM cipher from the secret
+func newSecretCipher(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealWithSecret encrypts data at rest with the secret, the nonce is prepended to the result
+func SealWithSecret(secret string, plaintext []byte) ([]byte, error) {
+	aead, err := newSecretCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// OpenWithSecret decrypts data sealed by SealWithSecret, it fails when the secret changed
+func OpenWithSecret(secret string, sealed []byte) ([]byte, error) {
+	aead, err := newSecretCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,139 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Access token signing algorithms
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RS256 keys
+const rsaKeyBits = 3072
+
+// SigningKey is an asymmetric key for access tokens, its ID is sent as the kid header
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer // Nil when only the public key could be loaded
+	Public    crypto.PublicKey
+}
+
+// IsValidSigningAlgorithm reports whether access tokens can be signed with the algorithm
+func IsValidSigningAlgorithm(algorithm string) bool {
+	return algorithm == SigningAlgorithmRS256 || algorithm == SigningAlgorithmEdDSA
+}
+
+// GenerateSigningKey generate a new key pair for the algorithm
+func GenerateSigningKey(id string, algorithm string) (*SigningKey, error) {
+	switch algorithm {
+	case SigningAlgorithmRS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		return &SigningKey{ID: id, Algorithm: algorithm, Private: private, Public: private.Public()}, nil
+	case SigningAlgorithmEdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &SigningKey{ID: id, Algorithm: algorithm, Private: private, Public: public}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+}
+
+// ParseSigningKey loads a key from its DER encodings, the private key is optional
+func ParseSigningKey(id string, algorithm string, privateDER []byte, publicDER []byte) (*SigningKey, error) {
+	if !IsValidSigningAlgorithm(algorithm) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	public, err := x509.ParsePKIXPublicKey(publicDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	key := &SigningKey{ID: id, Algorithm: algorithm, Public: public}
+	if privateDER == nil {
+		return key, nil
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(privateDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("private key can't sign")
+	}
+	key.Private = signer
+
+	return key, nil
+}
+
+// MarshalPrivateKey encodes the private key as PKCS #8 DER
+func (k *SigningKey) MarshalPrivateKey() ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(k.Private)
+}
+
+// MarshalPublicKey encodes the public key as PKIX DER
+func (k *SigningKey) MarshalPublicKey() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(k.Public)
+}
+
+// signingMethod returns the JWT signing method of the key
+func (k *SigningKey) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == SigningAlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// JWK is the public part of a signing key as published in a JWKS, see RFC 7517
+type JWK struct {
+	Kty string `json:"kty" example:"OKP"`
+	Kid string `json:"kid" example:"175928847299117063"`
+	Use string `json:"use" example:"sig"`
+	Alg string `json:"alg" example:"EdDSA"`
+	Crv string `json:"crv,omitempty" example:"Ed25519"` // EdDSA keys only
+	X   string `json:"x,omitempty"`                     // EdDSA keys only
+	N   string `json:"n,omitempty"`                     // RS256 keys only
+	E   string `json:"e,omitempty" example:"AQAB"`      // RS256 keys only
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public key in the JWK format
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+
+	switch public := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
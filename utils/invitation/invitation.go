@@ -0,0 +1,178 @@
+package invitation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Invitation errors
+var (
+	ErrInvalidToken  = errors.New("invalid invitation token")
+	ErrNotFound      = errors.New("invitation not found")
+	ErrNotPending    = errors.New("invitation has already been answered")
+	ErrExpired       = errors.New("invitation has expired")
+	ErrEmailMismatch = errors.New("invitation was sent to another email address")
+)
+
+// GenerateToken signs the token that is emailed to the invitee
+func GenerateToken(invitation models.Invitation) (string, error) {
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	return secret.GenerateEmailToken(encrypt.TokenPurposeInvitation, strconv.FormatInt(invitation.ID, 10), invitation.Email, invitation.ExpiresAt)
+}
+
+// AcceptURL returns the frontend link the invitee opens to answer the invitation
+func AcceptURL(token string) string {
+	return config.Env().FrontendURL + "/invitations/accept?token=" + token
+}
+
+// GetPending validates the token and loads the pending invitation it was issued for,
+// the invitation stays locked until the transaction ends so concurrent answers wait for each other
+func GetPending(ctx context.Context, tx pgx.Tx, token string) (*models.Invitation, error) {
+	secret := encrypt.JWTSecret{
+		Secret: config.Env().JWTSecretKey,
+	}
+
+	// A token with a bad signature is as invalid as an expired one for the invitee
+	valid, claims, err := secret.ValidateEmailTokenAndGetClaims(token, encrypt.TokenPurposeInvitation)
+	if err != nil || !valid {
+		return nil, ErrInvalidToken
+	}
+
+	invitationID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	invitation, err := repository.GetInvitationByID(ctx, tx, invitationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation == nil || invitation.Email != claims.Email {
+		return nil, ErrNotFound
+	}
+
+	if invitation.Status != models.InvitationStatusPending {
+		return nil, ErrNotPending
+	}
+
+	if invitation.ExpiresAt.Before(time.Now()) {
+		return nil, ErrExpired
+	}
+
+	return invitation, nil
+}
+
+// Accept adds the user to the invited team and marks the invitation as accepted. The token can be forwarded
+// or leaked, so the invitation must also have been sent to one of the emails the user proved to own.
+func Accept(ctx context.Context, tx pgx.Tx, token string, userID int64, emails []string) (*models.TeamMember, error) {
+	invitation, err := GetPending(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(emails, func(email string) bool { return strings.EqualFold(email, invitation.Email) }) {
+		return nil, ErrEmailMismatch
+	}
+
+	now := time.Now()
+	updated, err := repository.UpdateInvitationStatus(ctx, tx, invitation.ID, models.InvitationStatusAccepted, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+	if !updated {
+		return nil, ErrNotPending
+	}
+
+	// The user might have been added to the team since the invitation was sent
+	existingMember, err := repository.GetTeamMemberByTeamAndUser(ctx, tx, invitation.TeamID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing team member: %w", err)
+	}
+	if existingMember != nil {
+		return existingMember, nil
+	}
+
+	teamMemberID, err := id.GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate team member ID: %w", err)
+	}
+
+	teamMember := models.TeamMember{
+		ID:        teamMemberID,
+		TeamID:    invitation.TeamID,
+		UserID:    userID,
+		Role:      invitation.Role,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := repository.CreateTeamMember(ctx, tx, teamMember); err != nil {
+		return nil, fmt.Errorf("failed to add team member: %w", err)
+	}
+
+	return &teamMember, nil
+}
+
+// VerifiedEmails returns the emails of the verified accounts of the user
+func VerifiedEmails(ctx context.Context, tx pgx.Tx, userID int64) ([]string, error) {
+	accounts, err := repository.ListAccountsByUserID(ctx, tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	emails := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if account.VerifiedAt != nil && account.Email != "" {
+			emails = append(emails, account.Email)
+		}
+	}
+
+	return emails, nil
+}
+
+// Decline marks the invitation as declined
+func Decline(ctx context.Context, tx pgx.Tx, token string) (*models.Invitation, error) {
+	invitation, err := GetPending(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updated, err := repository.UpdateInvitationStatus(ctx, tx, invitation.ID, models.InvitationStatusDeclined, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+	if !updated {
+		return nil, ErrNotPending
+	}
+
+	invitation.Status = models.InvitationStatusDeclined
+	invitation.RespondedAt = &now
+	invitation.UpdatedAt = now
+
+	return invitation, nil
+}
+
+// IsClientError reports whether the error was caused by the token the client sent
+func IsClientError(err error) bool {
+	return errors.Is(err, ErrInvalidToken) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrNotPending) ||
+		errors.Is(err, ErrExpired) ||
+		errors.Is(err, ErrEmailMismatch)
+}
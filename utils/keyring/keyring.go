@@ -0,0 +1,276 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// Keyring holds the keys signing access tokens. They are stored in Postgres so every instance shares them,
+// a new key is published ahead of the rotation and an old one is kept until the last token it signed expires
+type Keyring struct {
+	db                  *pgxpool.Pool
+	secret              string
+	algorithm           string
+	rotationInterval    time.Duration
+	publishAhead        time.Duration
+	accessTokenLifetime time.Duration
+	refreshInterval     time.Duration
+
+	mu      sync.RWMutex
+	signing *encrypt.SigningKey
+	keys    map[string]*encrypt.SigningKey
+}
+
+var defaultKeyring *Keyring
+
+// ErrNoSigningKey is returned when no key has been loaded yet
+var ErrNoSigningKey = errors.New("no signing key loaded")
+
+// New constructs a keyring, call Rotate to load the keys
+func New(db *pgxpool.Pool, secret string, algorithm string, rotationInterval time.Duration, publishAhead time.Duration, accessTokenLifetime time.Duration, refreshInterval time.Duration) (*Keyring, error) {
+	if !encrypt.IsValidSigningAlgorithm(algorithm) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	return &Keyring{
+		db:                  db,
+		secret:              secret,
+		algorithm:           algorithm,
+		rotationInterval:    rotationInterval,
+		publishAhead:        publishAhead,
+		accessTokenLifetime: accessTokenLifetime,
+		refreshInterval:     refreshInterval,
+		keys:                map[string]*encrypt.SigningKey{},
+	}, nil
+}
+
+// Init constructs the default keyring from the config and loads its keys
+func Init(db *pgxpool.Pool) (*Keyring, error) {
+	k, err := New(
+		db,
+		config.Env().JWTSecretKey,
+		config.Env().JWTSigningAlgorithm,
+		time.Duration(config.Env().JWTKeyRotationInterval)*time.Second,
+		time.Duration(config.Env().JWTKeyPublishAhead)*time.Second,
+		time.Duration(config.Env().AccessTokenExpiresAt)*time.Second,
+		time.Duration(config.Env().JWTKeyRefreshInterval)*time.Second,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := k.Rotate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	defaultKeyring = k
+	return k, nil
+}
+
+// Default returns the keyring set up by Init. Panics if not initialized.
+func Default() *Keyring {
+	if defaultKeyring == nil {
+		panic("keyring not initialized — call Init() first")
+	}
+	return defaultKeyring
+}
+
+// Run rotates and reloads the keys every refresh interval until the context is cancelled
+func (k *Keyring) Run(ctx context.Context) {
+	ticker := time.NewTicker(k.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := k.Rotate(ctx); err != nil {
+			zap.L().Error("Failed to rotate signing keys", zap.Error(err))
+		}
+	}
+}
+
+// Rotate drops the expired keys, generates the next key when one is due and loads the keys in memory
+func (k *Keyring) Rotate(ctx context.Context) error {
+	tx, err := repository.StartTransaction(k.db, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer repository.DeferRollback(tx, ctx)
+
+	if err := repository.LockSigningKeys(ctx, tx); err != nil {
+		return fmt.Errorf("failed to lock signing keys: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := repository.DeleteExpiredSigningKeys(ctx, tx, now); err != nil {
+		return fmt.Errorf("failed to delete expired signing keys: %w", err)
+	}
+
+	stored, err := repository.ListUnexpiredSigningKeys(ctx, tx, now)
+	if err != nil {
+		return fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	keys := make(map[string]*encrypt.SigningKey, len(stored)+1)
+	var signing *encrypt.SigningKey
+	var newest *models.SigningKey
+	for i := range stored {
+		key := k.loadKey(stored[i])
+		if key == nil {
+			continue
+		}
+
+		keys[key.ID] = key
+		if key.Private != nil && !stored[i].ActivatesAt.After(now) {
+			signing = key
+		}
+		newest = &stored[i]
+	}
+
+	// A key is due when nothing can sign, or early enough before the rotation for consumers to fetch it
+	if signing == nil || !now.Before(newest.ActivatesAt.Add(k.rotationInterval-k.publishAhead)) {
+		activatesAt := now
+		if signing != nil && newest.ActivatesAt.Add(k.rotationInterval).After(now) {
+			activatesAt = newest.ActivatesAt.Add(k.rotationInterval)
+		}
+
+		key, err := k.createKey(ctx, tx, now, activatesAt)
+		if err != nil {
+			return err
+		}
+
+		keys[key.ID] = key
+		if !activatesAt.After(now) {
+			signing = key
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	k.mu.Lock()
+	k.signing = signing
+	k.keys = keys
+	k.mu.Unlock()
+
+	return nil
+}
+
+// loadKey opens a stored key, a key sealed with another secret still verifies but can't sign
+func (k *Keyring) loadKey(stored models.SigningKey) *encrypt.SigningKey {
+	kid := strconv.FormatInt(stored.ID, 10)
+
+	privateDER, err := encrypt.OpenWithSecret(k.secret, stored.PrivateKey)
+	if err != nil {
+		zap.L().Warn("Signing key can't be opened with JWT_SECRET_KEY, it will only verify", zap.String("kid", kid))
+		privateDER = nil
+	}
+
+	key, err := encrypt.ParseSigningKey(kid, stored.Algorithm, privateDER, stored.PublicKey)
+	if err != nil {
+		zap.L().Error("Failed to parse signing key", zap.String("kid", kid), zap.Error(err))
+		return nil
+	}
+
+	return key
+}
+
+// createKey generates and stores a key signing from activatesAt
+func (k *Keyring) createKey(ctx context.Context, tx pgx.Tx, now time.Time, activatesAt time.Time) (*encrypt.SigningKey, error) {
+	keyID, err := id.GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ID: %w", err)
+	}
+
+	key, err := encrypt.GenerateSigningKey(strconv.FormatInt(keyID, 10), k.algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	privateDER, err := key.MarshalPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	sealed, err := encrypt.SealWithSecret(k.secret, privateDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal private key: %w", err)
+	}
+
+	publicDER, err := key.MarshalPublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	// The key verifies until its successor took over, every instance reloaded, and the last token it signed expired
+	if err := repository.CreateSigningKey(ctx, tx, models.SigningKey{
+		ID:          keyID,
+		Algorithm:   k.algorithm,
+		PrivateKey:  sealed,
+		PublicKey:   publicDER,
+		ActivatesAt: activatesAt,
+		ExpiresAt:   activatesAt.Add(k.rotationInterval + k.refreshInterval + k.accessTokenLifetime),
+		CreatedAt:   now,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save signing key: %w", err)
+	}
+
+	zap.L().Info("Signing key generated", zap.String("kid", key.ID), zap.String("algorithm", k.algorithm), zap.Time("activates_at", activatesAt))
+
+	return key, nil
+}
+
+// GenerateAccessToken generate an access token signed by the current key
+func (k *Keyring) GenerateAccessToken(issuer string, subject string, expiresAt time.Time) (string, error) {
+	k.mu.RLock()
+	signing := k.signing
+	k.mu.RUnlock()
+
+	if signing == nil {
+		return "", ErrNoSigningKey
+	}
+
+	return signing.GenerateAccessToken(issuer, subject, expiresAt)
+}
+
+// ValidateAccessTokenAndGetClaims validate the access token against the published keys and get the claims
+func (k *Keyring) ValidateAccessTokenAndGetClaims(token string) (bool, encrypt.AccessTokenClaims, error) {
+	return encrypt.ValidateAccessTokenAndGetClaims(token, func(kid string) *encrypt.SigningKey {
+		k.mu.RLock()
+		defer k.mu.RUnlock()
+		return k.keys[kid]
+	})
+}
+
+// JWKS returns the public keys that verify access tokens, including the next key once it is published
+func (k *Keyring) JWKS() encrypt.JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := encrypt.JWKS{Keys: make([]encrypt.JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+
+	return jwks
+}
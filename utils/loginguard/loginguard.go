@@ -0,0 +1,246 @@
+package loginguard
+
+import (
+	"context"
+	"fmt"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// pruneInterval is the pause between two deletions of the stale counters
+const pruneInterval = 10 * time.Minute
+
+// Policy decides how long failed password logins keep an email or an IP address waiting
+type Policy struct {
+	FreeFailures      int           // Failures of an email before each attempt has to wait
+	BaseDelay         time.Duration // Wait after the first delayed failure, doubled by each one after
+	MaxDelay          time.Duration // Longest wait between two attempts
+	LockoutFailures   int           // Failures that lock an email
+	IPLockoutFailures int           // Failures that lock an IP address
+	LockoutDuration   time.Duration // How long a lockout lasts
+	FailureWindow     time.Duration // Failures older than this are forgotten
+}
+
+// Blocked is returned by Check when the login must not be attempted yet
+type Blocked struct {
+	Locked     bool          // The email is locked, as opposed to waiting out a delay or an IP lockout
+	RetryAfter time.Duration // When the next attempt is allowed
+}
+
+func (b *Blocked) Error() string {
+	if b.Locked {
+		return fmt.Sprintf("email locked for %s", b.RetryAfter)
+	}
+	return fmt.Sprintf("login attempts blocked for %s", b.RetryAfter)
+}
+
+// Lockout is returned by RecordFailure when the failure locked the email
+type Lockout struct {
+	UnlockToken string    // Lifts the lockout through Unlock, it is emailed to the user
+	LockedUntil time.Time // When the lockout ends by itself
+}
+
+// Guard counts failed password logins per email and per IP address in Postgres, so every instance shares them
+type Guard struct {
+	db     *pgxpool.Pool
+	policy Policy
+}
+
+var defaultGuard *Guard
+
+// New constructs a guard
+func New(db *pgxpool.Pool, policy Policy) *Guard {
+	return &Guard{db: db, policy: policy}
+}
+
+// Init constructs the default guard from the config
+func Init(db *pgxpool.Pool) *Guard {
+	defaultGuard = New(db, Policy{
+		FreeFailures:      config.Env().LoginFreeFailures,
+		BaseDelay:         time.Duration(config.Env().LoginBaseDelay) * time.Second,
+		MaxDelay:          time.Duration(config.Env().LoginMaxDelay) * time.Second,
+		LockoutFailures:   config.Env().LoginLockoutFailures,
+		IPLockoutFailures: config.Env().LoginIPLockoutFailures,
+		LockoutDuration:   time.Duration(config.Env().LoginLockoutDuration) * time.Second,
+		FailureWindow:     time.Duration(config.Env().LoginFailureWindow) * time.Second,
+	})
+	return defaultGuard
+}
+
+// Default returns the guard set up by Init. Panics if not initialized.
+func Default() *Guard {
+	if defaultGuard == nil {
+		panic("login guard not initialized — call Init() first")
+	}
+	return defaultGuard
+}
+
+// Run deletes the stale counters every prune interval until the context is cancelled
+func (g *Guard) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := g.prune(ctx); err != nil {
+			zap.L().Error("Failed to prune login attempts", zap.Error(err))
+		}
+	}
+}
+
+// prune deletes the counters whose failures are all forgotten and that aren't locked
+func (g *Guard) prune(ctx context.Context) error {
+	tx, err := repository.StartTransaction(g.db, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer repository.DeferRollback(tx, ctx)
+
+	now := time.Now()
+	if _, err := repository.DeleteStaleLoginAttempts(ctx, tx, now.Add(-g.policy.FailureWindow), now); err != nil {
+		return fmt.Errorf("failed to delete stale login attempts: %w", err)
+	}
+
+	return repository.CommitTransaction(tx, ctx)
+}
+
+// NormalizeEmail is the key the failures of an email are counted under
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check returns a *Blocked error when the login must not be attempted yet. The counter of the email stays locked
+// until the transaction ends, so concurrent attempts for it are checked one after the other.
+func (g *Guard) Check(ctx context.Context, tx pgx.Tx, email string, ip string, now time.Time) error {
+	emailAttempt, err := repository.LockLoginAttempt(ctx, tx, models.LoginAttemptKindEmail, NormalizeEmail(email), now)
+	if err != nil {
+		return fmt.Errorf("failed to lock login attempt: %w", err)
+	}
+
+	if emailAttempt == nil {
+		return fmt.Errorf("login attempt of %q not found after locking it", NormalizeEmail(email))
+	}
+
+	if emailAttempt.LockedUntil != nil && now.Before(*emailAttempt.LockedUntil) {
+		return &Blocked{Locked: true, RetryAfter: emailAttempt.LockedUntil.Sub(now)}
+	}
+
+	ipAttempt, err := repository.GetLoginAttempt(ctx, tx, models.LoginAttemptKindIP, ip)
+	if err != nil {
+		return fmt.Errorf("failed to get login attempt: %w", err)
+	}
+
+	if ipAttempt != nil && ipAttempt.LockedUntil != nil && now.Before(*ipAttempt.LockedUntil) {
+		return &Blocked{RetryAfter: ipAttempt.LockedUntil.Sub(now)}
+	}
+
+	if wait := g.wait(*emailAttempt, now); wait > 0 {
+		return &Blocked{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// wait is how long the email has to wait before its next attempt
+func (g *Guard) wait(attempt models.LoginAttempt, now time.Time) time.Duration {
+	if attempt.LastFailedAt == nil || attempt.LastFailedAt.Before(now.Add(-g.policy.FailureWindow)) {
+		return 0
+	}
+
+	delayed := attempt.Failures - g.policy.FreeFailures
+	if delayed <= 0 {
+		return 0
+	}
+
+	delay := g.policy.MaxDelay
+	if delayed <= 32 {
+		delay = min(g.policy.BaseDelay<<(delayed-1), g.policy.MaxDelay)
+	}
+
+	return attempt.LastFailedAt.Add(delay).Sub(now)
+}
+
+// RecordFailure counts a failed login for the email and the IP address and locks them once they reach the policy.
+// The lockout is only returned when this failure locked the email.
+func (g *Guard) RecordFailure(ctx context.Context, tx pgx.Tx, email string, ip string, now time.Time) (*Lockout, error) {
+	windowStart := now.Add(-g.policy.FailureWindow)
+	lockedUntil := now.Add(g.policy.LockoutDuration)
+
+	ipAttempt, err := repository.RecordLoginAttemptFailure(ctx, tx, models.LoginAttemptKindIP, ip, now, windowStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	if ipAttempt.Failures >= g.policy.IPLockoutFailures {
+		if err := repository.LockLoginAttemptUntil(ctx, tx, models.LoginAttemptKindIP, ip, lockedUntil, nil, now); err != nil {
+			return nil, fmt.Errorf("failed to lock login attempt: %w", err)
+		}
+		zap.L().Warn("IP address locked after failed logins", zap.String("ip", ip), zap.Time("locked_until", lockedUntil))
+	}
+
+	key := NormalizeEmail(email)
+	emailAttempt, err := repository.RecordLoginAttemptFailure(ctx, tx, models.LoginAttemptKindEmail, key, now, windowStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	if emailAttempt.Failures < g.policy.LockoutFailures {
+		return nil, nil
+	}
+
+	unlockToken, err := encrypt.GenerateRandomString(48)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate unlock token: %w", err)
+	}
+
+	unlockTokenHash := encrypt.HashToken(unlockToken)
+	if err := repository.LockLoginAttemptUntil(ctx, tx, models.LoginAttemptKindEmail, key, lockedUntil, &unlockTokenHash, now); err != nil {
+		return nil, fmt.Errorf("failed to lock login attempt: %w", err)
+	}
+
+	return &Lockout{UnlockToken: unlockToken, LockedUntil: lockedUntil}, nil
+}
+
+// RecordSuccess forgets the failures of the email. Those of the IP address are kept, logging into
+// one's own account must not reset the count of guesses against the others.
+func (g *Guard) RecordSuccess(ctx context.Context, tx pgx.Tx, email string, now time.Time) error {
+	if err := repository.ResetLoginAttempt(ctx, tx, models.LoginAttemptKindEmail, NormalizeEmail(email), now); err != nil {
+		return fmt.Errorf("failed to reset login attempt: %w", err)
+	}
+	return nil
+}
+
+// Unlock lifts the lockout an unlock token was emailed for, and returns false when the token is unknown or the lockout is over
+func (g *Guard) Unlock(ctx context.Context, tx pgx.Tx, unlockToken string, now time.Time) (bool, error) {
+	unlocked, err := repository.DeleteLockedLoginAttemptByUnlockTokenHash(ctx, tx, encrypt.HashToken(unlockToken), now)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete login attempt: %w", err)
+	}
+	return unlocked, nil
+}
+
+// UnlockEmails forgets the failures and lifts the lockouts of the emails
+func (g *Guard) UnlockEmails(ctx context.Context, tx pgx.Tx, emails []string) error {
+	keys := make([]string, 0, len(emails))
+	for _, email := range emails {
+		keys = append(keys, NormalizeEmail(email))
+	}
+
+	if _, err := repository.DeleteLoginAttemptsByKeys(ctx, tx, models.LoginAttemptKindEmail, keys); err != nil {
+		return fmt.Errorf("failed to delete login attempts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogTransport writes messages to a file or the logger instead of sending them, meant for development
+type LogTransport struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogTransport returns a transport appending to path, or logging through zap when path is empty
+func NewLogTransport(path string) *LogTransport {
+	return &LogTransport{path: path}
+}
+
+// Send records the message
+func (t *LogTransport) Send(ctx context.Context, msg Message) error {
+	if t.path == "" {
+		zap.L().Info("Email sent to log transport",
+			zap.String("from", msg.From),
+			zap.String("to", msg.To),
+			zap.String("subject", msg.Subject),
+			zap.String("text", msg.Text))
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	file, err := os.OpenFile(t.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail log file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n----\n\n",
+		time.Now().Format(time.RFC1123Z), msg.From, msg.To, msg.Subject, msg.Text)
+	if err != nil {
+		return fmt.Errorf("failed to write mail log file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/email"
+	"ridash/utils/id"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Message is a rendered email handed to a transport
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport delivers a rendered message
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Mailer renders templates into the outbox and drains it through a transport
+type Mailer struct {
+	db           *pgxpool.Pool
+	transport    Transport
+	from         string
+	pollInterval time.Duration
+	maxAttempts  int
+	batchSize    int
+}
+
+var defaultMailer *Mailer
+
+// New constructs a mailer, the from address is validated
+func New(db *pgxpool.Pool, transport Transport, from string, pollInterval time.Duration, maxAttempts int) (*Mailer, error) {
+	validatedFrom, err := email.ValidateAddress(from)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &Mailer{
+		db:           db,
+		transport:    transport,
+		from:         validatedFrom,
+		pollInterval: pollInterval,
+		maxAttempts:  maxAttempts,
+		batchSize:    20,
+	}, nil
+}
+
+// Init builds the default mailer with the transport selected by MAIL_TRANSPORT
+func Init(db *pgxpool.Pool) (*Mailer, error) {
+	transport, err := newTransportFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := New(
+		db,
+		transport,
+		config.Env().SMTPFrom,
+		time.Duration(config.Env().MailOutboxPollInterval)*time.Second,
+		config.Env().MailOutboxMaxAttempts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultMailer = m
+	return m, nil
+}
+
+// Default returns the mailer built by Init. Panics if not initialized.
+func Default() *Mailer {
+	if defaultMailer == nil {
+		panic("mailer not initialized — call Init() first")
+	}
+	return defaultMailer
+}
+
+// Transport returns the transport the outbox is drained through
+func (m *Mailer) Transport() Transport {
+	return m.transport
+}
+
+// Enqueue renders the template and stores it in the outbox inside the caller's transaction,
+// so the email is only sent if the transaction commits
+func (m *Mailer) Enqueue(ctx context.Context, tx pgx.Tx, to string, template Template, data any) error {
+	validatedTo, err := email.ValidateAddress(to)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := Render(template, data)
+	if err != nil {
+		return err
+	}
+
+	emailID, err := id.GetID()
+	if err != nil {
+		return fmt.Errorf("failed to generate email ID: %w", err)
+	}
+
+	now := time.Now()
+	outboxEmail := models.OutboxEmail{
+		ID:            emailID,
+		Recipient:     validatedTo,
+		Template:      string(template),
+		Subject:       rendered.Subject,
+		TextBody:      rendered.Text,
+		HTMLBody:      rendered.HTML,
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := repository.CreateOutboxEmail(ctx, tx, outboxEmail); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+
+	return nil
+}
+
+func newTransportFromConfig() (Transport, error) {
+	switch config.Env().MailTransport {
+	case config.MailTransportSMTP:
+		if config.Env().SMTPHost == "" {
+			return nil, errors.New("SMTP_HOST is required by the smtp mail transport")
+		}
+
+		settings, err := email.NewSMTPSettings(
+			config.Env().SMTPHost,
+			config.Env().SMTPPort,
+			config.Env().SMTPUsername,
+			config.Env().SMTPPassword,
+			config.Env().SMTPFrom,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return NewSMTPTransport(settings), nil
+	case config.MailTransportLog:
+		return NewLogTransport(config.Env().MailLogFile), nil
+	case config.MailTransportMemory:
+		return NewMemoryTransport(), nil
+	default:
+		return nil, fmt.Errorf("invalid mail transport: %s", config.Env().MailTransport)
+	}
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransport keeps sent messages in memory so tests can inspect them
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryTransport returns an empty in-memory transport
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+// Send stores the message
+func (t *MemoryTransport) Send(ctx context.Context, msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of every message sent so far
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Message(nil), t.messages...)
+}
+
+// MessagesTo returns the messages sent to the address
+func (t *MemoryTransport) MessagesTo(address string) []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var messages []Message
+	for _, msg := range t.messages {
+		if msg.To == address {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Reset drops every stored message
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = nil
+}
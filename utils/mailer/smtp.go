@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"ridash/utils/email"
+	"strings"
+	"time"
+)
+
+// SMTPTransport delivers messages through the configured SMTP server
+type SMTPTransport struct {
+	settings *email.SMTPSettings
+}
+
+// NewSMTPTransport returns a transport sending through the SMTP settings
+func NewSMTPTransport(settings *email.SMTPSettings) *SMTPTransport {
+	return &SMTPTransport{settings: settings}
+}
+
+// Send delivers the message as multipart/alternative with text and HTML parts
+func (t *SMTPTransport) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := buildMIMEMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if t.settings.Username != "" {
+		auth = t.settings.Auth()
+	}
+
+	if err := smtp.SendMail(t.settings.Address(), auth, msg.From, []string{msg.To}, body); err != nil {
+		return fmt.Errorf("failed to send email to %q: %w", msg.To, err)
+	}
+
+	return nil
+}
+
+// buildMIMEMessage encodes the headers and both bodies of the message
+func buildMIMEMessage(msg Message) ([]byte, error) {
+	boundaryBytes := make([]byte, 16)
+	if _, err := rand.Read(boundaryBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate MIME boundary: %w", err)
+	}
+	boundary := hex.EncodeToString(boundaryBytes)
+
+	var b strings.Builder
+	b.WriteString("From: " + msg.From + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: multipart/alternative; boundary=\"" + boundary + "\"\r\n")
+	b.WriteString("\r\n")
+
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n\r\n")
+	b.WriteString(msg.Text + "\r\n")
+
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n")
+	b.WriteString(msg.HTML + "\r\n")
+
+	b.WriteString("--" + boundary + "--\r\n")
+
+	return []byte(b.String()), nil
+}
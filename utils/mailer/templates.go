@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"ridash/utils/config"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Template names an email template in the templates directory
+type Template string
+
+// Template constants
+const (
+	TemplateInvitation        Template = "invitation"
+	TemplateEmailVerification Template = "email_verification"
+	TemplatePasswordReset     Template = "password_reset"
+	TemplateMagicLink         Template = "magic_link"
+	TemplateRefreshTokenReuse Template = "refresh_token_reuse"
+	TemplateAccountLocked     Template = "account_locked"
+)
+
+// InvitationData is rendered by TemplateInvitation
+type InvitationData struct {
+	TeamName    string
+	InviterName string
+	AcceptURL   string
+	ExpiresAt   time.Time
+}
+
+// EmailVerificationData is rendered by TemplateEmailVerification
+type EmailVerificationData struct {
+	DisplayName string
+	VerifyURL   string
+	ExpiresAt   time.Time
+}
+
+// PasswordResetData is rendered by TemplatePasswordReset
+type PasswordResetData struct {
+	DisplayName string
+	ResetURL    string
+	ExpiresAt   time.Time
+}
+
+// MagicLinkData is rendered by TemplateMagicLink
+type MagicLinkData struct {
+	LoginURL  string
+	ExpiresAt time.Time
+}
+
+// RefreshTokenReuseData is rendered by TemplateRefreshTokenReuse
+type RefreshTokenReuseData struct {
+	DisplayName string
+	IP          string
+	UserAgent   string
+	DetectedAt  time.Time
+}
+
+// AccountLockedData is rendered by TemplateAccountLocked
+type AccountLockedData struct {
+	DisplayName string
+	IP          string
+	UnlockURL   string
+	LockedUntil time.Time
+}
+
+// Rendered holds the subject and both bodies of a rendered template
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+var templateFuncs = map[string]any{
+	"appName": func() string { return config.Env().AppName },
+	"formatTime": func(t time.Time) string {
+		return t.UTC().Format(time.RFC1123)
+	},
+}
+
+// Render executes the subject, text and HTML templates of name with data.
+// The HTML body is wrapped in the shared layout.
+func Render(name Template, data any) (*Rendered, error) {
+	subject, err := renderText(string(name)+".subject.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := renderText(string(name)+".txt.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("layout.html.tmpl").Funcs(templateFuncs).ParseFS(templateFS,
+		"templates/layout.html.tmpl",
+		"templates/"+string(name)+".html.tmpl",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s html template: %w", name, err)
+	}
+
+	var html bytes.Buffer
+	if err := htmlTmpl.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s html template: %w", name, err)
+	}
+
+	return &Rendered{
+		// Subjects are single line headers
+		Subject: strings.Join(strings.Fields(subject), " "),
+		Text:    text,
+		HTML:    html.String(),
+	}, nil
+}
+
+func renderText(file string, data any) (string, error) {
+	tmpl, err := texttemplate.New(file).Funcs(templateFuncs).ParseFS(templateFS, "templates/"+file)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", file, err)
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,122 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"ridash/models"
+	"ridash/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// Run drains the outbox every poll interval until the context is cancelled
+func (m *Mailer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.ProcessOutbox(ctx); err != nil {
+			zap.L().Error("Failed to process email outbox", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOutbox delivers up to one batch of due emails and returns how many were sent.
+// Failed deliveries are retried with exponential backoff until the maximum number of attempts.
+func (m *Mailer) ProcessOutbox(ctx context.Context) (int, error) {
+	sent := 0
+	for i := 0; i < m.batchSize; i++ {
+		delivered, processed, err := m.deliverNext(ctx)
+		if err != nil {
+			return sent, err
+		}
+		if !processed {
+			break
+		}
+		if delivered {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// deliverNext sends the next due email and records the outcome in its own transaction,
+// so a failure later in the batch doesn't send the emails before it again
+func (m *Mailer) deliverNext(ctx context.Context) (delivered bool, processed bool, err error) {
+	tx, err := repository.StartTransaction(m.db, ctx)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer repository.DeferRollback(tx, ctx)
+
+	outboxEmail, err := repository.ClaimDueOutboxEmail(ctx, tx, time.Now())
+	if err != nil {
+		return false, false, fmt.Errorf("failed to claim due email: %w", err)
+	}
+	if outboxEmail == nil {
+		return false, false, nil
+	}
+
+	sendErr := m.transport.Send(ctx, Message{
+		From:    m.from,
+		To:      outboxEmail.Recipient,
+		Subject: outboxEmail.Subject,
+		Text:    outboxEmail.TextBody,
+		HTML:    outboxEmail.HTMLBody,
+	})
+
+	now := time.Now()
+	if sendErr == nil {
+		if err := repository.MarkOutboxEmailSent(ctx, tx, outboxEmail.ID, now); err != nil {
+			return false, false, fmt.Errorf("failed to mark email as sent: %w", err)
+		}
+	} else {
+		attempts := outboxEmail.Attempts + 1
+		status := models.EmailStatusPending
+		if attempts >= m.maxAttempts {
+			status = models.EmailStatusFailed
+		}
+
+		zap.L().Warn("Failed to deliver email",
+			zap.Error(sendErr),
+			zap.Int64("email_id", outboxEmail.ID),
+			zap.String("template", outboxEmail.Template),
+			zap.Int("attempts", attempts),
+			zap.String("status", string(status)))
+
+		if err := repository.MarkOutboxEmailFailed(ctx, tx, outboxEmail.ID, status, attempts, sendErr.Error(), now.Add(retryDelay(attempts)), now); err != nil {
+			return false, false, fmt.Errorf("failed to mark email as failed: %w", err)
+		}
+	}
+
+	if err := repository.CommitTransaction(tx, ctx); err != nil {
+		return false, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return sendErr == nil, true, nil
+}
+
+// retryDelay doubles the delay after every failed attempt, capped at retryMaxDelay
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,85 @@
+package mfa
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod = 30 // seconds
+	totpSkew   = 1  // steps accepted before and after the current one, for clock drift
+
+	// RecoveryCodeCount is how many recovery codes a user gets at once
+	RecoveryCodeCount = 10
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// GenerateTOTPKey creates a new TOTP secret, the key URL is the provisioning URI shown as a QR code
+func GenerateTOTPKey(accountName string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      config.Env().AppName,
+		AccountName: accountName,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+}
+
+// ValidateTOTP checks the code against the secret and returns the time step it belongs to.
+// Codes of lastUsedStep or earlier were already used and are rejected
+func ValidateTOTP(secret, code string, lastUsedStep *int64, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	currentStep := now.Unix() / totpPeriod
+
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := currentStep + offset
+		if lastUsedStep != nil && step <= *lastUsedStep {
+			continue
+		}
+
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCodes creates a set of recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		raw, err := encrypt.GenerateRandomString(10)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw = strings.ToLower(raw)
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+
+	return codes, nil
+}
+
+// HashRecoveryCode hashes the code the way it is stored, ignoring case, spaces and dashes
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return encrypt.HashToken(normalized)
+}
@@ -0,0 +1,284 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"ridash/models"
+	"ridash/repository"
+	"ridash/utils/config"
+	"ridash/utils/encrypt"
+	"ridash/utils/id"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// listenRetryDelay is the pause before listening again after the connection was lost
+const listenRetryDelay = 5 * time.Second
+
+// Store is the access token denylist. It is kept in memory so the middleware doesn't query Postgres,
+// every instance reloads it when Postgres notifies a change
+type Store struct {
+	db                  *pgxpool.Pool
+	accessTokenLifetime time.Duration
+	reloadInterval      time.Duration
+
+	mu       sync.RWMutex
+	tokens   map[string]struct{}        // Revoked jti
+	users    map[int64][]userRevocation // Tokens of the user issued before are revoked
+	disabled map[int64]struct{}         // Every token of the user is revoked
+}
+
+// userRevocation revokes the tokens of a user issued before a point in time, but the excepted one
+type userRevocation struct {
+	issuedBefore time.Time
+	exceptJTI    string
+}
+
+var defaultStore *Store
+
+// New constructs a store, call Reload to load the denylist
+func New(db *pgxpool.Pool, accessTokenLifetime time.Duration, reloadInterval time.Duration) *Store {
+	return &Store{
+		db:                  db,
+		accessTokenLifetime: accessTokenLifetime,
+		reloadInterval:      reloadInterval,
+		tokens:              map[string]struct{}{},
+		users:               map[int64][]userRevocation{},
+		disabled:            map[int64]struct{}{},
+	}
+}
+
+// Init constructs the default store from the config and loads the denylist
+func Init(db *pgxpool.Pool) (*Store, error) {
+	s := New(
+		db,
+		time.Duration(config.Env().AccessTokenExpiresAt)*time.Second,
+		time.Duration(config.Env().AccessTokenRevocationReloadInterval)*time.Second,
+	)
+
+	if err := s.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	defaultStore = s
+	return s, nil
+}
+
+// Default returns the store set up by Init. Panics if not initialized.
+func Default() *Store {
+	if defaultStore == nil {
+		panic("revocation store not initialized — call Init() first")
+	}
+	return defaultStore
+}
+
+// Reload replaces the denylist in memory with the one in Postgres
+func (s *Store) Reload(ctx context.Context) error {
+	tx, err := repository.StartTransaction(s.db, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer repository.DeferRollback(tx, ctx)
+
+	revocations, err := repository.ListActiveAccessTokenRevocations(ctx, tx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list access token revocations: %w", err)
+	}
+
+	disabledUserIDs, err := repository.ListDisabledUserIDs(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to list disabled users: %w", err)
+	}
+
+	if err := repository.CommitTransaction(tx, ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	tokens := make(map[string]struct{})
+	users := make(map[int64][]userRevocation)
+	for _, revocation := range revocations {
+		if revocation.JTI != nil {
+			tokens[*revocation.JTI] = struct{}{}
+			continue
+		}
+		userRevocation := userRevocation{issuedBefore: *revocation.IssuedBefore}
+		if revocation.ExceptJTI != nil {
+			userRevocation.exceptJTI = *revocation.ExceptJTI
+		}
+		users[*revocation.UserID] = append(users[*revocation.UserID], userRevocation)
+	}
+
+	disabled := make(map[int64]struct{}, len(disabledUserIDs))
+	for _, userID := range disabledUserIDs {
+		disabled[userID] = struct{}{}
+	}
+
+	s.mu.Lock()
+	s.tokens = tokens
+	s.users = users
+	s.disabled = disabled
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Run listens for changes until the context is cancelled, and prunes and reloads the denylist every reload interval
+// in case a notification was missed
+func (s *Store) Run(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.reloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := s.prune(ctx); err != nil {
+				zap.L().Error("Failed to prune access token revocations", zap.Error(err))
+			}
+			if err := s.Reload(ctx); err != nil {
+				zap.L().Error("Failed to reload access token revocations", zap.Error(err))
+			}
+		}
+	}()
+
+	for {
+		if err := s.listen(ctx); err != nil && ctx.Err() == nil {
+			zap.L().Error("Lost the access token revocations listener", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// listen reloads the denylist on every notification until the connection fails
+func (s *Store) listen(ctx context.Context) error {
+	pooled, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	// A listening connection can't go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{repository.AccessTokenRevocationsChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	// Changes made while nobody was listening would be missed otherwise
+	if err := s.Reload(ctx); err != nil {
+		return err
+	}
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+
+		if err := s.Reload(ctx); err != nil {
+			zap.L().Error("Failed to reload access token revocations", zap.Error(err))
+		}
+	}
+}
+
+// prune deletes the entries whose tokens have all expired
+func (s *Store) prune(ctx context.Context) error {
+	tx, err := repository.StartTransaction(s.db, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer repository.DeferRollback(tx, ctx)
+
+	if _, err := repository.DeleteExpiredAccessTokenRevocations(ctx, tx, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired access token revocations: %w", err)
+	}
+
+	return repository.CommitTransaction(tx, ctx)
+}
+
+// IsRevoked reports whether the access token of the user was revoked
+func (s *Store) IsRevoked(userID int64, claims encrypt.AccessTokenClaims) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.disabled[userID]; ok {
+		return true
+	}
+
+	if _, ok := s.tokens[claims.ID]; ok {
+		return true
+	}
+
+	for _, revocation := range s.users[userID] {
+		if claims.IssuedAt.Before(revocation.issuedBefore) && claims.ID != revocation.exceptJTI {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsUserDisabled reports whether the user was disabled, which also stops their personal access tokens
+func (s *Store) IsUserDisabled(userID int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.disabled[userID]
+	return ok
+}
+
+// RevokeToken denylists one access token inside the caller's transaction, call Reload once it is committed
+func (s *Store) RevokeToken(ctx context.Context, tx pgx.Tx, claims encrypt.AccessTokenClaims) error {
+	revocationID, err := id.GetID()
+	if err != nil {
+		return fmt.Errorf("failed to generate ID: %w", err)
+	}
+
+	return repository.CreateAccessTokenRevocation(ctx, tx, models.AccessTokenRevocation{
+		ID:        revocationID,
+		JTI:       &claims.ID,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		CreatedAt: time.Now(),
+	})
+}
+
+// RevokeUser denylists every access token of the user issued before now inside the caller's transaction,
+// call Reload once it is committed
+func (s *Store) RevokeUser(ctx context.Context, tx pgx.Tx, userID int64, now time.Time) error {
+	return s.revokeUser(ctx, tx, userID, now, nil)
+}
+
+// RevokeOtherTokens denylists every access token of the user issued before now but the one named by jti inside
+// the caller's transaction, call Reload once it is committed
+func (s *Store) RevokeOtherTokens(ctx context.Context, tx pgx.Tx, userID int64, jti string, now time.Time) error {
+	return s.revokeUser(ctx, tx, userID, now, &jti)
+}
+
+// revokeUser inserts the denylist entry of the tokens of the user issued before now
+func (s *Store) revokeUser(ctx context.Context, tx pgx.Tx, userID int64, now time.Time, exceptJTI *string) error {
+	revocationID, err := id.GetID()
+	if err != nil {
+		return fmt.Errorf("failed to generate ID: %w", err)
+	}
+
+	return repository.CreateAccessTokenRevocation(ctx, tx, models.AccessTokenRevocation{
+		ID:           revocationID,
+		UserID:       &userID,
+		IssuedBefore: &now,
+		ExceptJTI:    exceptJTI,
+		ExpiresAt:    now.Add(s.accessTokenLifetime),
+		CreatedAt:    now,
+	})
+}